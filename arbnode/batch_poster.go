@@ -28,6 +28,7 @@ import (
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rlp"
 
+	"github.com/offchainlabs/nitro/arbcompress"
 	"github.com/offchainlabs/nitro/arbnode/dataposter"
 	"github.com/offchainlabs/nitro/arbnode/dataposter/storage"
 	"github.com/offchainlabs/nitro/arbnode/redislock"
@@ -49,6 +50,7 @@ var (
 	batchPosterWalletBalance      = metrics.NewRegisteredGaugeFloat64("arb/batchposter/wallet/balanceether", nil)
 	batchPosterGasRefunderBalance = metrics.NewRegisteredGaugeFloat64("arb/batchposter/gasrefunder/balanceether", nil)
 	batchPosterSimpleRedisLockKey = "node.batch-poster.redis-lock.simple-lock-key"
+	batchPosterEigenDAFallbacks   = metrics.NewRegisteredCounter("arb/batchposter/eigenda/fallbacks", nil)
 )
 
 type batchPosterPosition struct {
@@ -73,6 +75,7 @@ type BatchPoster struct {
 	building            *buildingBatch
 	daWriter            das.DataAvailabilityServiceWriter
 	eigenDAWriter       eigenda.EigenDAWriter
+	eigenDAFailures     int // consecutive failures to disperse a batch to EigenDA
 	dataPoster          *dataposter.DataPoster
 	redisLock           *redislock.Simple
 	firstEphemeralError time.Time // first time a continuous error suspected to be ephemeral occurred
@@ -87,6 +90,8 @@ type BatchPoster struct {
 	nextRevertCheckBlock int64       // the last parent block scanned for reverting batches
 
 	accessList func(SequencerInboxAccs, AfterDelayedMessagesRead int) types.AccessList
+
+	arbOSCompressionLevel func() (uint64, error)
 }
 
 type l1BlockBound int
@@ -101,6 +106,15 @@ const (
 	l1BlockBoundIgnore
 )
 
+type eigenDAPostingMode int
+
+const (
+	// Post only the EigenDA ref to L1
+	eigenDAPostingModeEigenDA eigenDAPostingMode = iota + 1
+	// Post the EigenDA ref along with the raw batch as calldata, e.g. while migrating to EigenDA
+	eigenDAPostingModeDual
+)
+
 type BatchPosterConfig struct {
 	Enable                                 bool `koanf:"enable"`
 	DisableDasFallbackStoreDataOnChain     bool `koanf:"disable-das-fallback-store-data-on-chain" reload:"hot"`
@@ -125,9 +139,13 @@ type BatchPosterConfig struct {
 	ParentChainWallet  genericconf.WalletConfig    `koanf:"parent-chain-wallet"`
 	L1BlockBound       string                      `koanf:"l1-block-bound" reload:"hot"`
 	L1BlockBoundBypass time.Duration               `koanf:"l1-block-bound-bypass" reload:"hot"`
+	EigenDAPostingMode string                      `koanf:"eigenda-posting-mode" reload:"hot"`
+	// Consecutive EigenDA dispersal failures to retry through before posting batches as calldata.
+	EigenDAFallbackAfterFailures int `koanf:"eigenda-fallback-after-failures" reload:"hot"`
 
-	gasRefunder  common.Address
-	l1BlockBound l1BlockBound
+	gasRefunder        common.Address
+	l1BlockBound       l1BlockBound
+	eigenDAPostingMode eigenDAPostingMode
 }
 
 func (c *BatchPosterConfig) Validate() error {
@@ -151,6 +169,16 @@ func (c *BatchPosterConfig) Validate() error {
 	} else {
 		return fmt.Errorf("invalid L1 block bound tag \"%v\" (see --help for options)", c.L1BlockBound)
 	}
+	if c.EigenDAPostingMode == "" || c.EigenDAPostingMode == "eigenda" {
+		c.eigenDAPostingMode = eigenDAPostingModeEigenDA
+	} else if c.EigenDAPostingMode == "dual" {
+		c.eigenDAPostingMode = eigenDAPostingModeDual
+	} else {
+		return fmt.Errorf("invalid EigenDA posting mode \"%v\" (see --help for options)", c.EigenDAPostingMode)
+	}
+	if c.EigenDAFallbackAfterFailures < 0 {
+		return errors.New("EigenDA fallback failure count must not be negative")
+	}
 	return nil
 }
 
@@ -159,6 +187,7 @@ type BatchPosterConfigFetcher func() *BatchPosterConfig
 func BatchPosterConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.Bool(prefix+".enable", DefaultBatchPosterConfig.Enable, "enable posting batches to l1")
 	f.Bool(prefix+".disable-das-fallback-store-data-on-chain", DefaultBatchPosterConfig.DisableDasFallbackStoreDataOnChain, "If unable to batch to DAS, disable fallback storing data on chain")
+	f.Bool(prefix+".disable-eigenda-fallback-store-data-on-chain", DefaultBatchPosterConfig.DisableEigenDAFallbackStoreDataOnChain, "If unable to batch to EigenDA, disable fallback storing data on chain")
 	f.Int(prefix+".max-size", DefaultBatchPosterConfig.MaxSize, "maximum batch size")
 	f.Duration(prefix+".max-delay", DefaultBatchPosterConfig.MaxDelay, "maximum batch posting delay")
 	f.Bool(prefix+".wait-for-max-delay", DefaultBatchPosterConfig.WaitForMaxDelay, "wait for the max batch delay, even if the batch is full")
@@ -171,6 +200,8 @@ func BatchPosterConfigAddOptions(prefix string, f *pflag.FlagSet) {
 	f.String(prefix+".redis-url", DefaultBatchPosterConfig.RedisUrl, "if non-empty, the Redis URL to store queued transactions in")
 	f.String(prefix+".l1-block-bound", DefaultBatchPosterConfig.L1BlockBound, "only post messages to batches when they're within the max future block/timestamp as of this L1 block tag (\"safe\", \"finalized\", \"latest\", or \"ignore\" to ignore this check)")
 	f.Duration(prefix+".l1-block-bound-bypass", DefaultBatchPosterConfig.L1BlockBoundBypass, "post batches even if not within the layer 1 future bounds if we're within this margin of the max delay")
+	f.String(prefix+".eigenda-posting-mode", DefaultBatchPosterConfig.EigenDAPostingMode, "how batches are posted when EigenDA is enabled (\"eigenda\" to post only the EigenDA ref, or \"dual\" to also post the batch as calldata)")
+	f.Int(prefix+".eigenda-fallback-after-failures", DefaultBatchPosterConfig.EigenDAFallbackAfterFailures, "how many consecutive EigenDA dispersal failures to retry before falling back to posting batches as calldata")
 	redislock.AddConfigOptions(prefix+".redis-lock", f)
 	dataposter.DataPosterConfigAddOptions(prefix+".data-poster", f, dataposter.DefaultDataPosterConfig)
 	genericconf.WalletConfigAddOptions(prefix+".parent-chain-wallet", f, DefaultBatchPosterConfig.ParentChainWallet.Pathname)
@@ -194,6 +225,9 @@ var DefaultBatchPosterConfig = BatchPosterConfig{
 	L1BlockBound:       "",
 	L1BlockBoundBypass: time.Hour,
 	RedisLock:          redislock.DefaultCfg,
+	EigenDAPostingMode: "eigenda",
+	// Each failure may have waited out the dispersal timeout, so don't hold batches up for long
+	EigenDAFallbackAfterFailures: 2,
 }
 
 var DefaultBatchPosterL1WalletConfig = genericconf.WalletConfig{
@@ -232,6 +266,9 @@ type BatchPosterOpts struct {
 	TransactOpts  *bind.TransactOpts
 	DAWriter      das.DataAvailabilityServiceWriter
 	EigenDAWriter eigenda.EigenDAWriter
+	// ArbOSCompressionLevel optionally reads the brotli level ArbOS prices transactions at, which batches won't
+	// be compressed below
+	ArbOSCompressionLevel func() (uint64, error)
 }
 
 func NewBatchPoster(ctx context.Context, opts *BatchPosterOpts) (*BatchPoster, error) {
@@ -278,6 +315,8 @@ func NewBatchPoster(ctx context.Context, opts *BatchPosterOpts) (*BatchPoster, e
 		daWriter:        opts.DAWriter,
 		eigenDAWriter:   opts.EigenDAWriter,
 		redisLock:       redisLock,
+
+		arbOSCompressionLevel: opts.ArbOSCompressionLevel,
 	}
 	b.messagesPerBatch, err = arbmath.NewMovingAverage[uint64](20)
 	if err != nil {
@@ -533,7 +572,9 @@ type buildingBatch struct {
 	haveUsefulMessage bool
 }
 
-func newBatchSegments(firstDelayed uint64, config *BatchPosterConfig, backlog uint64) *batchSegments {
+// newBatchSegments picks compression levels for a new batch, trading compression for speed while backlogged,
+// though never compressing below minCompressionLevel
+func newBatchSegments(firstDelayed uint64, config *BatchPosterConfig, backlog uint64, minCompressionLevel int) *batchSegments {
 	compressedBuffer := bytes.NewBuffer(make([]byte, 0, config.MaxSize*2))
 	if config.MaxSize <= 40 {
 		panic("MaxBatchSize too small")
@@ -549,6 +590,8 @@ func newBatchSegments(firstDelayed uint64, config *BatchPosterConfig, backlog ui
 	if backlog > 60 {
 		compressionLevel = arbmath.MinInt(compressionLevel, 4)
 	}
+	compressionLevel = arbmath.MaxInt(compressionLevel, minCompressionLevel)
+	recompressionLevel = arbmath.MaxInt(recompressionLevel, minCompressionLevel)
 	if recompressionLevel < compressionLevel {
 		// This should never be possible
 		log.Warn(
@@ -568,6 +611,20 @@ func newBatchSegments(firstDelayed uint64, config *BatchPosterConfig, backlog ui
 	}
 }
 
+// minCompressionLevel is the brotli level ArbOS prices transactions at, so that every poster compresses at least
+// as well as the chain assumes
+func (b *BatchPoster) minCompressionLevel() int {
+	if b.arbOSCompressionLevel == nil {
+		return 0
+	}
+	level, err := b.arbOSCompressionLevel()
+	if err != nil {
+		log.Warn("failed to read the ArbOS brotli compression level", "err", err)
+		return 0
+	}
+	return int(arbmath.MinInt(level, arbcompress.LEVEL_WELL))
+}
+
 func (s *batchSegments) recompressAll() error {
 	s.compressedBuffer = bytes.NewBuffer(make([]byte, 0, s.sizeLimit*2))
 	s.compressedWriter = brotli.NewWriterLevel(s.compressedBuffer, s.recompressionLevel)
@@ -754,6 +811,43 @@ func (s *batchSegments) CloseAndGetBytes() ([]byte, error) {
 	return fullMsg, nil
 }
 
+// eigenDASequencerMessage disperses the batch to EigenDA, encoded by EncodeForEigenDA, and returns what should be
+// posted to L1 in its place.
+// If dispersal keeps failing past EigenDAFallbackAfterFailures, the batch is posted unchanged as calldata, which the
+// inbox reads like any other calldata batch, until a dispersal succeeds again. Dual posted batches already carry
+// their calldata, so they fall back at the first failure.
+func (b *BatchPoster) eigenDASequencerMessage(ctx context.Context, config *BatchPosterConfig, sequencerMsg []byte) ([]byte, error) {
+	daRef, err := b.eigenDAWriter.Store(ctx, eigenda.EncodeForEigenDA(sequencerMsg))
+	if err != nil {
+		if config.DisableEigenDAFallbackStoreDataOnChain {
+			return nil, fmt.Errorf("unable to post batch to EigenDA and fallback storing data on chain is disabled: %w", err)
+		}
+		b.eigenDAFailures++
+		if config.eigenDAPostingMode != eigenDAPostingModeDual && b.eigenDAFailures <= config.EigenDAFallbackAfterFailures {
+			return nil, fmt.Errorf("failed to post batch to EigenDA (failure %v of %v before falling back to calldata): %w", b.eigenDAFailures, config.EigenDAFallbackAfterFailures, err)
+		}
+		log.Warn("Falling back to storing data on chain", "err", err, "failures", b.eigenDAFailures)
+		batchPosterEigenDAFallbacks.Inc(1)
+		return sequencerMsg, nil
+	}
+	b.eigenDAFailures = 0
+
+	if config.eigenDAPostingMode == eigenDAPostingModeDual {
+		log.Info("EigenDA transaction receipt(data pointer), also posting batch as calldata: ", "hash", hex.EncodeToString(daRef.BatchHeaderHash), "index", daRef.BlobIndex)
+		dualMsg, err := eigenda.SerializeDualBatch(daRef, sequencerMsg)
+		if err != nil {
+			return nil, fmt.Errorf("dual batch serialization failed: %w", err)
+		}
+		return dualMsg, nil
+	}
+	pointer, err := b.eigenDAWriter.Serialize(daRef)
+	if err != nil {
+		return nil, fmt.Errorf("DaRef serialization failed: %w", err)
+	}
+	log.Info("EigenDA transaction receipt(data pointer): ", "hash", hex.EncodeToString(daRef.BatchHeaderHash), "index", daRef.BlobIndex)
+	return pointer, nil
+}
+
 func (b *BatchPoster) encodeAddBatch(seqNum *big.Int, prevMsgNum arbutil.MessageIndex, newMsgNum arbutil.MessageIndex, message []byte, delayedMsg uint64) ([]byte, error) {
 	method, ok := b.seqInboxABI.Methods["addSequencerL2BatchFromOrigin0"]
 	if !ok {
@@ -862,7 +956,7 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 
 	if b.building == nil || b.building.startMsgCount != batchPosition.MessageCount {
 		b.building = &buildingBatch{
-			segments:      newBatchSegments(batchPosition.DelayedMessageCount, b.config(), b.GetBacklogEstimate()),
+			segments:      newBatchSegments(batchPosition.DelayedMessageCount, b.config(), b.GetBacklogEstimate(), b.minCompressionLevel()),
 			msgCount:      batchPosition.MessageCount,
 			startMsgCount: batchPosition.MessageCount,
 		}
@@ -1041,22 +1135,10 @@ func (b *BatchPoster) maybePostSequencerBatch(ctx context.Context) (bool, error)
 	}
 
 	if b.daWriter == nil && b.eigenDAWriter != nil {
-		log.Info("Start to write data to eigenda: ", "data", hex.EncodeToString(sequencerMsg))
-		daRef, err := b.eigenDAWriter.Store(ctx, sequencerMsg)
+		sequencerMsg, err = b.eigenDASequencerMessage(ctx, config, sequencerMsg)
 		if err != nil {
-			if config.DisableEigenDAFallbackStoreDataOnChain {
-				log.Warn("Falling back to storing data on chain", "err", err)
-				return false, errors.New("unable to post batch to EigenDA and fallback storing data on chain is disabled")
-			}
-		}
-
-		pointer, err := b.eigenDAWriter.Serialize(daRef)
-		if err != nil {
-			log.Warn("DaRef serialization failed", "err", err)
-			return false, errors.New("DaRef serialization failed")
+			return false, err
 		}
-		log.Info("EigenDA transaction receipt(data pointer): ", "hash", hex.EncodeToString(daRef.BatchHeaderHash), "index", daRef.BlobIndex)
-		sequencerMsg = pointer
 	}
 
 	data, err := b.encodeAddBatch(new(big.Int).SetUint64(batchPosition.NextSeqNum), batchPosition.MessageCount, b.building.msgCount, sequencerMsg, b.building.segments.delayedMsg)
@@ -0,0 +1,160 @@
+// Copyright 2024-2024, Alt Research, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Layr-Labs/eigenda/api/grpc/disperser"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbcompress"
+	"github.com/offchainlabs/nitro/arbos/arbostypes"
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/das/eigenda"
+)
+
+// unreliableEigenDA fails the given number of dispersals before accepting blobs again, and can't be read from
+type unreliableEigenDA struct {
+	failures int
+	queries  int
+}
+
+func (d *unreliableEigenDA) Store(ctx context.Context, data []byte) (*eigenda.EigenDARef, error) {
+	if d.failures > 0 {
+		d.failures--
+		return nil, errors.New("disperser unavailable")
+	}
+	return &eigenda.EigenDARef{BatchHeaderHash: crypto.Keccak256(data), BlobIndex: 1, Certificate: &disperser.BlobInfo{}}, nil
+}
+
+func (d *unreliableEigenDA) Serialize(ref *eigenda.EigenDARef) ([]byte, error) {
+	return (&eigenda.EigenDA{}).Serialize(ref)
+}
+
+func (d *unreliableEigenDA) QueryBlob(ctx context.Context, ref *eigenda.EigenDARef) ([]byte, error) {
+	d.queries++
+	return nil, errors.New("disperser unavailable")
+}
+
+// singleBatchBackend serves one sequencer batch to the inbox multiplexer
+type singleBatchBackend struct {
+	batch                 []byte
+	batchSeqNum           uint64
+	positionWithinMessage uint64
+}
+
+func (b *singleBatchBackend) PeekSequencerInbox() ([]byte, error) {
+	if b.batchSeqNum != 0 {
+		return nil, errors.New("reading unknown sequencer batch")
+	}
+	return b.batch, nil
+}
+
+func (b *singleBatchBackend) GetSequencerInboxPosition() uint64   { return b.batchSeqNum }
+func (b *singleBatchBackend) AdvanceSequencerInbox()              { b.batchSeqNum++ }
+func (b *singleBatchBackend) GetPositionWithinMessage() uint64    { return b.positionWithinMessage }
+func (b *singleBatchBackend) SetPositionWithinMessage(pos uint64) { b.positionWithinMessage = pos }
+
+func (b *singleBatchBackend) ReadDelayedInbox(seqNum uint64) (*arbostypes.L1IncomingMessage, error) {
+	return nil, errors.New("reading unknown delayed message")
+}
+
+func TestEigenDAFallbackToCalldata(t *testing.T) {
+	ctx := context.Background()
+	l2Messages := [][]byte{[]byte("first message"), []byte("second message")}
+	var rawSegments []byte
+	for _, l2Message := range l2Messages {
+		segment, err := rlp.EncodeToBytes(append([]byte{arbstate.BatchSegmentKindL2Message}, l2Message...))
+		Require(t, err)
+		rawSegments = append(rawSegments, segment...)
+	}
+	compressed, err := arbcompress.CompressWell(rawSegments)
+	Require(t, err)
+	sequencerMsg := append([]byte{arbstate.BrotliMessageHeaderByte}, compressed...)
+
+	// readBack parses what was posted as the inbox would, with the L1 header the sequencer inbox adds
+	readBack := func(posted []byte, daReader eigenda.EigenDAReader) {
+		t.Helper()
+		header := make([]byte, 40)
+		binary.BigEndian.PutUint64(header[8:16], math.MaxUint64)
+		binary.BigEndian.PutUint64(header[24:32], math.MaxUint64)
+		backend := &singleBatchBackend{batch: append(header, posted...)}
+		multiplexer := arbstate.NewInboxMultiplexer(backend, 0, nil, daReader, arbstate.KeysetValidate)
+		for i, l2Message := range l2Messages {
+			msg, err := multiplexer.Pop(ctx)
+			Require(t, err)
+			if msg == nil || !bytes.Equal(msg.Message.L2msg, l2Message) {
+				Fail(t, "message", i, "read back as", msg)
+			}
+		}
+	}
+
+	config := DefaultBatchPosterConfig
+	config.EigenDAFallbackAfterFailures = 2
+	Require(t, config.Validate())
+	disperser := &unreliableEigenDA{failures: 4}
+	poster := &BatchPoster{eigenDAWriter: disperser}
+
+	// failures within the budget are retried by the poster loop
+	for i := 0; i < config.EigenDAFallbackAfterFailures; i++ {
+		if _, err := poster.eigenDASequencerMessage(ctx, &config, sequencerMsg); err == nil {
+			Fail(t, "fell back to calldata after only", i+1, "failures")
+		}
+	}
+
+	// past it, and for as long as the outage lasts, batches are posted unchanged as calldata
+	for i := 0; i < 2; i++ {
+		posted, err := poster.eigenDASequencerMessage(ctx, &config, sequencerMsg)
+		Require(t, err)
+		if !bytes.Equal(posted, sequencerMsg) {
+			Fail(t, "fallback didn't post the batch as calldata")
+		}
+		readBack(posted, disperser)
+	}
+	if disperser.queries != 0 {
+		Fail(t, "reading a calldata batch queried EigenDA")
+	}
+
+	// once dispersal works again, the EigenDA ref is posted and the failure count starts over
+	posted, err := poster.eigenDASequencerMessage(ctx, &config, sequencerMsg)
+	Require(t, err)
+	if len(posted) == 0 || posted[0] != eigenda.EigenDACertifiedMessageHeaderFlag {
+		Fail(t, "recovered dispersal didn't post an EigenDA ref")
+	}
+	disperser.failures = 1
+	if _, err := poster.eigenDASequencerMessage(ctx, &config, sequencerMsg); err == nil {
+		Fail(t, "failure count wasn't reset by a successful dispersal")
+	}
+
+	// dual posted batches carry their calldata anyway, so they fall back right away
+	dualConfig := config
+	dualConfig.EigenDAPostingMode = "dual"
+	Require(t, dualConfig.Validate())
+	disperser = &unreliableEigenDA{failures: 1}
+	poster = &BatchPoster{eigenDAWriter: disperser}
+	posted, err = poster.eigenDASequencerMessage(ctx, &dualConfig, sequencerMsg)
+	Require(t, err)
+	if !bytes.Equal(posted, sequencerMsg) {
+		Fail(t, "dual posting didn't fall back to calldata")
+	}
+	readBack(posted, disperser)
+
+	// unless falling back is disabled
+	noFallbackConfig := config
+	noFallbackConfig.DisableEigenDAFallbackStoreDataOnChain = true
+	disperser = &unreliableEigenDA{failures: 10}
+	poster = &BatchPoster{eigenDAWriter: disperser}
+	for i := 0; i <= config.EigenDAFallbackAfterFailures+1; i++ {
+		if _, err := poster.eigenDASequencerMessage(ctx, &noFallbackConfig, sequencerMsg); err == nil {
+			Fail(t, "fell back to calldata when it's disabled")
+		}
+	}
+}
@@ -119,6 +119,9 @@ func (c *Config) Validate() error {
 	if err := c.Staker.Validate(); err != nil {
 		return err
 	}
+	if err := c.EigenDA.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -144,6 +147,7 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet, feedInputEnable bool, feed
 	staker.L1ValidatorConfigAddOptions(prefix+".staker", f)
 	SeqCoordinatorConfigAddOptions(prefix+".seq-coordinator", f)
 	das.DataAvailabilityConfigAddNodeOptions(prefix+".data-availability", f)
+	eigenda.EigenDAConfigAddOptions(prefix+".eigen-da", f)
 	SyncMonitorConfigAddOptions(prefix+".sync-monitor", f)
 	DangerousConfigAddOptions(prefix+".dangerous", f)
 	TransactionStreamerConfigAddOptions(prefix+".transaction-streamer", f)
@@ -162,6 +166,7 @@ var ConfigDefault = Config{
 	Staker:              staker.DefaultL1ValidatorConfig,
 	SeqCoordinator:      DefaultSeqCoordinatorConfig,
 	DataAvailability:    das.DefaultDataAvailabilityConfig,
+	EigenDA:             eigenda.DefaultEigenDAConfig,
 	SyncMonitor:         DefaultSyncMonitorConfig,
 	Dangerous:           DefaultDangerousConfig,
 	TransactionStreamer: DefaultTransactionStreamerConfig,
@@ -512,7 +517,11 @@ func createNodeImpl(
 	} else if l2Config.ArbitrumChainParams.DataAvailabilityCommittee {
 		return nil, errors.New("a data availability service is required for this chain, but it was not configured")
 	} else if config.EigenDA.Enable {
-		eigenDAService, err := eigenda.NewEigenDA(config.EigenDA.Rpc)
+		var eigenDAL1Reader eigenda.L1HeaderReader
+		if l1Reader != nil {
+			eigenDAL1Reader = l1Reader
+		}
+		eigenDAService, err := eigenda.NewEigenDA(&config.EigenDA, eigenDAL1Reader)
 		if err != nil {
 			return nil, err
 		}
@@ -645,17 +654,22 @@ func createNodeImpl(
 		if txOptsBatchPoster == nil && config.BatchPoster.DataPoster.ExternalSigner.URL == "" {
 			return nil, errors.New("batchposter, but no TxOpts")
 		}
+		var arbOSCompressionLevel func() (uint64, error)
+		if execNode, ok := exec.(*gethexec.ExecutionNode); ok {
+			arbOSCompressionLevel = execNode.ExecEngine.BrotliCompressionLevel
+		}
 		batchPoster, err = NewBatchPoster(ctx, &BatchPosterOpts{
-			DataPosterDB:  rawdb.NewTable(arbDb, storage.BatchPosterPrefix),
-			L1Reader:      l1Reader,
-			Inbox:         inboxTracker,
-			Streamer:      txStreamer,
-			SyncMonitor:   syncMonitor,
-			Config:        func() *BatchPosterConfig { return &configFetcher.Get().BatchPoster },
-			DeployInfo:    deployInfo,
-			TransactOpts:  txOptsBatchPoster,
-			DAWriter:      daWriter,
-			EigenDAWriter: eigenDAWriter,
+			DataPosterDB:          rawdb.NewTable(arbDb, storage.BatchPosterPrefix),
+			L1Reader:              l1Reader,
+			Inbox:                 inboxTracker,
+			Streamer:              txStreamer,
+			SyncMonitor:           syncMonitor,
+			Config:                func() *BatchPosterConfig { return &configFetcher.Get().BatchPoster },
+			DeployInfo:            deployInfo,
+			TransactOpts:          txOptsBatchPoster,
+			DAWriter:              daWriter,
+			EigenDAWriter:         eigenDAWriter,
+			ArbOSCompressionLevel: arbOSCompressionLevel,
 		})
 		if err != nil {
 			return nil, err
@@ -7,6 +7,7 @@ package addressSet
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/offchainlabs/nitro/arbos/storage"
@@ -43,6 +44,19 @@ func (as *AddressSet) IsMember(addr common.Address) (bool, error) {
 	return value != (common.Hash{}), err
 }
 
+// At returns the member at the given position, counting from 0, in the order kept in storage.
+// Removing a member moves the last member into its position.
+func (as *AddressSet) At(index uint64) (common.Address, error) {
+	size, err := as.size.Get()
+	if err != nil {
+		return common.Address{}, err
+	}
+	if index >= size {
+		return common.Address{}, fmt.Errorf("index %v out of range for an address set of size %v", index, size)
+	}
+	return as.backingStorage.OpenStorageBackedAddress(index + 1).Get()
+}
+
 func (as *AddressSet) GetAnyMember() (*common.Address, error) {
 	size, err := as.size.Get()
 	if err != nil || size == 0 {
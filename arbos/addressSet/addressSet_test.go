@@ -176,6 +176,52 @@ func TestAddressSetAllMembers(t *testing.T) {
 	}
 }
 
+func TestAddressSetAt(t *testing.T) {
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	Require(t, Initialize(sto))
+	aset := OpenAddressSet(sto)
+	version := params.ArbitrumDevTestParams().InitialArbOSVersion
+
+	checkOrder := func(expected ...common.Address) {
+		t.Helper()
+		if size(t, aset) != uint64(len(expected)) {
+			Fail(t, "set has size", size(t, aset), "instead of", len(expected))
+		}
+		for i, addr := range expected {
+			member, err := aset.At(uint64(i))
+			Require(t, err)
+			if member != addr {
+				Fail(t, "member", i, "is", member, "instead of", addr)
+			}
+		}
+		if _, err := aset.At(uint64(len(expected))); err == nil {
+			Fail(t, "read past the end of the set")
+		}
+	}
+
+	addr1 := testhelpers.RandomAddress()
+	addr2 := testhelpers.RandomAddress()
+	addr3 := testhelpers.RandomAddress()
+	checkOrder()
+
+	// members are kept in the order they were added, and adding one twice doesn't duplicate it
+	Require(t, aset.Add(addr1))
+	Require(t, aset.Add(addr2))
+	Require(t, aset.Add(addr1))
+	Require(t, aset.Add(addr3))
+	checkOrder(addr1, addr2, addr3)
+
+	// removing a member moves the last one into its place
+	Require(t, aset.Remove(addr1, version))
+	checkOrder(addr3, addr2)
+	Require(t, aset.Remove(addr1, version))
+	checkOrder(addr3, addr2)
+	Require(t, aset.Remove(addr2, version))
+	checkOrder(addr3)
+	Require(t, aset.Add(addr1))
+	checkOrder(addr3, addr1)
+}
+
 func TestRectifyMappingAgainstHistory(t *testing.T) {
 	db := storage.NewMemoryBackedStateDB()
 	sto := storage.NewGeth(db, burn.NewSystemBurner(nil, false))
@@ -52,6 +52,10 @@ type ArbosState struct {
 	genesisBlockNum        storage.StorageBackedUint64
 	infraFeeAccount        storage.StorageBackedAddress
 	brotliCompressionLevel storage.StorageBackedUint64 // brotli compression level used for pricing
+	contractCount          storage.StorageBackedUint64 // contracts deployed by top-level txs since ArbOS 20
+	accountCount           storage.StorageBackedUint64 // accounts created by top-level txs since ArbOS 20
+	simulationGasLimit     storage.StorageBackedUint64 // gas ceiling for NodeInterface simulations, or 0 for the default
+	methodGasOverrides     storage.StorageBackedUint64 // number of precompile methods whose gas is overridden
 	backingStorage         *storage.Storage
 	Burner                 burn.Burner
 }
@@ -85,6 +89,10 @@ func OpenArbosState(stateDB vm.StateDB, burner burn.Burner) (*ArbosState, error)
 		backingStorage.OpenStorageBackedUint64(uint64(genesisBlockNumOffset)),
 		backingStorage.OpenStorageBackedAddress(uint64(infraFeeAccountOffset)),
 		backingStorage.OpenStorageBackedUint64(uint64(brotliCompressionLevelOffset)),
+		backingStorage.OpenStorageBackedUint64(uint64(contractCountOffset)),
+		backingStorage.OpenStorageBackedUint64(uint64(accountCountOffset)),
+		backingStorage.OpenStorageBackedUint64(uint64(simulationGasLimitOffset)),
+		backingStorage.OpenStorageBackedUint64(uint64(methodGasOverridesOffset)),
 		backingStorage,
 		burner,
 	}, nil
@@ -132,6 +140,32 @@ func ArbOSVersion(stateDB vm.StateDB) uint64 {
 	return arbosVersion
 }
 
+// PrecompileMethodGasOverridden returns whether the gas of any precompile method is overridden. Like the
+// version, it's read for free, so that calls to chains without overrides don't pay for looking them up.
+func PrecompileMethodGasOverridden(stateDB vm.StateDB) bool {
+	backingStorage := storage.NewGeth(stateDB, burn.NewSystemBurner(nil, false))
+	overrides, err := backingStorage.GetUint64ByUint64(uint64(methodGasOverridesOffset))
+	if err != nil {
+		log.Crit("failed to get the number of precompile gas overrides", "error", err)
+	}
+	return overrides != 0
+}
+
+// PrecompileMethodGas returns the minimum gas the precompile method is charged, where 0 means it isn't
+// overridden. The read is charged to the burner, which is usually the precompile call itself.
+func PrecompileMethodGas(stateDB vm.StateDB, burner burn.Burner, precompile common.Address, method [4]byte) (uint64, error) {
+	backingStorage := storage.NewGeth(stateDB, burner)
+	return openPrecompileMethodGas(backingStorage).GetUint64(precompileMethodGasKey(precompile, method))
+}
+
+func openPrecompileMethodGas(backingStorage *storage.Storage) *storage.Storage {
+	return backingStorage.OpenCachedSubStorage(precompileMethodGasSubspace)
+}
+
+func precompileMethodGasKey(precompile common.Address, method [4]byte) common.Hash {
+	return common.BytesToHash(append(precompile.Bytes(), method[:]...))
+}
+
 type Offset uint64
 
 const (
@@ -143,6 +177,10 @@ const (
 	genesisBlockNumOffset
 	infraFeeAccountOffset
 	brotliCompressionLevelOffset
+	contractCountOffset
+	accountCountOffset
+	simulationGasLimitOffset
+	methodGasOverridesOffset
 )
 
 type SubspaceID []byte
@@ -156,6 +194,8 @@ var (
 	sendMerkleSubspace   SubspaceID = []byte{5}
 	blockhashesSubspace  SubspaceID = []byte{6}
 	chainConfigSubspace  SubspaceID = []byte{7}
+
+	precompileMethodGasSubspace SubspaceID = []byte{8}
 )
 
 // Returns a list of precompiles that only appear in Arbitrum chains (i.e. ArbOS precompiles) at the genesis block
@@ -400,6 +440,182 @@ func (state *ArbosState) SetBrotliCompressionLevel(val uint64) error {
 	return errors.New("invalid brotli compression level")
 }
 
+// ContractCount is how many contracts have been deployed by top-level txs since ArbOS 20
+func (state *ArbosState) ContractCount() (uint64, error) {
+	return state.contractCount.Get()
+}
+
+func (state *ArbosState) IncrementContractCount() error {
+	_, err := state.contractCount.Increment()
+	return err
+}
+
+// AccountCount is how many empty accounts have been given a balance or code by top-level txs since ArbOS 20
+func (state *ArbosState) AccountCount() (uint64, error) {
+	return state.accountCount.Get()
+}
+
+func (state *ArbosState) IncrementAccountCount() error {
+	_, err := state.accountCount.Increment()
+	return err
+}
+
+// DefaultSimulationGasLimit bounds simulations until the chain owner sets a limit
+const DefaultSimulationGasLimit = 50_000_000
+
+// SimulationGasLimit is the most gas NodeInterface methods, and ArbGasInfo's gas estimates, may give the executions
+// they simulate. Since the latter run on-chain, changing it affects consensus.
+func (state *ArbosState) SimulationGasLimit() (uint64, error) {
+	limit, err := state.simulationGasLimit.Get()
+	if limit == 0 || err != nil {
+		return DefaultSimulationGasLimit, err
+	}
+	return limit, nil
+}
+
+// SetSimulationGasLimit sets the most gas simulations may use, where 0 restores the default
+func (state *ArbosState) SetSimulationGasLimit(limit uint64) error {
+	return state.simulationGasLimit.Set(limit)
+}
+
+// The optional ArbOS features reported by FeatureFlags, one bit each. All of them need at least ArbOS 20.
+const (
+	// FeatureL2Retryables is set when retryables can be submitted from L2 with ArbRetryableTx.SubmitRetryableFromL2
+	FeatureL2Retryables uint64 = 1 << iota
+	// FeatureCollectTips is set when priority fees are paid to the infra or network fee account instead of dropped
+	FeatureCollectTips
+	// FeatureRetryableCreatorAllowlist is set when only allowed senders may create retryables
+	FeatureRetryableCreatorAllowlist
+	// FeatureRetryableRateLimit is set when the number of retryables created in each block is limited
+	FeatureRetryableRateLimit
+	// FeatureRetryableGracePeriod is set when expired retryables can be revived during a grace period
+	FeatureRetryableGracePeriod
+)
+
+// FeatureFlags is the bitmask of optional features enabled at the current ArbOS version and configuration
+func (state *ArbosState) FeatureFlags() (uint64, error) {
+	if state.arbosVersion < 20 {
+		return 0, nil
+	}
+	flags := FeatureL2Retryables
+	collectTips, err := state.L2PricingState().CollectTips()
+	if err != nil {
+		return 0, err
+	}
+	allowlist, err := state.RetryableState().CreatorAllowlistEnabled()
+	if err != nil {
+		return 0, err
+	}
+	maxPerBlock, err := state.RetryableState().MaxRetryablesPerBlock()
+	if err != nil {
+		return 0, err
+	}
+	gracePeriod, err := state.RetryableState().ExpiryGracePeriod()
+	if err != nil {
+		return 0, err
+	}
+	if collectTips {
+		flags |= FeatureCollectTips
+	}
+	if allowlist {
+		flags |= FeatureRetryableCreatorAllowlist
+	}
+	if maxPerBlock != 0 {
+		flags |= FeatureRetryableRateLimit
+	}
+	if gracePeriod != 0 {
+		flags |= FeatureRetryableGracePeriod
+	}
+	return flags, nil
+}
+
+// ConfigHash is a hash of the chain owner's configuration of ArbOS, along with the ArbOS version and chain id.
+// It's a function of settings alone, so nodes that agree on them agree on it whatever else their state holds.
+func (state *ArbosState) ConfigHash() (common.Hash, error) {
+	var data []byte
+	var err error
+	appendWord := func(word common.Hash, readErr error) {
+		if err == nil {
+			err = readErr
+		}
+		data = append(data, word.Bytes()...)
+	}
+	appendUint := func(value uint64, readErr error) {
+		appendWord(util.UintToHash(value), readErr)
+	}
+	appendBig := func(value *big.Int, readErr error) {
+		if readErr != nil {
+			value = common.Big0
+		}
+		appendWord(common.BigToHash(value), readErr)
+	}
+	appendAddress := func(value common.Address, readErr error) {
+		appendWord(common.BytesToHash(value.Bytes()), readErr)
+	}
+	appendBool := func(value bool, readErr error) {
+		word := common.Hash{}
+		if value {
+			word[31] = 1
+		}
+		appendWord(word, readErr)
+	}
+
+	// parameters are only ever appended so that existing ones keep their positions
+	l1p := state.L1PricingState()
+	l2p := state.L2PricingState()
+	rs := state.RetryableState()
+	appendUint(state.ArbOSVersion(), nil)
+	appendBig(state.ChainId())
+	appendUint(state.BrotliCompressionLevel())
+	appendUint(state.SimulationGasLimit())
+	appendAddress(state.NetworkFeeAccount())
+	appendAddress(state.InfraFeeAccount())
+	appendBig(l2p.MinBaseFeeWei())
+	appendUint(l2p.SpeedLimitPerSecond())
+	appendUint(l2p.PerBlockGasLimit())
+	appendUint(l2p.PricingInertia())
+	appendUint(l2p.BacklogTolerance())
+	appendBool(l2p.CollectTips())
+	appendAddress(l1p.PayRewardsTo())
+	appendBig(l1p.EquilibrationUnits())
+	appendUint(l1p.Inertia())
+	appendUint(l1p.PerUnitReward())
+	perBatchGasCost, readErr := l1p.PerBatchGasCost()
+	appendWord(util.IntToHash(perBatchGasCost), readErr)
+	appendUint(l1p.AmortizedCostCapBips())
+	appendUint(rs.MinKeepaliveCost())
+	appendBool(rs.CreatorAllowlistEnabled())
+	appendUint(rs.MaxRetryablesPerBlock())
+	appendUint(rs.ExpiryGracePeriod())
+	appendBig(rs.ExpiryDeletionReward())
+	appendBig(rs.MinDeposit())
+	appendUint(rs.MaxTries())
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return state.KeccakHash(data)
+}
+
+// SetPrecompileMethodGas sets the minimum gas charged for calls to a precompile method, where 0 restores the default
+func (state *ArbosState) SetPrecompileMethodGas(precompile common.Address, method [4]byte, gas uint64) error {
+	methodGas := openPrecompileMethodGas(state.backingStorage)
+	key := precompileMethodGasKey(precompile, method)
+	previous, err := methodGas.GetUint64(key)
+	if err != nil {
+		return err
+	}
+	if previous == 0 && gas != 0 {
+		if _, err := state.methodGasOverrides.Increment(); err != nil {
+			return err
+		}
+	} else if previous != 0 && gas == 0 {
+		if _, err := state.methodGasOverrides.Decrement(); err != nil {
+			return err
+		}
+	}
+	return methodGas.Set(key, util.UintToHash(gas))
+}
+
 func (state *ArbosState) RetryableState() *retryables.RetryableState {
 	return state.retryableState
 }
@@ -459,6 +675,10 @@ func (state *ArbosState) ChainId() (*big.Int, error) {
 	return state.chainId.Get()
 }
 
+func (state *ArbosState) SetChainId(chainId *big.Int) error {
+	return state.chainId.SetChecked(chainId)
+}
+
 func (state *ArbosState) ChainConfig() ([]byte, error) {
 	return state.chainConfig.Get()
 }
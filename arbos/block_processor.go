@@ -40,6 +40,9 @@ var L2ToL1TransactionEventID common.Hash
 var L2ToL1TxEventID common.Hash
 var EmitReedeemScheduledEvent func(*vm.EVM, uint64, uint64, [32]byte, [32]byte, common.Address, *big.Int, *big.Int) error
 var EmitTicketCreatedEvent func(*vm.EVM, [32]byte) error
+var EmitExpiredEvent func(*vm.EVM, [32]byte) error
+var EmitRetryableCreationRateLimitedEvent func(*vm.EVM, [32]byte, uint64) error
+var EmitArbOSUpgradedEvent func(*vm.EVM, uint64, uint64) error
 var gasUsedSinceStartupCounter = metrics.NewRegisteredCounter("arb/gas_used", nil)
 
 // A helper struct that implements String() by marshalling to JSON.
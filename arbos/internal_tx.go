@@ -83,12 +83,25 @@ func ApplyInternalTxUpdate(tx *types.ArbitrumInternalTx, state *arbosState.Arbos
 		currentTime := evm.Context.Time
 
 		// Try to reap 2 retryables
-		_ = state.RetryableState().TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM)
-		_ = state.RetryableState().TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM)
+		for i := 0; i < 2; i++ {
+			expired, _ := state.RetryableState().ReapOneRetryable(currentTime, evm, util.TracingDuringEVM, state.ArbOSVersion())
+			if expired != nil && state.ArbOSVersion() >= 20 {
+				state.Restrict(EmitExpiredEvent(evm, *expired))
+			}
+		}
 
 		state.L2PricingState().UpdatePricingModel(l2BaseFee, timePassed, false)
 
-		return state.UpgradeArbosVersionIfNecessary(currentTime, evm.StateDB, evm.ChainConfig())
+		previousVersion := state.ArbOSVersion()
+		if err := state.UpgradeArbosVersionIfNecessary(currentTime, evm.StateDB, evm.ChainConfig()); err != nil {
+			return err
+		}
+
+		// Upgrades to versions before 20 predate the event, and emitting it would change their receipts
+		if newVersion := state.ArbOSVersion(); newVersion != previousVersion && newVersion >= 20 {
+			return EmitArbOSUpgradedEvent(evm, previousVersion, newVersion)
+		}
+		return nil
 	case InternalTxBatchPostingReportMethodID:
 		inputs, err := util.UnpackInternalTxDataBatchPostingReport(tx.Data)
 		if err != nil {
@@ -100,6 +113,13 @@ func ApplyInternalTxUpdate(tx *types.ArbitrumInternalTx, state *arbosState.Arbos
 		l1BaseFeeWei := util.SafeMapGet[*big.Int](inputs, "l1BaseFeeWei")
 
 		l1p := state.L1PricingState()
+		if state.ArbOSVersion() >= 20 {
+			// the report's L2 block started by recording its message header's L1 block number, which is that of the
+			// parent chain block the report was logged in. The sequencer inbox logs it in the tx that posts the batch.
+			l1BlockNumber, err := state.Blockhashes().L1BlockNumber()
+			state.Restrict(err)
+			state.Restrict(l1p.SetLastBatchL1Block(l1BlockNumber))
+		}
 		perBatchGas, err := l1p.PerBatchGasCost()
 		if err != nil {
 			log.Warn("L1Pricing PerBatchGas failed", "err", err)
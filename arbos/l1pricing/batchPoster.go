@@ -16,12 +16,18 @@ import (
 
 const totalFundsDueOffset = 0
 
+// FundsDueEpochSeconds is the length of the epochs a poster's accrued funds are broken down by
+const FundsDueEpochSeconds = 24 * 60 * 60
+
 var (
 	PosterAddrsKey = []byte{0}
 	PosterInfoKey  = []byte{1}
 
+	accrualsKey = []byte{0}
+
 	ErrAlreadyExists = errors.New("tried to add a batch poster that already exists")
 	ErrNotExist      = errors.New("tried to open a batch poster that does not exist")
+	ErrHasFundsDue   = errors.New("tried to remove a batch poster that is still owed funds")
 )
 
 // BatchPostersTable is the layout of storage in the table
@@ -34,6 +40,8 @@ type BatchPostersTable struct {
 type BatchPosterState struct {
 	fundsDue     storage.StorageBackedBigInt
 	payTo        storage.StorageBackedAddress
+	txBaseFee    storage.StorageBackedBigUint
+	accruals     *storage.Storage // the funds that came due in each epoch
 	postersTable *BatchPostersTable
 }
 
@@ -72,6 +80,8 @@ func (bpt *BatchPostersTable) internalOpen(poster common.Address) *BatchPosterSt
 	return &BatchPosterState{
 		fundsDue:     bpStorage.OpenStorageBackedBigInt(0),
 		payTo:        bpStorage.OpenStorageBackedAddress(1),
+		txBaseFee:    bpStorage.OpenStorageBackedBigUint(2),
+		accruals:     bpStorage.OpenSubStorage(accrualsKey),
 		postersTable: bpt,
 	}
 }
@@ -103,6 +113,25 @@ func (bpt *BatchPostersTable) AddPoster(posterAddress common.Address, payTo comm
 	return bpState, nil
 }
 
+// RemovePoster removes a batch poster, which must not be owed any funds so that none are lost track of
+func (bpt *BatchPostersTable) RemovePoster(posterAddress common.Address, arbosVersion uint64) error {
+	isBatchPoster, err := bpt.posterAddrs.IsMember(posterAddress)
+	if err != nil {
+		return err
+	}
+	if !isBatchPoster {
+		return ErrNotExist
+	}
+	due, err := bpt.internalOpen(posterAddress).FundsDue()
+	if err != nil {
+		return err
+	}
+	if due.Sign() != 0 {
+		return ErrHasFundsDue
+	}
+	return bpt.posterAddrs.Remove(posterAddress, arbosVersion)
+}
+
 func (bpt *BatchPostersTable) AllPosters(maxNumToGet uint64) ([]common.Address, error) {
 	return bpt.posterAddrs.AllMembers(maxNumToGet)
 }
@@ -132,6 +161,21 @@ func (bps *BatchPosterState) SetFundsDue(val *big.Int) error {
 	return bps.fundsDue.SetSaturatingWithWarning(val, "batch poster funds due")
 }
 
+// AccruedInEpoch is how much came due to the poster during the epoch, regardless of what's since been paid
+func (bps *BatchPosterState) AccruedInEpoch(epoch uint64) (*big.Int, error) {
+	return bps.accruals.OpenStorageBackedBigInt(epoch).Get()
+}
+
+// AddAccrual records funds coming due to the poster during the epoch
+func (bps *BatchPosterState) AddAccrual(epoch uint64, amount *big.Int) error {
+	accrued := bps.accruals.OpenStorageBackedBigInt(epoch)
+	prev, err := accrued.Get()
+	if err != nil {
+		return err
+	}
+	return accrued.SetSaturatingWithWarning(arbmath.BigAdd(prev, amount), "batch poster epoch accrual")
+}
+
 func (bps *BatchPosterState) PayTo() (common.Address, error) {
 	return bps.payTo.Get()
 }
@@ -140,6 +184,15 @@ func (bps *BatchPosterState) SetPayTo(addr common.Address) error {
 	return bps.payTo.Set(addr)
 }
 
+// TxBaseFee is the fixed fee, in L1 gas, the poster adds to each tx it posts
+func (bps *BatchPosterState) TxBaseFee() (*big.Int, error) {
+	return bps.txBaseFee.Get()
+}
+
+func (bps *BatchPosterState) SetTxBaseFee(feeInL1Gas *big.Int) error {
+	return bps.txBaseFee.SetChecked(feeInL1Gas)
+}
+
 type FundsDueItem struct {
 	dueTo   common.Address
 	balance *big.Int
@@ -45,6 +45,7 @@ type L1PricingState struct {
 	perBatchGasCost      storage.StorageBackedInt64   // introduced in ArbOS version 3
 	amortizedCostCapBips storage.StorageBackedUint64  // in basis points; introduced in ArbOS version 3
 	l1FeesAvailable      storage.StorageBackedBigUint
+	lastBatchL1Block     storage.StorageBackedUint64 // introduced in ArbOS version 20
 }
 
 var (
@@ -69,6 +70,7 @@ const (
 	perBatchGasCostOffset
 	amortizedCostCapBipsOffset
 	l1FeesAvailableOffset
+	lastBatchL1BlockOffset
 )
 
 const (
@@ -131,6 +133,7 @@ func OpenL1PricingState(sto *storage.Storage) *L1PricingState {
 		sto.OpenStorageBackedInt64(perBatchGasCostOffset),
 		sto.OpenStorageBackedUint64(amortizedCostCapBipsOffset),
 		sto.OpenStorageBackedBigUint(l1FeesAvailableOffset),
+		sto.OpenStorageBackedUint64(lastBatchL1BlockOffset),
 	}
 }
 
@@ -166,6 +169,24 @@ func (ps *L1PricingState) SetInertia(inertia uint64) error {
 	return ps.inertia.Set(inertia)
 }
 
+// ObserveL1BaseFee moves the L1 basefee estimate 1/inertia of the way toward an observed L1 basefee,
+// so that repeated observations of the same value converge to it
+func (ps *L1PricingState) ObserveL1BaseFee(observed *big.Int) error {
+	if observed.Sign() < 0 {
+		return errors.New("observed L1 basefee cannot be negative")
+	}
+	inertia, err := ps.Inertia()
+	if err != nil {
+		return err
+	}
+	price, err := ps.PricePerUnit()
+	if err != nil {
+		return err
+	}
+	step := am.BigDivByUint(am.BigSub(observed, price), am.MaxInt(inertia, 1))
+	return ps.SetPricePerUnit(am.BigAdd(price, step))
+}
+
 func (ps *L1PricingState) PerUnitReward() (uint64, error) {
 	return ps.perUnitReward.Get()
 }
@@ -254,16 +275,17 @@ func (ps *L1PricingState) SetL1FeesAvailable(val *big.Int) error {
 	return ps.l1FeesAvailable.SetChecked(val)
 }
 
+// LastBatchL1Block is the L1 block the most recently reported batch was posted in, or 0 if none has been reported
+func (ps *L1PricingState) LastBatchL1Block() (uint64, error) {
+	return ps.lastBatchL1Block.Get()
+}
+
+func (ps *L1PricingState) SetLastBatchL1Block(block uint64) error {
+	return ps.lastBatchL1Block.Set(block)
+}
+
 func (ps *L1PricingState) AddToL1FeesAvailable(delta *big.Int) (*big.Int, error) {
-	old, err := ps.L1FeesAvailable()
-	if err != nil {
-		return nil, err
-	}
-	new := new(big.Int).Add(old, delta)
-	if err := ps.SetL1FeesAvailable(new); err != nil {
-		return nil, err
-	}
-	return new, nil
+	return ps.l1FeesAvailable.Add(delta)
 }
 
 func (ps *L1PricingState) TransferFromL1FeesAvailable(
@@ -276,18 +298,11 @@ func (ps *L1PricingState) TransferFromL1FeesAvailable(
 	if err := util.TransferBalance(&L1PricerFundsPoolAddress, &recipient, amount, evm, scenario, purpose); err != nil {
 		return nil, err
 	}
-	old, err := ps.L1FeesAvailable()
-	if err != nil {
-		return nil, err
-	}
-	updated := new(big.Int).Sub(old, amount)
-	if updated.Sign() < 0 {
+	updated, err := ps.l1FeesAvailable.Sub(amount)
+	if errors.Is(err, storage.ErrUnderflow) {
 		return nil, core.ErrInsufficientFunds
 	}
-	if err := ps.SetL1FeesAvailable(updated); err != nil {
-		return nil, err
-	}
-	return updated, nil
+	return updated, err
 }
 
 // UpdateForBatchPosterSpending updates the pricing model based on a payment by a batch poster
@@ -377,6 +392,11 @@ func (ps *L1PricingState) UpdateForBatchPosterSpending(
 	if err != nil {
 		return err
 	}
+	if arbosVersion >= 20 {
+		if err := posterState.AddAccrual(updateTime/FundsDueEpochSeconds, weiSpent); err != nil {
+			return err
+		}
+	}
 	perUnitReward, err := ps.PerUnitReward()
 	if err != nil {
 		return err
@@ -523,6 +543,31 @@ func (ps *L1PricingState) GetPosterInfo(tx *types.Transaction, poster common.Add
 	return am.BigMulByUint(pricePerUnit, units), units
 }
 
+// TxBaseFeeCost returns the poster's fixed per-tx fee converted to wei at the current price per unit,
+// along with the poster's fee collector, which the fee is paid to
+func (ps *L1PricingState) TxBaseFeeCost(poster common.Address) (*big.Int, common.Address, error) {
+	posterState, err := ps.BatchPosterTable().OpenPoster(poster, false)
+	if errors.Is(err, ErrNotExist) {
+		return common.Big0, common.Address{}, nil
+	}
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	feeInL1Gas, err := posterState.TxBaseFee()
+	if err != nil || feeInL1Gas.Sign() == 0 {
+		return common.Big0, common.Address{}, err
+	}
+	payTo, err := posterState.PayTo()
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	pricePerUnit, err := ps.PricePerUnit()
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	return am.BigMul(pricePerUnit, feeInL1Gas), payTo, nil
+}
+
 // We don't have the full tx in gas estimation, so we assume it might be a bit bigger in practice.
 const estimationPaddingUnits = 16 * params.TxDataNonZeroGasEIP2028
 const estimationPaddingBasisPoints = 100
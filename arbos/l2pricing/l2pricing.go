@@ -4,9 +4,11 @@
 package l2pricing
 
 import (
+	"errors"
 	"math/big"
 
 	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/arbmath"
 )
 
 type L2PricingState struct {
@@ -18,6 +20,9 @@ type L2PricingState struct {
 	gasBacklog          storage.StorageBackedUint64
 	pricingInertia      storage.StorageBackedUint64
 	backlogTolerance    storage.StorageBackedUint64
+	collectTips         storage.StorageBackedUint64 // nonzero if tips are collected rather than dropped
+	gasUsedBlock        storage.StorageBackedUint64 // the block gasUsedInBlock is counting
+	gasUsedInBlock      storage.StorageBackedUint64
 }
 
 const (
@@ -28,6 +33,9 @@ const (
 	gasBacklogOffset
 	pricingInertiaOffset
 	backlogToleranceOffset
+	collectTipsOffset
+	gasUsedBlockOffset
+	gasUsedInBlockOffset
 )
 
 const GethBlockGasLimit = 1 << 50
@@ -52,6 +60,9 @@ func OpenL2PricingState(sto *storage.Storage) *L2PricingState {
 		sto.OpenStorageBackedUint64(gasBacklogOffset),
 		sto.OpenStorageBackedUint64(pricingInertiaOffset),
 		sto.OpenStorageBackedUint64(backlogToleranceOffset),
+		sto.OpenStorageBackedUint64(collectTipsOffset),
+		sto.OpenStorageBackedUint64(gasUsedBlockOffset),
+		sto.OpenStorageBackedUint64(gasUsedInBlockOffset),
 	}
 }
 
@@ -82,6 +93,29 @@ func (ps *L2PricingState) SetSpeedLimitPerSecond(limit uint64) error {
 	return ps.speedLimitPerSecond.Set(limit)
 }
 
+// RescaleSpeedLimitPerSecond changes the speed limit, scaling the backlog by the same factor so that the basefee,
+// which depends on the backlog relative to the speed limit, carries on from where it was
+func (ps *L2PricingState) RescaleSpeedLimitPerSecond(limit uint64) error {
+	if limit == 0 {
+		return errors.New("speed limit must be nonzero")
+	}
+	oldLimit, err := ps.SpeedLimitPerSecond()
+	if err != nil {
+		return err
+	}
+	if oldLimit != 0 {
+		backlog, err := ps.GasBacklog()
+		if err != nil {
+			return err
+		}
+		scaled := arbmath.BigDivByUint(arbmath.BigMulByUint(arbmath.UintToBig(backlog), limit), oldLimit)
+		if err := ps.SetGasBacklog(arbmath.BigToUintSaturating(scaled)); err != nil {
+			return err
+		}
+	}
+	return ps.SetSpeedLimitPerSecond(limit)
+}
+
 func (ps *L2PricingState) PerBlockGasLimit() (uint64, error) {
 	return ps.perBlockGasLimit.Get()
 }
@@ -114,6 +148,40 @@ func (ps *L2PricingState) SetBacklogTolerance(val uint64) error {
 	return ps.backlogTolerance.Set(val)
 }
 
+// CollectTips is whether priority fees are paid to the infra fee account instead of being dropped
+func (ps *L2PricingState) CollectTips() (bool, error) {
+	collect, err := ps.collectTips.Get()
+	return collect != 0, err
+}
+
+func (ps *L2PricingState) SetCollectTips(collect bool) error {
+	if collect {
+		return ps.collectTips.Set(1)
+	}
+	return ps.collectTips.Set(0)
+}
+
+// BlockGasUsed is the gas used by the block's transactions that have finished so far
+func (ps *L2PricingState) BlockGasUsed(blockNumber uint64) (uint64, error) {
+	countedBlock, err := ps.gasUsedBlock.Get()
+	if err != nil || countedBlock != blockNumber {
+		return 0, err
+	}
+	return ps.gasUsedInBlock.Get()
+}
+
+// AddBlockGasUsed counts a finished transaction's gas towards its block, starting the count over in each block
+func (ps *L2PricingState) AddBlockGasUsed(blockNumber uint64, gas uint64) error {
+	used, err := ps.BlockGasUsed(blockNumber)
+	if err != nil {
+		return err
+	}
+	if err := ps.gasUsedBlock.Set(blockNumber); err != nil {
+		return err
+	}
+	return ps.gasUsedInBlock.Set(arbmath.SaturatingUAdd(used, gas))
+}
+
 func (ps *L2PricingState) Restrict(err error) {
 	ps.storage.Burner().Restrict(err)
 }
@@ -50,3 +50,18 @@ func (ps *L2PricingState) UpdatePricingModel(l2BaseFee *big.Int, timePassed uint
 	}
 	_ = ps.SetBaseFeeWei(baseFee)
 }
+
+// BaseFeeComponents splits the basefee into the floor set by the minimum basefee and the congestion premium above it
+func (ps *L2PricingState) BaseFeeComponents() (*big.Int, *big.Int, error) {
+	baseFee, err := ps.BaseFeeWei()
+	if err != nil {
+		return nil, nil, err
+	}
+	minBaseFee, err := ps.MinBaseFeeWei()
+	if err != nil {
+		return nil, nil, err
+	}
+	// the minimum may have been raised since the basefee was last computed
+	floor := arbmath.BigMin(minBaseFee, baseFee)
+	return floor, arbmath.BigSub(baseFee, floor), nil
+}
@@ -51,7 +51,7 @@ func TestRetryableLifecycle(t *testing.T) {
 	proveReapingDoesNothing := func() {
 		stateCheck(t, statedb, false, "reaping had an effect", func() {
 			evm := vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
-			Require(t, retryableState.TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM))
+			Require(t, retryableState.TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM, state.ArbOSVersion()))
 		})
 	}
 	checkQueueSize := func(expected int, message string) {
@@ -107,7 +107,7 @@ func TestRetryableLifecycle(t *testing.T) {
 		// check that our reap pricing is reflective of the true cost
 		gasBefore := burner.Burned()
 		evm := vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
-		Require(t, retryableState.TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM))
+		Require(t, retryableState.TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM, state.ArbOSVersion()))
 		gasBurnedToReap := burner.Burned() - gasBefore
 		if gasBurnedToReap != retryables.RetryableReapPrice {
 			Fail(t, "reaping has been mispriced", gasBurnedToReap, retryables.RetryableReapPrice)
@@ -129,7 +129,7 @@ func TestRetryableLifecycle(t *testing.T) {
 
 		gasBefore := burner.Burned()
 		evm := vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
-		Require(t, retryableState.TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM))
+		Require(t, retryableState.TryToReapOneRetryable(currentTime, evm, util.TracingDuringEVM, state.ArbOSVersion()))
 		gasBurnedToReapAndDelete := burner.Burned() - gasBefore
 		if gasBurnedToReapAndDelete <= retryables.RetryableReapPrice {
 			Fail(t, "deletion was cheap", gasBurnedToReapAndDelete, retryables.RetryableReapPrice)
@@ -174,7 +174,59 @@ func TestRetryableCleanup(t *testing.T) {
 		_, err := retryableState.CreateRetryable(id, timeout, from, &to, callvalue, beneficiary, calldata)
 		Require(t, err)
 		evm := vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
-		Require(t, retryableState.TryToReapOneRetryable(timestamp, evm, util.TracingDuringEVM))
+		Require(t, retryableState.TryToReapOneRetryable(timestamp, evm, util.TracingDuringEVM, state.ArbOSVersion()))
+		cleared, err := retryableState.TimeoutQueue.Shift()
+		Require(t, err)
+		if !cleared {
+			Fail(t, "failed to reset the queue")
+		}
+	})
+}
+
+func TestRetryableCleanupRecords(t *testing.T) {
+	state, statedb := arbosState.NewArbosMemoryBackedArbOSState()
+	retryableState := state.RetryableState()
+
+	id := common.BigToHash(big.NewInt(rand.Int63n(1 << 32)))
+	beneficiary := testhelpers.RandomAddress()
+	calldata := testhelpers.RandomizeSlice(make([]byte, 100))
+
+	// everything recorded about a ticket since ArbOS 20 is deleted along with it
+	stateCheck(t, statedb, false, "ticket records outlived the ticket", func() {
+		retryable, err := retryableState.CreateRetryable(id, 1, testhelpers.RandomAddress(), nil, big.NewInt(0), beneficiary, calldata)
+		Require(t, err)
+		Require(t, retryableState.SetMaxFeePerGas(id, big.NewInt(params.GWei)))
+		Require(t, retryableState.IndexBeneficiary(id, beneficiary))
+		Require(t, retryableState.SetApprovedRedeemer(id, testhelpers.RandomAddress()))
+		migrated, err := retryableState.MigrateRetryableStorage([]common.Hash{id})
+		Require(t, err)
+		if migrated != 1 {
+			Fail(t, "ticket wasn't migrated")
+		}
+		var redeemTxIds []common.Hash
+		for i := 0; i < 3; i++ {
+			redeemTxIds = append(redeemTxIds, common.BigToHash(big.NewInt(rand.Int63())))
+			sequenceNum, err := retryable.IncrementNumTries()
+			Require(t, err)
+			Require(t, retryableState.RecordRedeemTx(redeemTxIds[i], id, sequenceNum-1))
+		}
+		Require(t, retryableState.RecordAutoRedeem(id, redeemTxIds[0]))
+		Require(t, retryableState.RecordSubmissionFeeRefund(id, big.NewInt(params.GWei)))
+		Require(t, retryableState.FinishAutoRedeem(id, redeemTxIds[0], false))
+
+		evm := vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{})
+		deleted, err := retryableState.DeleteRetryable(id, evm, util.TracingDuringEVM, 20)
+		Require(t, err)
+		if !deleted {
+			Fail(t, "ticket wasn't deleted")
+		}
+		ticketId, _, err := retryableState.RedeemTxTicket(redeemTxIds[1])
+		Require(t, err)
+		if ticketId != (common.Hash{}) {
+			Fail(t, "redeem attempt is still recorded for the deleted ticket")
+		}
+		_, err = retryableState.TimeoutQueue.Get()
+		Require(t, err)
 		cleared, err := retryableState.TimeoutQueue.Shift()
 		Require(t, err)
 		if !cleared {
@@ -0,0 +1,124 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package retryables
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+)
+
+// Each beneficiary's tickets are listed like the members of an AddressSet: the count is stored at position 0,
+// the tickets sequentially from 1 onward, and each ticket's position in the byTicket substorage.
+// Only tickets created since ArbOS 20 are indexed.
+type beneficiaryIndex struct {
+	list     *storage.Storage
+	size     storage.StorageBackedUint64
+	byTicket *storage.Storage
+}
+
+func (rs *RetryableState) openBeneficiaryIndex(beneficiary common.Address) *beneficiaryIndex {
+	sto := rs.retryables.OpenCachedSubStorage(beneficiariesKey).OpenSubStorage(beneficiary.Bytes())
+	return &beneficiaryIndex{
+		list:     sto,
+		size:     sto.OpenStorageBackedUint64(0),
+		byTicket: sto.OpenSubStorage([]byte{0}),
+	}
+}
+
+// IndexBeneficiary lists the ticket under its beneficiary, which should be done when it's created
+func (rs *RetryableState) IndexBeneficiary(ticketId common.Hash, beneficiary common.Address) error {
+	index := rs.openBeneficiaryIndex(beneficiary)
+	slot, err := index.byTicket.GetUint64(ticketId)
+	if slot != 0 || err != nil {
+		return err
+	}
+	size, err := index.size.Increment()
+	if err != nil {
+		return err
+	}
+	if err := index.list.SetByUint64(size, ticketId); err != nil {
+		return err
+	}
+	return index.byTicket.Set(ticketId, util.UintToHash(size))
+}
+
+// unindexBeneficiary removes the ticket from its beneficiary's list, if it's there, by moving the last ticket into
+// its place
+func (rs *RetryableState) unindexBeneficiary(ticketId common.Hash, beneficiary common.Address) error {
+	index := rs.openBeneficiaryIndex(beneficiary)
+	slot, err := index.byTicket.GetUint64(ticketId)
+	if slot == 0 || err != nil {
+		return err
+	}
+	if err := index.byTicket.Clear(ticketId); err != nil {
+		return err
+	}
+	size, err := index.size.Get()
+	if err != nil {
+		return err
+	}
+	if slot < size {
+		last, err := index.list.GetByUint64(size)
+		if err != nil {
+			return err
+		}
+		if err := index.list.SetByUint64(slot, last); err != nil {
+			return err
+		}
+		if err := index.byTicket.Set(last, util.UintToHash(slot)); err != nil {
+			return err
+		}
+	}
+	if err := index.list.ClearByUint64(size); err != nil {
+		return err
+	}
+	_, err = index.size.Decrement()
+	return err
+}
+
+// TransferBeneficiary makes another address the ticket's beneficiary, moving it to that address's list
+// if it was indexed
+func (rs *RetryableState) TransferBeneficiary(ticketId common.Hash, retryable *Retryable, beneficiary common.Address) error {
+	previous, err := retryable.Beneficiary()
+	if err != nil {
+		return err
+	}
+	slot, err := rs.openBeneficiaryIndex(previous).byTicket.GetUint64(ticketId)
+	if err != nil {
+		return err
+	}
+	if err := retryable.beneficiary.Set(beneficiary); err != nil {
+		return err
+	}
+	if slot == 0 {
+		return nil
+	}
+	if err := rs.unindexBeneficiary(ticketId, previous); err != nil {
+		return err
+	}
+	return rs.IndexBeneficiary(ticketId, beneficiary)
+}
+
+// RetryablesByBeneficiary lists up to count of the beneficiary's tickets, starting at the given position.
+// Removing a ticket moves the last one into its place, so pages read across removals may skip or repeat tickets.
+// Expired tickets stay listed until they're reaped.
+func (rs *RetryableState) RetryablesByBeneficiary(beneficiary common.Address, start, count uint64) ([]common.Hash, error) {
+	index := rs.openBeneficiaryIndex(beneficiary)
+	size, err := index.size.Get()
+	if err != nil || start >= size {
+		return []common.Hash{}, err
+	}
+	if count > size-start {
+		count = size - start
+	}
+	tickets := make([]common.Hash, count)
+	for i := range tickets {
+		tickets[i], err = index.list.GetByUint64(start + uint64(i) + 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tickets, nil
+}
@@ -6,28 +6,61 @@ package retryables
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/offchainlabs/nitro/arbos/addressSet"
 	"github.com/offchainlabs/nitro/arbos/storage"
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/util/arbmath"
 )
 
 const RetryableLifetimeSeconds = 7 * 24 * 60 * 60 // one week
+const RetryableMaxLifetimes = 2                   // keepalives can't push a timeout further than this many lifetimes from now
 const RetryableReapPrice = 58000
 
+// MaxL2SubmissionDataSize matches the calldata limit the L1 inbox enforces on retryables
+const MaxL2SubmissionDataSize = 117964
+
 type RetryableState struct {
 	retryables   *storage.Storage
 	TimeoutQueue *storage.Queue
 }
 
 var (
-	timeoutQueueKey = []byte{0}
-	calldataKey     = []byte{1}
+	timeoutQueueKey  = []byte{0}
+	calldataKey      = []byte{1}
+	autoRedeemsKey   = []byte{2}
+	creatorsKey      = []byte{3}
+	layoutsKey       = []byte{4}
+	feeCapsKey       = []byte{5}
+	beneficiariesKey = []byte{6}
+	staleEntriesKey  = []byte{7}
+	redeemersKey     = []byte{8}
+	redeemTxsKey     = []byte{9}
+
+	ErrCreatorNotAllowed   = errors.New("sender isn't allowed to create retryables")
+	ErrCreationRateLimited = errors.New("too many retryables created this block")
+	ErrDepositTooSmall     = errors.New("retryable deposit is below the minimum")
+	ErrTriesExhausted      = errors.New("retryable has used all of its redeem attempts")
+	ErrUncallableTarget    = errors.New("retryable targets an address its redeems can never call")
+)
+
+const (
+	l2SubmissionCountOffset uint64 = iota
+	minKeepaliveCostOffset
+	creatorAllowlistEnabledOffset
+	maxRetryablesPerBlockOffset
+	creationsBlockOffset
+	creationsCountOffset
+	expiryGracePeriodOffset
+	expiryDeletionRewardOffset
+	minDepositOffset
+	maxTriesOffset
 )
 
 func InitializeRetryableState(sto *storage.Storage) error {
@@ -123,17 +156,96 @@ func (rs *RetryableState) OpenRetryable(id common.Hash, currentTimestamp uint64)
 	}, nil
 }
 
+// TimeoutIncludingExpired gets when the retryable will expire, even if that's already passed and it's awaiting reaping.
+// This is 0 if no such retryable exists.
+func (rs *RetryableState) TimeoutIncludingExpired(id common.Hash) (uint64, error) {
+	sto := rs.retryables.OpenSubStorage(id.Bytes())
+	timeout, err := sto.GetUint64ByUint64(timeoutOffset)
+	if timeout == 0 || err != nil {
+		return 0, err
+	}
+	windows, err := sto.GetUint64ByUint64(timeoutWindowsLeftOffset)
+	return timeout + windows*RetryableLifetimeSeconds, err
+}
+
 func (rs *RetryableState) RetryableSizeBytes(id common.Hash, currentTime uint64) (uint64, error) {
 	retryable, err := rs.OpenRetryable(id, currentTime)
 	if retryable == nil || err != nil {
 		return 0, err
 	}
+	return retryable.SizeBytes()
+}
+
+func (retryable *Retryable) SizeBytes() (uint64, error) {
 	size, err := retryable.CalldataSize()
 	calldata := 32 + 32*arbmath.WordsForBytes(size) // length + contents
 	return 6*32 + calldata, err
 }
 
-func (rs *RetryableState) DeleteRetryable(id common.Hash, evm *vm.EVM, scenario util.TracingScenario) (bool, error) {
+// OpenExpiredInGracePeriod opens a retryable that expired no more than the grace period ago, which can't be
+// redeemed but can still be revived. Returns nil if there's no such retryable.
+func (rs *RetryableState) OpenExpiredInGracePeriod(id common.Hash, currentTimestamp uint64) (*Retryable, error) {
+	grace, err := rs.ExpiryGracePeriod()
+	if grace == 0 || err != nil {
+		return nil, err
+	}
+	sto := rs.retryables.OpenSubStorage(id.Bytes())
+	timeout, err := sto.GetUint64ByUint64(timeoutOffset)
+	if timeout == 0 || timeout >= currentTimestamp || err != nil {
+		return nil, err
+	}
+	windowsLeft, err := sto.GetUint64ByUint64(timeoutWindowsLeftOffset)
+	if windowsLeft != 0 || timeout+grace < currentTimestamp || err != nil {
+		// a retryable with windows left hasn't really expired, it's just waiting for the reaper to use one
+		return nil, err
+	}
+	return rs.OpenRetryable(id, timeout)
+}
+
+// Revive gives a retryable in its grace period another lifetime from when it expired, returning its new timeout.
+// The retryable is redeemable again right away. Its expired queue entry can't be taken out of the middle of the
+// queue, so it's counted as stale for the reaper to discard, and a new entry is added in its place.
+func (rs *RetryableState) Revive(retryable *Retryable) (uint64, error) {
+	timeout, err := retryable.timeout.Get()
+	if err != nil {
+		return 0, err
+	}
+	newTimeout := timeout + RetryableLifetimeSeconds
+	if err := retryable.timeout.Set(newTimeout); err != nil {
+		return 0, err
+	}
+	stale := rs.retryables.OpenCachedSubStorage(staleEntriesKey)
+	count, err := stale.GetUint64(retryable.id)
+	if err != nil {
+		return 0, err
+	}
+	if err := stale.Set(retryable.id, util.UintToHash(count+1)); err != nil {
+		return 0, err
+	}
+	if err := rs.TimeoutQueue.Put(retryable.id); err != nil {
+		return 0, err
+	}
+
+	// Pay in advance for the work needed to reap the stale entry from the timeout queue
+	return newTimeout, rs.retryables.Burner().Burn(RetryableReapPrice)
+}
+
+// discardStaleEntry pops the peeked queue entry if a revive left one of the ticket's entries behind, reporting whether
+// it did. A ticket's entries are interchangeable, so it doesn't matter which of them is discarded.
+func (rs *RetryableState) discardStaleEntry(id common.Hash) (bool, error) {
+	stale := rs.retryables.OpenCachedSubStorage(staleEntriesKey)
+	count, err := stale.GetUint64(id)
+	if count == 0 || err != nil {
+		return false, err
+	}
+	if err := stale.Set(id, util.UintToHash(count-1)); err != nil {
+		return false, err
+	}
+	_, err = rs.TimeoutQueue.Get()
+	return true, err
+}
+
+func (rs *RetryableState) DeleteRetryable(id common.Hash, evm *vm.EVM, scenario util.TracingScenario, arbosVersion uint64) (bool, error) {
 	retStorage := rs.retryables.OpenSubStorage(id.Bytes())
 	timeout, err := retStorage.GetByUint64(timeoutOffset)
 	if timeout == (common.Hash{}) || err != nil {
@@ -149,6 +261,21 @@ func (rs *RetryableState) DeleteRetryable(id common.Hash, evm *vm.EVM, scenario
 	if err != nil {
 		return false, err
 	}
+	if arbosVersion >= 20 {
+		if err := rs.unindexBeneficiary(id, beneficiaryAddress); err != nil {
+			return false, err
+		}
+		// any stale queue entries left by revives are discarded like the rest of the ticket's entries
+		if err := rs.retryables.OpenCachedSubStorage(staleEntriesKey).Clear(id); err != nil {
+			return false, err
+		}
+		if err := rs.retryables.OpenCachedSubStorage(redeemersKey).Clear(id); err != nil {
+			return false, err
+		}
+		if err := rs.clearTicketRecords(id, retStorage); err != nil {
+			return false, err
+		}
+	}
 
 	// we ignore returned error as we expect that if one ClearByUint64 fails, than all consecutive calls to ClearByUint64 will fail with the same error (not modifying state), and then ClearBytes will also fail with the same error (also not modifying state) - and this one we check and return
 	_ = retStorage.ClearByUint64(numTriesOffset)
@@ -162,6 +289,43 @@ func (rs *RetryableState) DeleteRetryable(id common.Hash, evm *vm.EVM, scenario
 	return true, err
 }
 
+// clearTicketRecords deletes what's recorded about the ticket outside of its own fields, including the records of
+// each of its redeem attempts, so that none of it outlives the ticket
+func (rs *RetryableState) clearTicketRecords(id common.Hash, retStorage *storage.Storage) error {
+	autoRedeem := rs.autoRedeemStorage(id)
+	_ = autoRedeem.ClearByUint64(autoRedeemStatusOffset)
+	_ = autoRedeem.ClearByUint64(autoRedeemTxIdOffset)
+	_ = autoRedeem.ClearByUint64(autoRedeemSubmissionFeeRefundOffset)
+	feeCap := rs.maxFeePerGas(id)
+	_ = feeCap.SetChecked(common.Big0)
+	version := rs.layoutVersion(id)
+	if err := version.Clear(); err != nil {
+		return err
+	}
+	numTries, err := retStorage.GetUint64ByUint64(numTriesOffset)
+	if err != nil {
+		return err
+	}
+	attempts := rs.ticketRedeemTxs(id)
+	for sequenceNum := uint64(0); sequenceNum < numTries; sequenceNum++ {
+		redeemTxId, err := attempts.GetByUint64(sequenceNum)
+		if err != nil {
+			return err
+		}
+		if redeemTxId == (common.Hash{}) {
+			// the attempt was made before redeems were recorded
+			continue
+		}
+		sto := rs.redeemTxStorage(redeemTxId)
+		_ = sto.ClearByUint64(redeemTxTicketIdOffset)
+		_ = sto.ClearByUint64(redeemTxSequenceNumOffset)
+		if err := attempts.ClearByUint64(sequenceNum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (retryable *Retryable) NumTries() (uint64, error) {
 	return retryable.numTries.Get()
 }
@@ -212,6 +376,11 @@ func (retryable *Retryable) CalldataSize() (uint64, error) {
 	return retryable.calldata.Size()
 }
 
+// CalldataSlice reads part of the calldata, paying only for the words read
+func (retryable *Retryable) CalldataSlice(offset uint64, length uint64) ([]byte, error) {
+	return retryable.calldata.GetSlice(offset, length)
+}
+
 func (rs *RetryableState) Keepalive(
 	ticketId common.Hash,
 	currentTimestamp,
@@ -287,46 +456,116 @@ func (retryable *Retryable) Equals(other *Retryable) (bool, error) { // for test
 	return true, err
 }
 
-func (rs *RetryableState) TryToReapOneRetryable(currentTimestamp uint64, evm *vm.EVM, scenario util.TracingScenario) error {
+func (rs *RetryableState) TryToReapOneRetryable(currentTimestamp uint64, evm *vm.EVM, scenario util.TracingScenario, arbosVersion uint64) error {
+	_, err := rs.ReapOneRetryable(currentTimestamp, evm, scenario, arbosVersion)
+	return err
+}
+
+// ReapOneRetryable processes the next entry in the timeout queue, returning the ticket's id if it expired and was deleted
+func (rs *RetryableState) ReapOneRetryable(currentTimestamp uint64, evm *vm.EVM, scenario util.TracingScenario, arbosVersion uint64) (*common.Hash, error) {
 	id, err := rs.TimeoutQueue.Peek()
 	if err != nil || id == nil {
-		return err
+		return nil, err
 	}
 	retryableStorage := rs.retryables.OpenSubStorage(id.Bytes())
 	timeoutStorage := retryableStorage.OpenStorageBackedUint64(timeoutOffset)
 	timeout, err := timeoutStorage.Get()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if timeout == 0 {
 		// The retryable has already been deleted, so discard the peeked entry
 		_, err = rs.TimeoutQueue.Get()
-		return err
+		return nil, err
+	}
+	if arbosVersion >= 20 {
+		if discarded, err := rs.discardStaleEntry(*id); discarded || err != nil {
+			return nil, err
+		}
 	}
 
 	windowsLeftStorage := retryableStorage.OpenStorageBackedUint64(timeoutWindowsLeftOffset)
 	windowsLeft, err := windowsLeftStorage.Get()
 	if err != nil || timeout >= currentTimestamp {
-		return err
+		return nil, err
+	}
+	if windowsLeft == 0 && arbosVersion >= 20 {
+		grace, err := rs.ExpiryGracePeriod()
+		if err != nil {
+			return nil, err
+		}
+		if timeout+grace >= currentTimestamp {
+			// the retryable can still be revived, so move it to the back of the queue instead of holding up the
+			// retryables behind it
+			if _, err := rs.TimeoutQueue.Get(); err != nil {
+				return nil, err
+			}
+			return nil, rs.TimeoutQueue.Put(*id)
+		}
 	}
 
 	// Either the retryable has expired, or it's lost a lifetime's worth of time
 	_, err = rs.TimeoutQueue.Get()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if windowsLeft == 0 {
 		// the retryable has expired, time to reap
-		_, err = rs.DeleteRetryable(*id, evm, scenario)
-		return err
+		deleted, err := rs.DeleteRetryable(*id, evm, scenario, arbosVersion)
+		if !deleted || err != nil {
+			return nil, err
+		}
+		return id, nil
 	}
 
 	// Consume a window, delaying the timeout one lifetime period
 	if err := timeoutStorage.Set(timeout + RetryableLifetimeSeconds); err != nil {
-		return err
+		return nil, err
 	}
-	return windowsLeftStorage.Set(windowsLeft - 1)
+	return nil, windowsLeftStorage.Set(windowsLeft - 1)
+}
+
+// SweepExpired processes up to maxEntries entries of the timeout queue, stopping early once the front of the queue
+// hasn't expired. It returns how many words of storage were reclaimed from the retryables it deleted.
+func (rs *RetryableState) SweepExpired(
+	currentTimestamp uint64, maxEntries uint64, evm *vm.EVM, scenario util.TracingScenario, arbosVersion uint64,
+) (uint64, error) {
+	var words uint64
+	for i := uint64(0); i < maxEntries; i++ {
+		id, err := rs.TimeoutQueue.Peek()
+		if err != nil || id == nil {
+			return words, err
+		}
+		size, err := rs.retryables.OpenSubStorage(id.Bytes()).OpenStorageBackedBytes(calldataKey).Size()
+		if err != nil {
+			return words, err
+		}
+		reaped, err := rs.ReapOneRetryable(currentTimestamp, evm, scenario, arbosVersion)
+		if err != nil {
+			return words, err
+		}
+		if reaped != nil {
+			words += 7 + arbmath.WordsForBytes(size) // the fields, calldata length, and calldata contents
+			continue
+		}
+		front, err := rs.TimeoutQueue.Peek()
+		if err != nil || (front != nil && *front == *id) {
+			// the front of the queue hasn't expired yet, so neither has anything behind it
+			return words, err
+		}
+	}
+	return words, nil
+}
+
+// ExpiryDeletionReward is how much wei the network fee account pays per word of storage reclaimed by sweeping
+// expired retryables
+func (rs *RetryableState) ExpiryDeletionReward() (*big.Int, error) {
+	return rs.retryables.OpenStorageBackedBigUint(expiryDeletionRewardOffset).Get()
+}
+
+func (rs *RetryableState) SetExpiryDeletionReward(weiPerWord *big.Int) error {
+	return rs.retryables.OpenStorageBackedBigUint(expiryDeletionRewardOffset).SetChecked(weiPerWord)
 }
 
 func (retryable *Retryable) MakeTx(chainId *big.Int, nonce uint64, gasFeeCap *big.Int, gas uint64, ticketId common.Hash, refundTo common.Address, maxRefund *big.Int, submissionFeeRefund *big.Int) (*types.ArbitrumRetryTx, error) {
@@ -362,6 +601,307 @@ func (retryable *Retryable) MakeTx(chainId *big.Int, nonce uint64, gasFeeCap *bi
 	}, nil
 }
 
+// MinKeepaliveCost is the least gas a keepalive costs regardless of the ticket's size, or 0 if there's no floor
+func (rs *RetryableState) MinKeepaliveCost() (uint64, error) {
+	return rs.retryables.GetUint64ByUint64(minKeepaliveCostOffset)
+}
+
+func (rs *RetryableState) SetMinKeepaliveCost(cost uint64) error {
+	return rs.retryables.SetUint64ByUint64(minKeepaliveCostOffset, cost)
+}
+
+// CreatorAllowlistEnabled reports whether only allowed senders may create retryables
+func (rs *RetryableState) CreatorAllowlistEnabled() (bool, error) {
+	enabled, err := rs.retryables.GetUint64ByUint64(creatorAllowlistEnabledOffset)
+	return enabled != 0, err
+}
+
+func (rs *RetryableState) SetCreatorAllowlistEnabled(enabled bool) error {
+	if enabled {
+		return rs.retryables.SetUint64ByUint64(creatorAllowlistEnabledOffset, 1)
+	}
+	return rs.retryables.SetUint64ByUint64(creatorAllowlistEnabledOffset, 0)
+}
+
+// Creators is the set of senders allowed to create retryables when the allowlist is enabled.
+// L1 contracts submit retryables as their aliases, so that's how they should be added.
+func (rs *RetryableState) Creators() *addressSet.AddressSet {
+	return addressSet.OpenAddressSet(rs.retryables.OpenCachedSubStorage(creatorsKey))
+}
+
+// CheckCreator returns ErrCreatorNotAllowed if the allowlist is enabled and the sender isn't on it
+func (rs *RetryableState) CheckCreator(sender common.Address) error {
+	enabled, err := rs.CreatorAllowlistEnabled()
+	if err != nil || !enabled {
+		return err
+	}
+	allowed, err := rs.Creators().IsMember(sender)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %v", ErrCreatorNotAllowed, sender)
+	}
+	return nil
+}
+
+// ExpiryGracePeriod is how many seconds after expiring a retryable can still be revived with Keepalive before
+// it's deleted. Retryables can't be redeemed during their grace period.
+func (rs *RetryableState) ExpiryGracePeriod() (uint64, error) {
+	return rs.retryables.GetUint64ByUint64(expiryGracePeriodOffset)
+}
+
+func (rs *RetryableState) SetExpiryGracePeriod(seconds uint64) error {
+	if seconds >= RetryableLifetimeSeconds {
+		return fmt.Errorf("expiry grace period must be less than the %v second retryable lifetime", RetryableLifetimeSeconds)
+	}
+	return rs.retryables.SetUint64ByUint64(expiryGracePeriodOffset, seconds)
+}
+
+// MaxRetryablesPerBlock is how many retryables may be created in each block, where 0 means there's no limit
+func (rs *RetryableState) MaxRetryablesPerBlock() (uint64, error) {
+	return rs.retryables.GetUint64ByUint64(maxRetryablesPerBlockOffset)
+}
+
+func (rs *RetryableState) SetMaxRetryablesPerBlock(max uint64) error {
+	return rs.retryables.SetUint64ByUint64(maxRetryablesPerBlockOffset, max)
+}
+
+// creationsInBlock is how many retryables have been counted towards the block's limit
+func (rs *RetryableState) creationsInBlock(blockNumber uint64) (uint64, error) {
+	countedBlock, err := rs.retryables.GetUint64ByUint64(creationsBlockOffset)
+	if err != nil || countedBlock != blockNumber {
+		return 0, err
+	}
+	return rs.retryables.GetUint64ByUint64(creationsCountOffset)
+}
+
+// MinDeposit is the least value a retryable's submission must bring in, so that dust tickets don't take up storage
+func (rs *RetryableState) MinDeposit() (*big.Int, error) {
+	return rs.retryables.OpenStorageBackedBigUint(minDepositOffset).Get()
+}
+
+func (rs *RetryableState) SetMinDeposit(minimum *big.Int) error {
+	return rs.retryables.OpenStorageBackedBigUint(minDepositOffset).SetChecked(minimum)
+}
+
+// CheckDeposit returns ErrDepositTooSmall if the submission's deposit is below the minimum
+func (rs *RetryableState) CheckDeposit(deposit *big.Int) error {
+	minimum, err := rs.MinDeposit()
+	if err != nil {
+		return err
+	}
+	if deposit.Cmp(minimum) < 0 {
+		return fmt.Errorf("%w: %v is less than %v", ErrDepositTooSmall, deposit, minimum)
+	}
+	return nil
+}
+
+// MaxTries gets how many redeem attempts a retryable gets before it's deleted, where 0 means unlimited
+func (rs *RetryableState) MaxTries() (uint64, error) {
+	return rs.retryables.GetUint64ByUint64(maxTriesOffset)
+}
+
+func (rs *RetryableState) SetMaxTries(maxTries uint64) error {
+	return rs.retryables.SetUint64ByUint64(maxTriesOffset, maxTries)
+}
+
+// TriesExhausted checks whether the retryable has been attempted as many times as it may be
+func (rs *RetryableState) TriesExhausted(retryable *Retryable) (bool, error) {
+	maxTries, err := rs.MaxTries()
+	if err != nil || maxTries == 0 {
+		return false, err
+	}
+	numTries, err := retryable.NumTries()
+	return numTries >= maxTries, err
+}
+
+// CheckTarget returns ErrUncallableTarget if the retryable's redeems couldn't do what a call to its target means to.
+// Redeems call precompiles like any other contract, with the retryable's sender as the caller, so the owner-only
+// and payable checks are the same as for direct calls. The exceptions are ArbosActs, which only ArbOS itself may
+// call, and NodeInterface, which only exists for RPCs; on-chain its address is an empty account that a redeem
+// would silently succeed in calling.
+func CheckTarget(to *common.Address) error {
+	if to == nil {
+		return nil
+	}
+	switch *to {
+	case types.ArbosAddress, types.NodeInterfaceAddress, types.NodeInterfaceDebugAddress:
+		return fmt.Errorf("%w: %v", ErrUncallableTarget, *to)
+	}
+	return nil
+}
+
+// CheckCreationRateLimit returns ErrCreationRateLimited if the block already has as many retryables as are allowed
+func (rs *RetryableState) CheckCreationRateLimit(blockNumber uint64) error {
+	limit, err := rs.MaxRetryablesPerBlock()
+	if err != nil || limit == 0 {
+		return err
+	}
+	created, err := rs.creationsInBlock(blockNumber)
+	if err != nil {
+		return err
+	}
+	if created >= limit {
+		return fmt.Errorf("%w: %v of %v were already created in block %v", ErrCreationRateLimited, created, limit, blockNumber)
+	}
+	return nil
+}
+
+// RecordCreation counts a retryable created in the block towards its limit, starting the count over in each block.
+// Creations aren't counted while there's no limit.
+func (rs *RetryableState) RecordCreation(blockNumber uint64) error {
+	limit, err := rs.MaxRetryablesPerBlock()
+	if err != nil || limit == 0 {
+		return err
+	}
+	created, err := rs.creationsInBlock(blockNumber)
+	if err != nil {
+		return err
+	}
+	if err := rs.retryables.SetUint64ByUint64(creationsBlockOffset, blockNumber); err != nil {
+		return err
+	}
+	return rs.retryables.SetUint64ByUint64(creationsCountOffset, created+1)
+}
+
+// NextL2SubmissionId returns a fresh ticket id for a retryable submitted from L2.
+// Unlike inbox submissions there's no request id to derive it from, so a count of L2 submissions is used instead.
+func (rs *RetryableState) NextL2SubmissionId(chainId *big.Int, from common.Address) (common.Hash, error) {
+	count := rs.retryables.OpenStorageBackedUint64(l2SubmissionCountOffset)
+	nonce, err := count.Increment()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return L2SubmissionTicketId(chainId, from, nonce-1), nil
+}
+
+// L2SubmissionCount is how many retryables have been submitted from L2, which is the nonce the next one will use
+func (rs *RetryableState) L2SubmissionCount() (uint64, error) {
+	return rs.retryables.GetUint64ByUint64(l2SubmissionCountOffset)
+}
+
+// InboxTicketId is the id of a retryable submitted through the inbox as the given delayed message, which is the hash
+// of the submit tx ArbOS creates from the message. The sender and addresses are as the message carries them.
+func InboxTicketId(
+	chainId *big.Int,
+	messageNum uint64,
+	from common.Address,
+	l1BaseFee *big.Int,
+	deposit *big.Int,
+	retryTo *common.Address,
+	callValue *big.Int,
+	maxSubmissionFee *big.Int,
+	feeRefundAddr common.Address,
+	beneficiary common.Address,
+	gasLimit uint64,
+	maxFeePerGas *big.Int,
+	data []byte,
+) common.Hash {
+	return types.NewTx(&types.ArbitrumSubmitRetryableTx{
+		ChainId:          chainId,
+		RequestId:        common.BigToHash(arbmath.UintToBig(messageNum)),
+		From:             from,
+		L1BaseFee:        l1BaseFee,
+		DepositValue:     deposit,
+		GasFeeCap:        maxFeePerGas,
+		Gas:              gasLimit,
+		RetryTo:          retryTo,
+		RetryValue:       callValue,
+		Beneficiary:      beneficiary,
+		MaxSubmissionFee: maxSubmissionFee,
+		FeeRefundAddr:    feeRefundAddr,
+		RetryData:        data,
+	}).Hash()
+}
+
+func L2SubmissionTicketId(chainId *big.Int, from common.Address, nonce uint64) common.Hash {
+	return crypto.Keccak256Hash(
+		[]byte("l2 retryable"), common.BigToHash(chainId).Bytes(), from.Bytes(), arbmath.UintToBytes(nonce),
+	)
+}
+
+// RetryableLayoutVersion is the storage layout tickets are migrated to by MigrateRetryableStorage.
+// Tickets that predate layout tracking are version 0; version 1 has the same fields and only starts tracking versions.
+const RetryableLayoutVersion uint64 = 1
+
+// retryableLayoutUpgrades[v] rewrites a ticket's storage from layout v to layout v+1
+var retryableLayoutUpgrades = []func(sto *storage.Storage) error{
+	func(sto *storage.Storage) error { return nil },
+}
+
+// Layout versions are kept apart from the retryable's fields, like auto-redeem outcomes, and are cleared with it
+func (rs *RetryableState) layoutVersion(ticketId common.Hash) storage.StorageBackedUint64 {
+	return rs.retryables.OpenCachedSubStorage(layoutsKey).OpenSubStorage(ticketId.Bytes()).OpenStorageBackedUint64(0)
+}
+
+// LayoutVersion gets the storage layout of a ticket
+func (rs *RetryableState) LayoutVersion(ticketId common.Hash) (uint64, error) {
+	version := rs.layoutVersion(ticketId)
+	return version.Get()
+}
+
+// MigrateRetryableStorage upgrades the tickets to the current storage layout in place, returning how many it changed.
+// Tickets that don't exist or are already current are skipped, so migrating the same tickets again is harmless.
+func (rs *RetryableState) MigrateRetryableStorage(ticketIds []common.Hash) (uint64, error) {
+	migrated := uint64(0)
+	for _, ticketId := range ticketIds {
+		timeout, err := rs.TimeoutIncludingExpired(ticketId)
+		if err != nil {
+			return migrated, err
+		}
+		if timeout == 0 {
+			continue
+		}
+		versionStorage := rs.layoutVersion(ticketId)
+		version, err := versionStorage.Get()
+		if err != nil {
+			return migrated, err
+		}
+		if version >= RetryableLayoutVersion {
+			continue
+		}
+		sto := rs.retryables.OpenSubStorage(ticketId.Bytes())
+		for ; version < RetryableLayoutVersion; version++ {
+			if err := retryableLayoutUpgrades[version](sto); err != nil {
+				return migrated, err
+			}
+		}
+		if err := versionStorage.Set(version); err != nil {
+			return migrated, err
+		}
+		migrated++
+	}
+	return migrated, nil
+}
+
+// Fee caps are kept apart from the retryable's fields, like layout versions, so that tickets created before they
+// existed are unchanged. They're cleared with the retryable.
+func (rs *RetryableState) maxFeePerGas(ticketId common.Hash) storage.StorageBackedBigUint {
+	return rs.retryables.OpenCachedSubStorage(feeCapsKey).OpenSubStorage(ticketId.Bytes()).OpenStorageBackedBigUint(0)
+}
+
+// MaxFeePerGas gets the most per gas the ticket's submitter agreed to pay for its retries, where 0 means no cap
+func (rs *RetryableState) MaxFeePerGas(ticketId common.Hash) (*big.Int, error) {
+	feeCap := rs.maxFeePerGas(ticketId)
+	return feeCap.Get()
+}
+
+func (rs *RetryableState) SetMaxFeePerGas(ticketId common.Hash, maxFeePerGas *big.Int) error {
+	feeCap := rs.maxFeePerGas(ticketId)
+	return feeCap.SetChecked(maxFeePerGas)
+}
+
+// ApprovedRedeemer is the only address besides its beneficiary that may redeem the ticket, or zero if anyone may
+func (rs *RetryableState) ApprovedRedeemer(ticketId common.Hash) (common.Address, error) {
+	redeemer, err := rs.retryables.OpenCachedSubStorage(redeemersKey).Get(ticketId)
+	return common.BytesToAddress(redeemer.Bytes()), err
+}
+
+func (rs *RetryableState) SetApprovedRedeemer(ticketId common.Hash, redeemer common.Address) error {
+	return rs.retryables.OpenCachedSubStorage(redeemersKey).Set(ticketId, common.BytesToHash(redeemer.Bytes()))
+}
+
 func RetryableEscrowAddress(ticketId common.Hash) common.Address {
 	return common.BytesToAddress(crypto.Keccak256([]byte("retryable escrow"), ticketId.Bytes()))
 }
@@ -369,3 +909,121 @@ func RetryableEscrowAddress(ticketId common.Hash) common.Address {
 func RetryableSubmissionFee(calldataLengthInBytes int, l1BaseFee *big.Int) *big.Int {
 	return arbmath.BigMulByUint(l1BaseFee, uint64(1400+6*calldataLengthInBytes))
 }
+
+// AutoRedeemStatus is what became of the redeem attempt scheduled when a retryable was submitted
+type AutoRedeemStatus uint64
+
+const (
+	AutoRedeemNotAttempted AutoRedeemStatus = iota
+	AutoRedeemPending
+	AutoRedeemSucceeded
+	AutoRedeemFailed
+)
+
+const (
+	autoRedeemStatusOffset uint64 = iota
+	autoRedeemTxIdOffset
+	autoRedeemSubmissionFeeRefundOffset
+)
+
+// Auto-redeem outcomes are kept apart from the retryable's fields, and are cleared when it's deleted
+func (rs *RetryableState) autoRedeemStorage(ticketId common.Hash) *storage.Storage {
+	return rs.retryables.OpenCachedSubStorage(autoRedeemsKey).OpenSubStorage(ticketId.Bytes())
+}
+
+// RecordAutoRedeem notes that the retryable's submission scheduled the given retry
+func (rs *RetryableState) RecordAutoRedeem(ticketId common.Hash, retryTxId common.Hash) error {
+	sto := rs.autoRedeemStorage(ticketId)
+	if err := sto.SetUint64ByUint64(autoRedeemStatusOffset, uint64(AutoRedeemPending)); err != nil {
+		return err
+	}
+	return sto.SetByUint64(autoRedeemTxIdOffset, retryTxId)
+}
+
+// FinishAutoRedeem records the outcome of a retry, if it's the retryable's pending auto-redeem
+func (rs *RetryableState) FinishAutoRedeem(ticketId common.Hash, retryTxId common.Hash, success bool) error {
+	sto := rs.autoRedeemStorage(ticketId)
+	status, err := sto.GetUint64ByUint64(autoRedeemStatusOffset)
+	if err != nil || AutoRedeemStatus(status) != AutoRedeemPending {
+		return err
+	}
+	pendingTxId, err := sto.GetByUint64(autoRedeemTxIdOffset)
+	if err != nil || pendingTxId != retryTxId {
+		return err
+	}
+	status = uint64(AutoRedeemFailed)
+	if success {
+		status = uint64(AutoRedeemSucceeded)
+	}
+	if err := sto.ClearByUint64(autoRedeemSubmissionFeeRefundOffset); err != nil {
+		return err
+	}
+	return sto.SetUint64ByUint64(autoRedeemStatusOffset, status)
+}
+
+// RecordSubmissionFeeRefund notes the submission fee the retryable's pending auto-redeem refunds if it succeeds
+func (rs *RetryableState) RecordSubmissionFeeRefund(ticketId common.Hash, refund *big.Int) error {
+	pending := rs.autoRedeemStorage(ticketId).OpenStorageBackedBigUint(autoRedeemSubmissionFeeRefundOffset)
+	return pending.SetChecked(refund)
+}
+
+// PendingSubmissionFeeRefund gets the submission fee that's still to be refunded, which is 0 once the auto-redeem finishes
+func (rs *RetryableState) PendingSubmissionFeeRefund(ticketId common.Hash) (*big.Int, error) {
+	pending := rs.autoRedeemStorage(ticketId).OpenStorageBackedBigUint(autoRedeemSubmissionFeeRefundOffset)
+	return pending.Get()
+}
+
+// AutoRedeemResult gets the status of the retryable's auto-redeem and the id of its retry, if one was scheduled
+func (rs *RetryableState) AutoRedeemResult(ticketId common.Hash) (AutoRedeemStatus, common.Hash, error) {
+	sto := rs.autoRedeemStorage(ticketId)
+	status, err := sto.GetUint64ByUint64(autoRedeemStatusOffset)
+	if err != nil {
+		return AutoRedeemNotAttempted, common.Hash{}, err
+	}
+	retryTxId, err := sto.GetByUint64(autoRedeemTxIdOffset)
+	return AutoRedeemStatus(status), retryTxId, err
+}
+
+const (
+	redeemTxTicketIdOffset uint64 = iota
+	redeemTxSequenceNumOffset
+)
+
+// Scheduled redeems are indexed by their tx id so that a redeem can be traced back to the ticket and attempt that
+// produced it. The index is only kept while the retryable exists.
+func (rs *RetryableState) redeemTxStorage(redeemTxId common.Hash) *storage.Storage {
+	return rs.retryables.OpenCachedSubStorage(redeemTxsKey).OpenSubStorage(redeemTxId.Bytes())
+}
+
+// ticketRedeemTxs lists the tx ids of the ticket's redeem attempts by sequence number, in the ticket's own storage,
+// so that deleting the ticket can find and clear their index entries
+func (rs *RetryableState) ticketRedeemTxs(ticketId common.Hash) *storage.Storage {
+	return rs.retryables.OpenSubStorage(ticketId.Bytes()).OpenSubStorage(redeemTxsKey)
+}
+
+// RedeemTxRecordCost is the gas RecordRedeemTx spends writing a redeem's index entries
+const RedeemTxRecordCost = 3 * storage.StorageWriteCost
+
+// RecordRedeemTx notes that the retryable's sequenceNum'th redeem attempt is the tx with the given id
+func (rs *RetryableState) RecordRedeemTx(redeemTxId common.Hash, ticketId common.Hash, sequenceNum uint64) error {
+	sto := rs.redeemTxStorage(redeemTxId)
+	if err := sto.SetByUint64(redeemTxTicketIdOffset, ticketId); err != nil {
+		return err
+	}
+	if err := sto.SetUint64ByUint64(redeemTxSequenceNumOffset, sequenceNum); err != nil {
+		return err
+	}
+	return rs.ticketRedeemTxs(ticketId).SetByUint64(sequenceNum, redeemTxId)
+}
+
+// RedeemTxTicket gets the retryable and sequence number of the redeem attempt with the given id.
+// The ticket id is zero if no such redeem was recorded.
+func (rs *RetryableState) RedeemTxTicket(redeemTxId common.Hash) (common.Hash, uint64, error) {
+	sto := rs.redeemTxStorage(redeemTxId)
+	ticketId, err := sto.GetByUint64(redeemTxTicketIdOffset)
+	if err != nil {
+		return common.Hash{}, 0, err
+	}
+	sequenceNum, err := sto.GetUint64ByUint64(redeemTxSequenceNumOffset)
+	return ticketId, sequenceNum, err
+}
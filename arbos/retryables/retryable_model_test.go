@@ -0,0 +1,460 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package retryables
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+// The model applies sequences of operations to a RetryableState the way ArbOS does, tracking what each ticket's
+// timeout and escrow should be, and checks the state against it after every step.
+
+type modelOpKind uint8
+
+const (
+	opCreate modelOpKind = iota
+	opKeepalive
+	opRedeem
+	opCancel
+	opAdvance
+	modelOpKinds
+)
+
+type modelOp struct {
+	kind   modelOpKind
+	ticket uint8 // which ticket the op applies to, modulo how many have been created
+	step   uint8 // how much to advance time by, or the callvalue of a created ticket
+}
+
+func (op modelOp) String() string {
+	names := []string{"create", "keepalive", "redeem", "cancel", "advance"}
+	return fmt.Sprintf("%v(%v, %v)", names[op.kind], op.ticket, op.step)
+}
+
+const modelGracePeriod = RetryableLifetimeSeconds / 8
+const modelArbOSVersion = 20
+
+// time steps land on either side of lifetimes and grace periods ending
+var modelTimeSteps = []uint64{
+	1, 60, modelGracePeriod / 2, modelGracePeriod, modelGracePeriod + 1, RetryableLifetimeSeconds / 2,
+	RetryableLifetimeSeconds - 1, RetryableLifetimeSeconds,
+}
+
+var modelBeneficiaries = []common.Address{{0xb1}, {0xb2}, {0xb3}}
+var modelTargets = []common.Address{{0xc1}, {0xc2}}
+
+type modelTicket struct {
+	id          common.Hash
+	beneficiary common.Address
+	target      common.Address
+	callvalue   *big.Int
+	timeout     uint64 // including any windows and revives
+	deleted     bool
+}
+
+type retryableModel struct {
+	rs      *RetryableState
+	evm     *vm.EVM
+	now     uint64
+	tickets []*modelTicket
+	minted  *big.Int // total callvalue put into escrow
+}
+
+func newRetryableModel() (*retryableModel, error) {
+	statedb := storage.NewMemoryBackedStateDB()
+	sto := storage.NewGeth(statedb, burn.NewSystemBurner(nil, false))
+	if err := InitializeRetryableState(sto); err != nil {
+		return nil, err
+	}
+	rs := OpenRetryableState(sto, statedb)
+	if err := rs.SetExpiryGracePeriod(modelGracePeriod); err != nil {
+		return nil, err
+	}
+	return &retryableModel{
+		rs:     rs,
+		evm:    vm.NewEVM(vm.BlockContext{}, vm.TxContext{}, statedb, &params.ChainConfig{}, vm.Config{}),
+		now:    1 << 20,
+		minted: new(big.Int),
+	}, nil
+}
+
+func (m *retryableModel) apply(op modelOp) error {
+	if op.kind == opCreate {
+		return m.create(op.step)
+	}
+	if op.kind == opAdvance {
+		return m.advance(modelTimeSteps[int(op.step)%len(modelTimeSteps)])
+	}
+	if len(m.tickets) == 0 {
+		return nil
+	}
+	ticket := m.tickets[int(op.ticket)%len(m.tickets)]
+	switch op.kind {
+	case opKeepalive:
+		return m.keepalive(ticket)
+	case opRedeem:
+		return m.redeem(ticket)
+	case opCancel:
+		return m.cancel(ticket)
+	}
+	return fmt.Errorf("unknown op %v", op.kind)
+}
+
+func (m *retryableModel) create(callvalue uint8) error {
+	n := len(m.tickets)
+	ticket := &modelTicket{
+		id:          common.BigToHash(big.NewInt(int64(n + 1))),
+		beneficiary: modelBeneficiaries[n%len(modelBeneficiaries)],
+		target:      modelTargets[n%len(modelTargets)],
+		callvalue:   big.NewInt(int64(callvalue)),
+		timeout:     m.now + RetryableLifetimeSeconds,
+	}
+	_, err := m.rs.CreateRetryable(
+		ticket.id, ticket.timeout, common.Address{0xa1}, &ticket.target, ticket.callvalue, ticket.beneficiary, nil,
+	)
+	if err != nil {
+		return err
+	}
+	if err := m.rs.IndexBeneficiary(ticket.id, ticket.beneficiary); err != nil {
+		return err
+	}
+	m.evm.StateDB.AddBalance(RetryableEscrowAddress(ticket.id), ticket.callvalue)
+	m.minted.Add(m.minted, ticket.callvalue)
+	m.tickets = append(m.tickets, ticket)
+	return nil
+}
+
+// keepalive extends the ticket as ArbRetryableTx.Keepalive does, reviving it if it's in its grace period
+func (m *retryableModel) keepalive(ticket *modelTicket) error {
+	expired, err := m.rs.OpenExpiredInGracePeriod(ticket.id, m.now)
+	if err != nil {
+		return err
+	}
+	var newTimeout uint64
+	if expired != nil {
+		if ticket.deleted || ticket.timeout >= m.now || ticket.timeout+modelGracePeriod < m.now {
+			return fmt.Errorf("ticket %v can be revived but expires at %v", ticket.id, ticket.timeout)
+		}
+		newTimeout, err = m.rs.Revive(expired)
+		if err != nil {
+			return err
+		}
+	} else {
+		retryable, err := m.rs.OpenRetryable(ticket.id, m.now)
+		if retryable == nil || err != nil {
+			return err
+		}
+		newTimeout, err = m.rs.Keepalive(ticket.id, m.now, m.now+RetryableLifetimeSeconds, RetryableLifetimeSeconds)
+		if err != nil {
+			if err.Error() == "timeout too far into the future" {
+				return nil
+			}
+			return err
+		}
+	}
+	if newTimeout != ticket.timeout+RetryableLifetimeSeconds {
+		return fmt.Errorf("ticket %v was extended to %v instead of a lifetime past %v", ticket.id, newTimeout, ticket.timeout)
+	}
+	ticket.timeout = newTimeout
+
+	// having paid to extend the ticket, it must be usable
+	retryable, err := m.rs.OpenRetryable(ticket.id, m.now)
+	if err != nil {
+		return err
+	}
+	if retryable == nil {
+		return fmt.Errorf("ticket %v can't be opened after being extended to %v", ticket.id, newTimeout)
+	}
+	return nil
+}
+
+// redeem models a successful retry, which delivers the callvalue to the target before deleting the ticket
+func (m *retryableModel) redeem(ticket *modelTicket) error {
+	retryable, err := m.rs.OpenRetryable(ticket.id, m.now)
+	if retryable == nil || err != nil {
+		return err
+	}
+	escrow := RetryableEscrowAddress(ticket.id)
+	if err := util.TransferBalance(&escrow, &ticket.target, ticket.callvalue, m.evm, util.TracingDuringEVM, "escrow"); err != nil {
+		return err
+	}
+	return m.delete(ticket)
+}
+
+func (m *retryableModel) cancel(ticket *modelTicket) error {
+	retryable, err := m.rs.OpenRetryable(ticket.id, m.now)
+	if retryable == nil || err != nil {
+		return err
+	}
+	return m.delete(ticket)
+}
+
+func (m *retryableModel) delete(ticket *modelTicket) error {
+	if ticket.deleted || ticket.timeout < m.now {
+		return fmt.Errorf("ticket %v could be opened at %v after expiring at %v", ticket.id, m.now, ticket.timeout)
+	}
+	deleted, err := m.rs.DeleteRetryable(ticket.id, m.evm, util.TracingDuringEVM, modelArbOSVersion)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("live ticket %v wasn't deleted", ticket.id)
+	}
+	ticket.deleted = true
+	return nil
+}
+
+// advance moves time forward and then reaps as a new block does
+func (m *retryableModel) advance(seconds uint64) error {
+	m.now += seconds
+	for i := 0; i < 2; i++ {
+		reaped, err := m.rs.ReapOneRetryable(m.now, m.evm, util.TracingDuringEVM, modelArbOSVersion)
+		if err != nil {
+			return err
+		}
+		if reaped == nil {
+			continue
+		}
+		ticket := m.lookup(*reaped)
+		if ticket == nil || ticket.deleted {
+			return fmt.Errorf("reaped unknown or deleted ticket %v", *reaped)
+		}
+		if ticket.timeout+modelGracePeriod >= m.now {
+			return fmt.Errorf("reaped ticket %v at %v before its grace period after %v ended", ticket.id, m.now, ticket.timeout)
+		}
+		ticket.deleted = true
+	}
+	return nil
+}
+
+func (m *retryableModel) lookup(id common.Hash) *modelTicket {
+	for _, ticket := range m.tickets {
+		if ticket.id == id {
+			return ticket
+		}
+	}
+	return nil
+}
+
+func (m *retryableModel) checkInvariants() error {
+	entries := make(map[common.Hash]uint64)
+	err := m.rs.TimeoutQueue.ForEach(func(_ uint64, id common.Hash) (bool, error) {
+		entries[id]++
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	total := new(big.Int)
+	indexed := make(map[common.Address]map[common.Hash]bool)
+	for _, ticket := range m.tickets {
+		escrowed := m.evm.StateDB.GetBalance(RetryableEscrowAddress(ticket.id))
+		total.Add(total, escrowed)
+		timeout, err := m.rs.TimeoutIncludingExpired(ticket.id)
+		if err != nil {
+			return err
+		}
+		if ticket.deleted {
+			if timeout != 0 || escrowed.Sign() != 0 {
+				return fmt.Errorf("deleted ticket %v has timeout %v and escrow %v", ticket.id, timeout, escrowed)
+			}
+			continue
+		}
+		if timeout != ticket.timeout {
+			return fmt.Errorf("ticket %v times out at %v instead of %v", ticket.id, timeout, ticket.timeout)
+		}
+		if timeout > m.now+2*RetryableLifetimeSeconds {
+			return fmt.Errorf("ticket %v times out at %v, more than two lifetimes after %v", ticket.id, timeout, m.now)
+		}
+		if !arbmath.BigEquals(escrowed, ticket.callvalue) {
+			return fmt.Errorf("ticket %v has %v escrowed instead of its callvalue %v", ticket.id, escrowed, ticket.callvalue)
+		}
+		sto := m.rs.retryables.OpenSubStorage(ticket.id.Bytes())
+		windows, err := sto.GetUint64ByUint64(timeoutWindowsLeftOffset)
+		if err != nil {
+			return err
+		}
+		stale, err := m.rs.retryables.OpenCachedSubStorage(staleEntriesKey).GetUint64(ticket.id)
+		if err != nil {
+			return err
+		}
+		if entries[ticket.id] < windows+1+stale {
+			return fmt.Errorf("ticket %v has %v queue entries for %v windows and %v stale entries", ticket.id, entries[ticket.id], windows, stale)
+		}
+		if indexed[ticket.beneficiary] == nil {
+			indexed[ticket.beneficiary] = make(map[common.Hash]bool)
+		}
+		indexed[ticket.beneficiary][ticket.id] = true
+	}
+
+	for _, beneficiary := range modelBeneficiaries {
+		listed, err := m.rs.RetryablesByBeneficiary(beneficiary, 0, uint64(len(m.tickets))+1)
+		if err != nil {
+			return err
+		}
+		if len(listed) != len(indexed[beneficiary]) {
+			return fmt.Errorf("beneficiary %v has %v tickets listed instead of %v", beneficiary, len(listed), len(indexed[beneficiary]))
+		}
+		for _, id := range listed {
+			if !indexed[beneficiary][id] {
+				return fmt.Errorf("beneficiary %v lists ticket %v that isn't live", beneficiary, id)
+			}
+		}
+	}
+
+	// callvalue is only ever moved from escrow to a target or beneficiary
+	for _, account := range append(append([]common.Address{}, modelBeneficiaries...), modelTargets...) {
+		total.Add(total, m.evm.StateDB.GetBalance(account))
+	}
+	if !arbmath.BigEquals(total, m.minted) {
+		return fmt.Errorf("%v callvalue is accounted for out of %v escrowed", total, m.minted)
+	}
+	return nil
+}
+
+// run applies the ops in order, returning the first invariant violation along with the op's index
+func (m *retryableModel) run(ops []modelOp) (int, error) {
+	for i, op := range ops {
+		if err := m.apply(op); err != nil {
+			return i, err
+		}
+		if err := m.checkInvariants(); err != nil {
+			return i, err
+		}
+	}
+	return len(ops), nil
+}
+
+func runRetryableModel(ops []modelOp) (int, error) {
+	m, err := newRetryableModel()
+	if err != nil {
+		return -1, err
+	}
+	return m.run(ops)
+}
+
+// shrinkRetryableOps removes ops from a failing sequence for as long as it keeps failing
+func shrinkRetryableOps(ops []modelOp) []modelOp {
+	if index, err := runRetryableModel(ops); err != nil {
+		ops = ops[:index+1]
+	}
+	for shrunk := true; shrunk; {
+		shrunk = false
+		for i := len(ops) - 1; i >= 0; i-- {
+			candidate := append(append([]modelOp{}, ops[:i]...), ops[i+1:]...)
+			if index, err := runRetryableModel(candidate); err != nil {
+				ops = candidate[:index+1]
+				shrunk = true
+				break
+			}
+		}
+	}
+	return ops
+}
+
+func checkRetryableModel(t *testing.T, ops []modelOp) {
+	t.Helper()
+	if _, err := runRetryableModel(ops); err != nil {
+		shrunk := shrinkRetryableOps(ops)
+		_, err := runRetryableModel(shrunk)
+		Fail(t, "retryable invariant violated by", shrunk, ":", err)
+	}
+}
+
+func randomRetryableOps(rng *rand.Rand, count int) []modelOp {
+	ops := make([]modelOp, count)
+	for i := range ops {
+		ops[i] = modelOp{
+			kind:   modelOpKind(rng.Intn(int(modelOpKinds))),
+			ticket: uint8(rng.Intn(256)),
+			step:   uint8(rng.Intn(256)),
+		}
+	}
+	return ops
+}
+
+func TestRetryableStateMachine(t *testing.T) {
+	for seed := int64(0); seed < 64; seed++ {
+		checkRetryableModel(t, randomRetryableOps(rand.New(rand.NewSource(seed)), 200))
+	}
+}
+
+func FuzzRetryableStateMachine(f *testing.F) {
+	f.Add([]byte{0, 0, 7, 4, 0, 7, 1, 0, 0, 4, 0, 3, 1, 0, 0})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ops := make([]modelOp, 0, len(data)/3)
+		for i := 0; i+2 < len(data); i += 3 {
+			ops = append(ops, modelOp{modelOpKind(data[i] % uint8(modelOpKinds)), data[i+1], data[i+2]})
+		}
+		checkRetryableModel(t, ops)
+	})
+}
+
+// Reviving a ticket used to add a timeout window that only the reaper could apply, leaving the ticket that was just
+// paid for unredeemable until the reaper reached its expired entry in the queue.
+func TestRevivedRetryableIsRedeemable(t *testing.T) {
+	m, err := newRetryableModel()
+	Require(t, err)
+	ops := []modelOp{
+		{opCreate, 0, 7},
+		{opCreate, 1, 9},
+		{opAdvance, 0, 7}, // both tickets expire at the same time
+		{opAdvance, 0, 1}, // and are in their grace period, with the first at the head of the queue
+		{opKeepalive, 1, 0},
+		{opAdvance, 0, 4}, // the first is reaped and the second's stale entry discarded
+	}
+	index, err := m.run(ops)
+	if err != nil {
+		Fail(t, "op", index, ops[index], "failed:", err)
+	}
+	if !m.tickets[0].deleted || m.tickets[1].deleted {
+		Fail(t, "reaped the wrong tickets")
+	}
+	size, err := m.rs.TimeoutQueue.Size()
+	Require(t, err)
+	if size != 1 {
+		Fail(t, "revived ticket has", size, "queue entries left instead of just its live one")
+	}
+
+	// the revived ticket can still be redeemed, and nothing is left behind once it is
+	ops = []modelOp{{opRedeem, 1, 0}, {opAdvance, 0, 7}}
+	index, err = m.run(ops)
+	if err != nil {
+		Fail(t, "op", index, ops[index], "failed:", err)
+	}
+	empty, err := m.rs.TimeoutQueue.IsEmpty()
+	Require(t, err)
+	if !empty {
+		Fail(t, "timeout queue wasn't emptied")
+	}
+	stale, err := m.rs.retryables.OpenCachedSubStorage(staleEntriesKey).GetUint64(m.tickets[1].id)
+	Require(t, err)
+	if stale != 0 {
+		Fail(t, "redeemed ticket still has", stale, "stale entries")
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
@@ -5,6 +5,7 @@ package storage
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math/big"
 	"sync/atomic"
@@ -255,6 +256,36 @@ func (s *Storage) GetBytes() ([]byte, error) {
 	return ret, nil
 }
 
+// GetBytesSlice reads up to length bytes starting at offset, loading only the words that hold them.
+// The slice is cut short if it would run past the end of the bytes.
+func (s *Storage) GetBytesSlice(offset uint64, length uint64) ([]byte, error) {
+	size, err := s.GetUint64ByUint64(0)
+	if err != nil {
+		return nil, err
+	}
+	end := arbmath.MinInt(arbmath.SaturatingUAdd(offset, length), size)
+	if offset >= end {
+		return []byte{}, nil
+	}
+	ret := make([]byte, 0, end-offset)
+	for word := offset / 32; word*32 < end; word++ {
+		next, err := s.GetByUint64(word + 1)
+		if err != nil {
+			return nil, err
+		}
+		data := next.Bytes()
+		if word == size/32 {
+			// the last word is partial, and its bytes are right-aligned
+			data = data[32-size%32:]
+		}
+		wordStart := word * 32
+		from := arbmath.SaturatingUSub(offset, wordStart)
+		to := arbmath.MinInt(end-wordStart, uint64(len(data)))
+		ret = append(ret, data[from:to]...)
+	}
+	return ret, nil
+}
+
 func (s *Storage) GetBytesSize() (uint64, error) {
 	return s.GetUint64ByUint64(0)
 }
@@ -519,6 +550,39 @@ func (sbbu *StorageBackedBigUint) SetChecked(val *big.Int) error {
 	return sbbu.StorageSlot.Set(common.BytesToHash(val.Bytes()))
 }
 
+// ErrUnderflow is returned when subtracting more than a storage-backed value holds
+var ErrUnderflow = errors.New("storage-backed value is less than the amount subtracted")
+
+// Add adds delta, which may be negative, to the value and returns the result.
+// Like SetChecked, this will panic if the result doesn't fit with a system burner.
+func (sbbu *StorageBackedBigUint) Add(delta *big.Int) (*big.Int, error) {
+	old, err := sbbu.Get()
+	if err != nil {
+		return nil, err
+	}
+	updated := new(big.Int).Add(old, delta)
+	if err := sbbu.SetChecked(updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Sub subtracts amount from the value and returns the result, leaving the value as it was if it's less than amount
+func (sbbu *StorageBackedBigUint) Sub(amount *big.Int) (*big.Int, error) {
+	old, err := sbbu.Get()
+	if err != nil {
+		return nil, err
+	}
+	updated := new(big.Int).Sub(old, amount)
+	if updated.Sign() < 0 {
+		return nil, ErrUnderflow
+	}
+	if err := sbbu.SetChecked(updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
 func (sbbu *StorageBackedBigUint) SetSaturatingWithWarning(val *big.Int, name string) error {
 	if val.Sign() < 0 {
 		log.Warn("ArbOS storage big uint underflowed", "name", name, "value", val)
@@ -653,6 +717,10 @@ func (sbb *StorageBackedBytes) Set(val []byte) error {
 	return sbb.Storage.SetBytes(val)
 }
 
+func (sbb *StorageBackedBytes) GetSlice(offset uint64, length uint64) ([]byte, error) {
+	return sbb.Storage.GetBytesSlice(offset, length)
+}
+
 func (sbb *StorageBackedBytes) Clear() error {
 	return sbb.Storage.ClearBytes()
 }
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"math/big"
 	"math/rand"
@@ -166,3 +167,120 @@ func TestMapAddressCache(t *testing.T) {
 		t.Fatal(<-errs)
 	}
 }
+
+func TestStorageBackedBytes(t *testing.T) {
+	for _, size := range []int{0, 1, 31, 32, 33, 64, 100} {
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i + 1)
+		}
+		sto := NewMemoryBacked(burn.NewSystemBurner(nil, false)).OpenStorageBackedBytes([]byte("bytes"))
+		if err := sto.Set(data); err != nil {
+			t.Fatal(err)
+		}
+		stored, err := sto.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		storedSize, err := sto.Size()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(stored, data) || storedSize != uint64(size) {
+			t.Fatal("read back", storedSize, "bytes", stored, "instead of", data)
+		}
+
+		for offset := 0; offset <= size+1; offset++ {
+			for _, length := range []int{0, 1, 5, 31, 32, 33, 200} {
+				burner := burn.NewSystemBurner(nil, false)
+				sto := NewMemoryBacked(burner).OpenStorageBackedBytes([]byte("bytes"))
+				if err := sto.Set(data); err != nil {
+					t.Fatal(err)
+				}
+				before := burner.Burned()
+				slice, err := sto.GetSlice(uint64(offset), uint64(length))
+				if err != nil {
+					t.Fatal(err)
+				}
+				start := arbmath.MinInt(offset, size)
+				end := arbmath.MinInt(offset+length, size)
+				if !bytes.Equal(slice, data[start:end]) {
+					t.Fatal("slice", offset, length, "of", size, "bytes was", slice, "instead of", data[start:end])
+				}
+
+				// the size, then each word the slice overlaps
+				words := uint64(0)
+				if end > start {
+					words = uint64((end+31)/32 - start/32)
+				}
+				if burned := burner.Burned() - before; burned != (1+words)*StorageReadCost {
+					t.Fatal("slice", offset, length, "of", size, "bytes burned", burned, "for", words, "words")
+				}
+			}
+		}
+	}
+}
+
+func TestStorageBackedBigUintAddSub(t *testing.T) {
+	statedb := NewMemoryBackedStateDB()
+	open := func() StorageBackedBigUint {
+		return NewGeth(statedb, burn.NewSystemBurner(nil, false)).OpenStorageBackedBigUint(7)
+	}
+	expect := func(sbbu StorageBackedBigUint, expected int64) {
+		t.Helper()
+		value, err := sbbu.Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value.Cmp(big.NewInt(expected)) != 0 {
+			t.Fatal("expected", expected, "but have", value)
+		}
+	}
+
+	sbbu := open()
+	sum, err := sbbu.Add(big.NewInt(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Int64() != 100 {
+		t.Fatal("add returned", sum)
+	}
+	if _, err := sbbu.Add(big.NewInt(-30)); err != nil {
+		t.Fatal(err)
+	}
+	expect(sbbu, 70)
+
+	// values persist across separately opened states, as they would between txs
+	sbbu = open()
+	expect(sbbu, 70)
+
+	if _, err := sbbu.Sub(big.NewInt(71)); !errors.Is(err, ErrUnderflow) {
+		t.Fatal("subtracting too much didn't underflow", err)
+	}
+	expect(sbbu, 70)
+
+	difference, err := sbbu.Sub(big.NewInt(70))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if difference.Sign() != 0 {
+		t.Fatal("subtracting everything left", difference)
+	}
+	expect(open(), 0)
+
+	// adding past the max is an overflow, which panics under a system burner
+	max := new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+	if _, err := sbbu.Add(max); err != nil {
+		t.Fatal(err)
+	}
+	requirePanic(t, "overflowing add", func() {
+		_, _ = sbbu.Add(common.Big1)
+	})
+	value, err := open().Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.Cmp(max) != 0 {
+		t.Fatal("overflowing add changed the value to", value)
+	}
+}
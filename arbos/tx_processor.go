@@ -24,6 +24,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	glog "github.com/ethereum/go-ethereum/log"
 )
 
@@ -38,6 +39,8 @@ type TxProcessor struct {
 	msg              *core.Message
 	state            *arbosState.ArbosState
 	PosterFee        *big.Int // set once in GasChargingHook to track L1 calldata costs
+	aggregatorFee    *big.Int // the part of the poster fee that's the aggregator's tx base fee
+	aggregatorPayTo  common.Address
 	posterGas        uint64
 	computeHoldGas   uint64 // amount of gas temporarily held to prevent compute from exceeding the gas limit
 	delayedInbox     bool   // whether this tx was submitted through the delayed inbox
@@ -46,6 +49,8 @@ type TxProcessor struct {
 	evm              *vm.EVM
 	CurrentRetryable *common.Hash
 	CurrentRefundTo  *common.Address
+	target           common.Address // the tx's recipient, or the address of the contract it deploys
+	targetWasEmpty   bool           // whether the target was an empty account before the tx ran
 
 	// Caches for the latest L1 block number and hash,
 	// for the NUMBER and BLOCKHASH opcodes.
@@ -60,6 +65,7 @@ func NewTxProcessor(evm *vm.EVM, msg *core.Message) *TxProcessor {
 		msg:                 msg,
 		state:               arbosState,
 		PosterFee:           new(big.Int),
+		aggregatorFee:       new(big.Int),
 		posterGas:           0,
 		delayedInbox:        evm.Context.Coinbase != l1pricing.BatchPosterAddress,
 		Callers:             []common.Address{},
@@ -140,10 +146,14 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 		}
 		util.MintBalance(&from, value, evm, util.TracingBeforeEVM, "deposit")
 		defer (startTracer())()
+		fresh := evm.StateDB.Empty(*to)
 		// We intentionally use the variant here that doesn't do tracing,
 		// because this transfer is represented as the outer eth transaction.
 		// This transfer is necessary because we don't actually invoke the EVM.
 		core.Transfer(evm.StateDB, from, *to, value)
+		if p.state.ArbOSVersion() >= 20 && fresh && !evm.StateDB.Empty(*to) {
+			p.state.Restrict(p.state.IncrementAccountCount())
+		}
 		return true, 0, nil, nil
 	case *types.ArbitrumInternalTx:
 		defer (startTracer())()
@@ -170,6 +180,28 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 			return util.TransferBalance(from, to, amount, evm, scenario, "during evm execution")
 		}
 
+		if p.state.ArbOSVersion() >= 20 {
+			// the deposit is kept, but the retryable isn't created if the sender may not create one
+			if err := p.state.RetryableState().CheckCreator(tx.From); err != nil {
+				return true, 0, err, nil
+			}
+			if err := p.state.RetryableState().CheckDeposit(tx.DepositValue); err != nil {
+				return true, 0, err, nil
+			}
+			if err := retryables.CheckTarget(tx.RetryTo); err != nil {
+				return true, 0, err, nil
+			}
+			if err := p.state.RetryableState().CheckCreationRateLimit(evm.Context.BlockNumber.Uint64()); err != nil {
+				if errors.Is(err, retryables.ErrCreationRateLimited) {
+					limit, _ := p.state.RetryableState().MaxRetryablesPerBlock()
+					if emitErr := EmitRetryableCreationRateLimitedEvent(evm, ticketId, limit); emitErr != nil {
+						glog.Error("failed to emit RetryableCreationRateLimited event", "err", emitErr)
+					}
+				}
+				return true, 0, err, nil
+			}
+		}
+
 		// check that the user has enough balance to pay for the max submission fee
 		balanceAfterMint := evm.StateDB.GetBalance(tx.From)
 		if balanceAfterMint.Cmp(tx.MaxSubmissionFee) < 0 {
@@ -238,6 +270,12 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 			tx.RetryData,
 		)
 		p.state.Restrict(err)
+		if p.state.ArbOSVersion() >= 20 {
+			// later redeems won't be scheduled while the basefee is above what the submitter bid
+			p.state.Restrict(p.state.RetryableState().SetMaxFeePerGas(ticketId, tx.GasFeeCap))
+			p.state.Restrict(p.state.RetryableState().IndexBeneficiary(ticketId, tx.Beneficiary))
+			p.state.Restrict(p.state.RetryableState().RecordCreation(evm.Context.BlockNumber.Uint64()))
+		}
 
 		err = EmitTicketCreatedEvent(evm, ticketId)
 		if err != nil {
@@ -323,12 +361,19 @@ func (p *TxProcessor) StartTxHook() (endTxNow bool, gasUsed uint64, err error, r
 		_, err = retryable.IncrementNumTries()
 		p.state.Restrict(err)
 
+		retryTxHash := types.NewTx(retryTxInner).Hash()
+		if p.state.ArbOSVersion() >= 20 {
+			p.state.Restrict(p.state.RetryableState().RecordAutoRedeem(ticketId, retryTxHash))
+			p.state.Restrict(p.state.RetryableState().RecordSubmissionFeeRefund(ticketId, submissionFee))
+			p.state.Restrict(p.state.RetryableState().RecordRedeemTx(retryTxHash, ticketId, retryTxInner.Nonce))
+		}
+
 		err = EmitReedeemScheduledEvent(
 			evm,
 			usergas,
 			retryTxInner.Nonce,
 			ticketId,
-			types.NewTx(retryTxInner).Hash(),
+			retryTxHash,
 			tx.FeeRefundAddr,
 			availableRefund,
 			submissionFee,
@@ -393,8 +438,21 @@ func (p *TxProcessor) GasChargingHook(gasRemaining *uint64) (common.Address, err
 	// as if the user was buying an equivalent amount of L2 compute gas. This hook determines what
 	// that cost looks like, ensuring the user can pay and saving the result for later reference.
 
+	if p.msg.To == nil {
+		p.target = crypto.CreateAddress(p.msg.From, p.evm.StateDB.GetNonce(p.msg.From))
+	} else {
+		p.target = *p.msg.To
+	}
+	p.targetWasEmpty = p.evm.StateDB.Empty(p.target)
+
 	var gasNeededToStartEVM uint64
 	tipReceipient, _ := p.state.NetworkFeeAccount()
+	if p.collectsTips() {
+		infraFeeAccount, _ := p.state.InfraFeeAccount()
+		if infraFeeAccount != (common.Address{}) {
+			tipReceipient = infraFeeAccount
+		}
+	}
 	basefee := p.evm.Context.BaseFee
 
 	var poster common.Address
@@ -419,8 +477,19 @@ func (p *TxProcessor) GasChargingHook(gasRemaining *uint64) (common.Address, err
 		if calldataUnits > 0 {
 			p.state.Restrict(p.state.L1PricingState().AddToUnitsSinceUpdate(calldataUnits))
 		}
+		var txBaseFeeCost *big.Int
+		if p.state.ArbOSVersion() >= 20 {
+			// the poster's fixed fee applies to every tx it posts, even those without calldata
+			var err error
+			txBaseFeeCost, p.aggregatorPayTo, err = p.state.L1PricingState().TxBaseFeeCost(poster)
+			p.state.Restrict(err)
+			posterCost = arbmath.BigAdd(posterCost, txBaseFeeCost)
+		}
 		p.posterGas = GetPosterGas(p.state, basefee, p.msg.TxRunMode, posterCost)
 		p.PosterFee = arbmath.BigMulByUint(basefee, p.posterGas) // round down
+		if txBaseFeeCost != nil {
+			p.aggregatorFee = arbmath.BigMin(txBaseFeeCost, p.PosterFee)
+		}
 		gasNeededToStartEVM = p.posterGas
 	}
 
@@ -464,6 +533,14 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 	}
 	gasUsed := p.msg.GasLimit - gasLeft
 
+	if p.state.ArbOSVersion() >= 20 {
+		p.countCreatedAccounts(success)
+		blockNumber := p.evm.Context.BlockNumber.Uint64()
+		if err := p.state.L2PricingState().AddBlockGasUsed(blockNumber, gasUsed); err != nil {
+			log.Error("failed to count the tx's gas towards its block", "err", err)
+		}
+	}
+
 	if underlyingTx != nil && underlyingTx.Type() == types.ArbitrumRetryTxType {
 		inner, _ := underlyingTx.GetInner().(*types.ArbitrumRetryTx)
 		effectiveBaseFee := inner.GasFeeCap
@@ -532,11 +609,17 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 		}
 		refund(networkFeeAccount, networkRefund)
 
+		if p.state.ArbOSVersion() >= 20 {
+			// this is recorded before the ticket can be deleted below, which clears the outcome along with the ticket
+			err := p.state.RetryableState().FinishAutoRedeem(inner.TicketId, underlyingTx.Hash(), success)
+			p.state.Restrict(err)
+		}
+
 		if success {
 			// we don't want to charge for this
 			tracingInfo := util.NewTracingInfo(p.evm, arbosAddress, p.msg.From, scenario)
 			state := arbosState.OpenSystemArbosStateOrPanic(p.evm.StateDB, tracingInfo, false)
-			_, _ = state.RetryableState().DeleteRetryable(inner.TicketId, p.evm, scenario)
+			_, _ = state.RetryableState().DeleteRetryable(inner.TicketId, p.evm, scenario, state.ArbOSVersion())
 		} else {
 			// return the Callvalue to escrow
 			escrow := retryables.RetryableEscrowAddress(inner.TicketId)
@@ -546,6 +629,21 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 				// and the transaction reverted
 				panic(err)
 			}
+			if p.state.ArbOSVersion() >= 20 {
+				// a retryable that's failed as many times as allowed is given up on, returning its escrow to the beneficiary
+				retryableState := p.state.RetryableState()
+				retryable, err := retryableState.OpenRetryable(inner.TicketId, p.evm.Context.Time)
+				p.state.Restrict(err)
+				if retryable != nil {
+					exhausted, err := retryableState.TriesExhausted(retryable)
+					p.state.Restrict(err)
+					if exhausted {
+						_, err = retryableState.DeleteRetryable(inner.TicketId, p.evm, scenario, p.state.ArbOSVersion())
+						p.state.Restrict(err)
+						p.state.Restrict(EmitExpiredEvent(p.evm, inner.TicketId))
+					}
+				}
+			}
 		}
 		// we've already credited the network fee account, but we didn't charge the gas pool yet
 		p.state.Restrict(p.state.L2PricingState().AddToGasPool(-arbmath.SaturatingCast(gasUsed)))
@@ -585,9 +683,15 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 	if p.state.ArbOSVersion() < 2 {
 		posterFeeDestination = p.evm.Context.Coinbase
 	}
-	util.MintBalance(&posterFeeDestination, p.PosterFee, p.evm, scenario, purpose)
+	l1Fee := p.PosterFee
+	if aggregatorFee := arbmath.BigMin(p.aggregatorFee, p.PosterFee); aggregatorFee.Sign() > 0 {
+		// the aggregator's tx base fee is its own, rather than a reimbursement of L1 costs
+		util.MintBalance(&p.aggregatorPayTo, aggregatorFee, p.evm, scenario, "aggregatorFee")
+		l1Fee = arbmath.BigSub(l1Fee, aggregatorFee)
+	}
+	util.MintBalance(&posterFeeDestination, l1Fee, p.evm, scenario, purpose)
 	if p.state.ArbOSVersion() >= 10 {
-		if _, err := p.state.L1PricingState().AddToL1FeesAvailable(p.PosterFee); err != nil {
+		if _, err := p.state.L1PricingState().AddToL1FeesAvailable(l1Fee); err != nil {
 			log.Error("failed to update L1FeesAvailable: ", "err", err)
 		}
 	}
@@ -611,6 +715,18 @@ func (p *TxProcessor) EndTxHook(gasLeft uint64, success bool) {
 	}
 }
 
+// countCreatedAccounts records the contract the tx deployed or the empty recipient it funded.
+// Accounts created by internal calls aren't visible to ArbOS, so they aren't counted.
+func (p *TxProcessor) countCreatedAccounts(success bool) {
+	if p.msg.To == nil && success {
+		p.state.Restrict(p.state.IncrementContractCount())
+	}
+	if p.targetWasEmpty && !p.evm.StateDB.Empty(p.target) {
+		// a contract deployed to an address that already had a balance or nonce isn't a new account
+		p.state.Restrict(p.state.IncrementAccountCount())
+	}
+}
+
 func (p *TxProcessor) ScheduledTxes() types.Transactions {
 	scheduled := types.Transactions{}
 	time := p.evm.Context.Time
@@ -688,15 +804,29 @@ func (p *TxProcessor) L1BlockHash(blockCtx vm.BlockContext, l1BlockNumber uint64
 	return hash, nil
 }
 
+// collectsTips is whether the chain owner has opted into collecting priority fees.
+// Tips are still dropped for delayed messages, whose senders can't choose their ordering.
+func (p *TxProcessor) collectsTips() bool {
+	if p.state.ArbOSVersion() < 20 || p.delayedInbox {
+		return false
+	}
+	collect, err := p.state.L2PricingState().CollectTips()
+	p.state.Restrict(err)
+	return collect
+}
+
 func (p *TxProcessor) DropTip() bool {
 	version := p.state.ArbOSVersion()
+	if p.collectsTips() {
+		return false
+	}
 	return version != 9 || p.delayedInbox
 }
 
 func (p *TxProcessor) GetPaidGasPrice() *big.Int {
 	gasPrice := p.evm.GasPrice
 	version := p.state.ArbOSVersion()
-	if version != 9 {
+	if version != 9 && !p.collectsTips() {
 		gasPrice = p.evm.Context.BaseFee
 		if p.msg.TxRunMode != core.MessageCommitMode && p.msg.GasFeeCap.Sign() == 0 {
 			gasPrice = common.Big0
@@ -67,12 +67,19 @@ func parseSequencerMessage(ctx context.Context, batchNum uint64, data []byte, da
 	payload := data[40:]
 	log.Info("Inbox parse sequencer message: ", "payload", hex.EncodeToString(payload))
 
-	if len(payload) > 0 && IsDASMessageHeaderByte(payload[0]) {
-		if dasReader == nil {
-			log.Error("No DAS Reader configured, but sequencer message found with DAS header")
+	// detect eigenda message from byte, before DAS as the EigenDA header bytes also have the DAS bit set
+	if len(payload) > 0 && eigenda.IsEigenDADualMessageHeaderByte(payload[0]) {
+		var err error
+		payload, err = eigenda.PayloadFromEigenDADualBatch(payload[1:])
+		if err != nil {
+			return nil, err
+		}
+	} else if len(payload) > 0 && (eigenda.IsEigenDAMessageHeaderByte(payload[0]) || eigenda.IsEigenDACertifiedMessageHeaderByte(payload[0])) {
+		if eigenDAReader == nil {
+			log.Error("No EigenDA Reader configured, but sequencer message found with EigenDA header")
 		} else {
 			var err error
-			payload, err = RecoverPayloadFromDasBatch(ctx, batchNum, data, dasReader, nil, keysetValidationMode)
+			payload, err = eigenda.RecoverPayloadFromEigenDABatch(ctx, payload, eigenDAReader, nil)
 			if err != nil {
 				return nil, err
 			}
@@ -80,15 +87,12 @@ func parseSequencerMessage(ctx context.Context, batchNum uint64, data []byte, da
 				return parsedMsg, nil
 			}
 		}
-	}
-
-	// detect eigenda message from byte
-	if len(payload) > 0 && eigenda.IsEigenDAMessageHeaderByte(payload[0]) {
-		if eigenDAReader == nil {
-			log.Error("No EigenDA Reader configured, but sequencer message found with EigenDA header")
+	} else if len(payload) > 0 && IsDASMessageHeaderByte(payload[0]) {
+		if dasReader == nil {
+			log.Error("No DAS Reader configured, but sequencer message found with DAS header")
 		} else {
 			var err error
-			payload, err = eigenda.RecoverPayloadFromEigenDABatch(ctx, payload[1:], eigenDAReader, nil)
+			payload, err = RecoverPayloadFromDasBatch(ctx, batchNum, data, dasReader, nil, keysetValidationMode)
 			if err != nil {
 				return nil, err
 			}
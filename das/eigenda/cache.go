@@ -0,0 +1,141 @@
+// Copyright 2024-2024, Alt Research, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package eigenda
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/offchainlabs/nitro/util/containers"
+	flag "github.com/spf13/pflag"
+)
+
+// ReadCacheConfig keeps recently read blobs in memory, since validators and RPC nodes read the same blobs repeatedly
+type ReadCacheConfig struct {
+	Enable bool          `koanf:"enable"`
+	Size   int           `koanf:"size"`
+	TTL    time.Duration `koanf:"ttl"`
+}
+
+var DefaultReadCacheConfig = ReadCacheConfig{
+	Enable: false,
+	Size:   64,
+	TTL:    time.Hour,
+}
+
+func ReadCacheConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultReadCacheConfig.Enable, "cache blobs read from EigenDA in memory")
+	f.Int(prefix+".size", DefaultReadCacheConfig.Size, "maximum number of blobs kept in the EigenDA read cache")
+	f.Duration(prefix+".ttl", DefaultReadCacheConfig.TTL, "how long a blob is kept in the EigenDA read cache after it's read (0 to keep it until it's evicted)")
+}
+
+func (c *ReadCacheConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.Size <= 0 {
+		return errors.New("EigenDA read cache is enabled but its size isn't positive")
+	}
+	if c.TTL < 0 {
+		return errors.New("EigenDA read cache TTL can't be negative")
+	}
+	return nil
+}
+
+// blobCacheKey identifies a blob by the serialized KZG commitment in its certificate, which commits to its contents
+// however many refs it's read through
+type blobCacheKey [2 * BytesPerFieldElement]byte
+
+type blobCacheEntry struct {
+	data    []byte
+	digest  [32]byte
+	expires time.Time
+}
+
+// blobCache is a size and age bounded cache of blobs, which checks that entries are intact before returning them
+type blobCache struct {
+	mutex sync.Mutex
+	lru   *containers.LruCache[blobCacheKey, *blobCacheEntry]
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+func newBlobCache(config *ReadCacheConfig) *blobCache {
+	if !config.Enable {
+		return nil
+	}
+	return &blobCache{
+		lru: containers.NewLruCache[blobCacheKey, *blobCacheEntry](config.Size),
+		ttl: config.TTL,
+		now: time.Now,
+	}
+}
+
+// cacheKeyOf is the key the ref's blob is cached under. Refs without a certificate have no commitment to key their
+// blob by, so their blobs aren't cached.
+func cacheKeyOf(ref *EigenDARef) (blobCacheKey, bool) {
+	commitment := ref.Certificate.GetBlobHeader().GetCommitment()
+	if commitment == nil {
+		return blobCacheKey{}, false
+	}
+	var key blobCacheKey
+	copy(key[:BytesPerFieldElement], common.LeftPadBytes(commitment.GetX(), BytesPerFieldElement))
+	copy(key[BytesPerFieldElement:], common.LeftPadBytes(commitment.GetY(), BytesPerFieldElement))
+	return key, true
+}
+
+// digestOf hashes the blob along with its key, so that neither can be swapped without it being noticed
+func digestOf(key blobCacheKey, data []byte) [32]byte {
+	hasher := sha256.New()
+	hasher.Write(key[:])
+	hasher.Write(data)
+	var digest [32]byte
+	copy(digest[:], hasher.Sum(nil))
+	return digest
+}
+
+// get returns a copy of the cached blob, dropping it instead if it's expired or no longer matches what was cached
+func (c *blobCache) get(ref *EigenDARef) ([]byte, bool) {
+	key, ok := cacheKeyOf(ref)
+	if !ok {
+		return nil, false
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.lru.Get(key)
+	if !ok {
+		return nil, false
+	}
+	if c.ttl > 0 && !c.now().Before(entry.expires) {
+		c.lru.Remove(key)
+		return nil, false
+	}
+	if digestOf(key, entry.data) != entry.digest {
+		log.Warn("dropping corrupted EigenDA read cache entry", "commitment", hexutil.Encode(key[:]))
+		c.lru.Remove(key)
+		return nil, false
+	}
+	return bytes.Clone(entry.data), true
+}
+
+func (c *blobCache) add(ref *EigenDARef, data []byte) {
+	key, ok := cacheKeyOf(ref)
+	if !ok {
+		return
+	}
+	entry := &blobCacheEntry{
+		data:    bytes.Clone(data),
+		digest:  digestOf(key, data),
+		expires: c.now().Add(c.ttl),
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.lru.Add(key, entry)
+}
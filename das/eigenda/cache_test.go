@@ -0,0 +1,121 @@
+// Copyright 2024-2024, Alt Research, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package eigenda
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	eigendacommon "github.com/Layr-Labs/eigenda/api/grpc/common"
+	"github.com/Layr-Labs/eigenda/api/grpc/disperser"
+)
+
+// certifiedTestRef is a ref for the mock disperser's batch whose certificate has a commitment made up from the seed
+func certifiedTestRef(client *mockDisperserClient, blobIndex uint32, seed byte) *EigenDARef {
+	return eigenDARefFromCertificate(&disperser.BlobInfo{
+		BlobHeader: &disperser.BlobHeader{
+			Commitment: &eigendacommon.G1Commitment{X: []byte{seed}, Y: []byte{seed, seed}},
+		},
+		BlobVerificationProof: &disperser.BlobVerificationProof{
+			BlobIndex:     blobIndex,
+			BatchMetadata: &disperser.BatchMetadata{BatchHeaderHash: client.batchHeaderHash},
+		},
+	})
+}
+
+func TestEigenDAReadCache(t *testing.T) {
+	ctx := context.Background()
+	client := newMockDisperserClient()
+	client.data = []byte("blob")
+	ref := certifiedTestRef(client, client.blobIndex, 1)
+	eigenDA := newTestEigenDA(client, time.Second*5, time.Second*5)
+	eigenDA.readCache = newBlobCache(&ReadCacheConfig{Enable: true, Size: 4, TTL: time.Minute})
+	now := time.Unix(1700000000, 0)
+	eigenDA.readCache.now = func() time.Time { return now }
+
+	// query reads the blob, checking whether the disperser was asked for it
+	query := func(expectRetrieval bool) {
+		t.Helper()
+		retrievals := client.retrievals
+		data, err := eigenDA.QueryBlob(ctx, ref)
+		Require(t, err)
+		if !bytes.Equal(data, client.data) {
+			Fail(t, "unexpected data", data)
+		}
+		if retrieved := client.retrievals != retrievals; retrieved != expectRetrieval {
+			Fail(t, "expected retrieval", expectRetrieval, "but retrieved", retrieved)
+		}
+	}
+
+	// the first read misses and the next is served from the cache
+	query(true)
+	query(false)
+
+	// blobs are cached by their commitment, so the same blob is served from the cache through any ref
+	cachedRef := ref
+	ref = certifiedTestRef(client, client.blobIndex+1, 1)
+	query(false)
+
+	// while blobs with other commitments aren't
+	ref = certifiedTestRef(client, client.blobIndex, 2)
+	query(true)
+
+	// and refs without a certificate have no commitment to cache their blob by
+	ref = &EigenDARef{BatchHeaderHash: client.batchHeaderHash, BlobIndex: client.blobIndex}
+	query(true)
+	query(true)
+	ref = cachedRef
+
+	// callers can't change what's cached through what they're returned
+	data, err := eigenDA.QueryBlob(ctx, ref)
+	Require(t, err)
+	data[0] ^= 0xff
+	query(false)
+
+	// entries are dropped once they're older than the TTL
+	now = now.Add(time.Minute - time.Second)
+	query(false)
+	now = now.Add(time.Second)
+	query(true)
+	query(false)
+
+	// and entries that no longer match what was cached are dropped rather than returned
+	key, ok := cacheKeyOf(ref)
+	if !ok {
+		Fail(t, "certified ref has no cache key")
+	}
+	entry, ok := eigenDA.readCache.lru.Get(key)
+	if !ok {
+		Fail(t, "blob wasn't cached")
+	}
+	entry.data[0] ^= 0xff
+	query(true)
+	query(false)
+
+	// without a cache every read goes to the disperser
+	eigenDA.readCache = nil
+	query(true)
+	query(true)
+}
+
+func TestReadCacheConfig(t *testing.T) {
+	config := DefaultReadCacheConfig
+	Require(t, config.Validate())
+	config.Enable = true
+	Require(t, config.Validate())
+	config.Size = 0
+	if err := config.Validate(); err == nil {
+		Fail(t, "enabled a read cache without any capacity")
+	}
+	config.Size = 1
+	config.TTL = -time.Second
+	if err := config.Validate(); err == nil {
+		Fail(t, "accepted a negative TTL")
+	}
+	if newBlobCache(&DefaultReadCacheConfig) != nil {
+		Fail(t, "created a read cache while it's disabled")
+	}
+}
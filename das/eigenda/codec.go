@@ -0,0 +1,71 @@
+// Copyright 2024-2024, Alt Research, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package eigenda
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Every field element of an encoded blob holds 31 bytes of the payload behind a zero byte, which keeps it below the
+// field's modulus however the payload's bytes are set.
+const BytesPerEncodedChunk = BytesPerFieldElement - 1
+
+// the payload is prefixed by its length so the zero padding of the last chunk can be told apart from the payload
+const encodedLengthPrefixSize = 4
+
+var ErrMalformedEncodedBlob = errors.New("malformed EigenDA blob encoding")
+
+// EncodeForEigenDA encodes arbitrary bytes into canonical field elements that DecodeFromEigenDA recovers them from
+func EncodeForEigenDA(data []byte) []byte {
+	if uint64(len(data)) > math.MaxUint32 {
+		panic("payload is too large to encode for EigenDA")
+	}
+	prefixed := make([]byte, encodedLengthPrefixSize, encodedLengthPrefixSize+len(data))
+	binary.BigEndian.PutUint32(prefixed, uint32(len(data)))
+	prefixed = append(prefixed, data...)
+
+	chunks := (len(prefixed) + BytesPerEncodedChunk - 1) / BytesPerEncodedChunk
+	encoded := make([]byte, chunks*BytesPerFieldElement)
+	for i := 0; i < chunks; i++ {
+		copy(encoded[i*BytesPerFieldElement+1:(i+1)*BytesPerFieldElement], prefixed[i*BytesPerEncodedChunk:])
+	}
+	return encoded
+}
+
+// DecodeFromEigenDA recovers the bytes EncodeForEigenDA encoded, rejecting blobs it couldn't have produced
+func DecodeFromEigenDA(blob []byte) ([]byte, error) {
+	if len(blob) == 0 || len(blob)%BytesPerFieldElement != 0 {
+		return nil, fmt.Errorf("%w: blob of %v bytes isn't a whole number of field elements", ErrMalformedEncodedBlob, len(blob))
+	}
+	chunks := len(blob) / BytesPerFieldElement
+	prefixed := make([]byte, 0, chunks*BytesPerEncodedChunk)
+	for i := 0; i < chunks; i++ {
+		element := blob[i*BytesPerFieldElement : (i+1)*BytesPerFieldElement]
+		if element[0] != 0 {
+			return nil, fmt.Errorf("%w: field element %v has a nonzero leading byte", ErrMalformedEncodedBlob, i)
+		}
+		prefixed = append(prefixed, element[1:]...)
+	}
+	if len(prefixed) < encodedLengthPrefixSize {
+		return nil, fmt.Errorf("%w: blob is too short to hold its length", ErrMalformedEncodedBlob)
+	}
+	length := uint64(binary.BigEndian.Uint32(prefixed))
+	if length > uint64(len(prefixed)-encodedLengthPrefixSize) {
+		return nil, fmt.Errorf("%w: payload of %v bytes is longer than the blob", ErrMalformedEncodedBlob, length)
+	}
+	payload := prefixed[encodedLengthPrefixSize : encodedLengthPrefixSize+length]
+	padding := prefixed[encodedLengthPrefixSize+length:]
+	if len(padding) >= BytesPerEncodedChunk {
+		return nil, fmt.Errorf("%w: blob has whole field elements of padding", ErrMalformedEncodedBlob)
+	}
+	for _, b := range padding {
+		if b != 0 {
+			return nil, fmt.Errorf("%w: nonzero padding after the payload", ErrMalformedEncodedBlob)
+		}
+	}
+	return payload, nil
+}
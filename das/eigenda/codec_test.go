@@ -0,0 +1,60 @@
+// Copyright 2024-2024, Alt Research, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package eigenda
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestEigenDAEncodingRoundTrip(t *testing.T) {
+	for _, size := range []int{
+		0,
+		1,
+		BytesPerEncodedChunk - encodedLengthPrefixSize,     // the length and payload fill one chunk
+		BytesPerEncodedChunk - encodedLengthPrefixSize + 1, // and spill into a second
+		BytesPerEncodedChunk * 3,
+		1 << 20,
+	} {
+		data := testhelpers.RandomizeSlice(make([]byte, size))
+		encoded := EncodeForEigenDA(data)
+		if len(encoded)%BytesPerFieldElement != 0 {
+			Fail(t, "encoding of", size, "bytes isn't a whole number of field elements")
+		}
+		expectedChunks := (size + encodedLengthPrefixSize + BytesPerEncodedChunk - 1) / BytesPerEncodedChunk
+		if len(encoded) != expectedChunks*BytesPerFieldElement {
+			Fail(t, "encoding of", size, "bytes took", len(encoded), "bytes")
+		}
+		if _, err := BlobToFieldElements(encoded); err != nil {
+			Fail(t, "encoding of", size, "bytes isn't canonical", err)
+		}
+		decoded, err := DecodeFromEigenDA(encoded)
+		Require(t, err)
+		if !bytes.Equal(decoded, data) {
+			Fail(t, "round trip of", size, "bytes changed the data")
+		}
+	}
+}
+
+func TestEigenDADecodeMalformed(t *testing.T) {
+	valid := EncodeForEigenDA([]byte{1, 2, 3})
+	corrupt := func(edit func([]byte) []byte) []byte {
+		return edit(append([]byte{}, valid...))
+	}
+	for name, blob := range map[string][]byte{
+		"empty":            {},
+		"partial element":  valid[:BytesPerFieldElement-1],
+		"nonzero leading":  corrupt(func(b []byte) []byte { b[0] = 1; return b }),
+		"overlong length":  corrupt(func(b []byte) []byte { b[4] = 0xff; return b }),
+		"nonzero padding":  corrupt(func(b []byte) []byte { b[BytesPerFieldElement-1] = 1; return b }),
+		"trailing element": append(append([]byte{}, valid...), make([]byte, BytesPerFieldElement)...),
+	} {
+		if _, err := DecodeFromEigenDA(blob); !errors.Is(err, ErrMalformedEncodedBlob) {
+			Fail(t, "decoded a blob with", name, err)
+		}
+	}
+}
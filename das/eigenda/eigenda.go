@@ -6,27 +6,50 @@ package eigenda
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"crypto/sha256"
 	"crypto/tls"
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/Layr-Labs/eigenda/api/grpc/disperser"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/offchainlabs/nitro/arbutil"
+	flag "github.com/spf13/pflag"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/proto"
 )
 
 // EigenDAMessageHeaderFlag indicated that the message is a EigenDARef which will be used to retrieve data from EigenDA
 const EigenDAMessageHeaderFlag byte = 0xed
 
+// EigenDADualMessageHeaderFlag indicates that the message carries both an EigenDARef and the raw batch as calldata
+const EigenDADualMessageHeaderFlag byte = 0xee
+
+// EigenDACertifiedMessageHeaderFlag indicates that the message is the disperser's certificate for a blob, which the
+// blob's EigenDARef is read from and which lets readers verify the blob against the batches confirmed on L1
+const EigenDACertifiedMessageHeaderFlag byte = 0xef
+
 func IsEigenDAMessageHeaderByte(header byte) bool {
-	return (EigenDAMessageHeaderFlag & header) > 0
+	return header == EigenDAMessageHeaderFlag
+}
+
+func IsEigenDADualMessageHeaderByte(header byte) bool {
+	return header == EigenDADualMessageHeaderFlag
+}
+
+func IsEigenDACertifiedMessageHeaderByte(header byte) bool {
+	return header == EigenDACertifiedMessageHeaderFlag
 }
 
 type EigenDAWriter interface {
@@ -41,6 +64,139 @@ type EigenDAReader interface {
 type EigenDAConfig struct {
 	Enable bool   `koanf:"enable"`
 	Rpc    string `koanf:"rpc"`
+
+	// Dispersal waits for the blob to be confirmed on L1, so it legitimately takes much longer than a read.
+	DispersalTimeout time.Duration `koanf:"dispersal-timeout"`
+	RetrievalTimeout time.Duration `koanf:"retrieval-timeout"`
+	// Durability is the status a blob must reach before its dispersal returns, either confirmed or finalized.
+	Durability string `koanf:"durability"`
+	// A confirmed blob is only treated as durable once its confirmation is this many L1 blocks deep.
+	// Finalized blobs are always durable.
+	ConfirmationDepth uint64 `koanf:"confirmation-depth"`
+
+	KZG                 KZGConfig                 `koanf:"kzg"`
+	Auth                EigenDAAuthConfig         `koanf:"auth"`
+	OnChainVerification OnChainVerificationConfig `koanf:"on-chain-verification"`
+	ReadCache           ReadCacheConfig           `koanf:"read-cache"`
+}
+
+// EigenDAAuthConfig enables authenticated dispersal, which some dispersers require to attribute blobs to an account
+type EigenDAAuthConfig struct {
+	Enable     bool   `koanf:"enable"`
+	PrivateKey string `koanf:"private-key"`
+}
+
+var DefaultEigenDAAuthConfig = EigenDAAuthConfig{
+	Enable:     false,
+	PrivateKey: "",
+}
+
+var DefaultEigenDAConfig = EigenDAConfig{
+	Enable:              false,
+	Rpc:                 "",
+	DispersalTimeout:    15 * time.Minute,
+	RetrievalTimeout:    30 * time.Second,
+	Durability:          DurabilityConfirmed.String(),
+	ConfirmationDepth:   0,
+	KZG:                 DefaultKZGConfig,
+	Auth:                DefaultEigenDAAuthConfig,
+	OnChainVerification: DefaultOnChainVerificationConfig,
+	ReadCache:           DefaultReadCacheConfig,
+}
+
+func EigenDAConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultEigenDAConfig.Enable, "enable EigenDA mode")
+	f.String(prefix+".rpc", DefaultEigenDAConfig.Rpc, "EigenDA disperser RPC endpoint")
+	f.Duration(prefix+".dispersal-timeout", DefaultEigenDAConfig.DispersalTimeout, "EigenDA timeout duration for dispersing a blob and waiting for its confirmation")
+	f.Duration(prefix+".retrieval-timeout", DefaultEigenDAConfig.RetrievalTimeout, "EigenDA timeout duration for retrieving a blob")
+	f.String(prefix+".durability", DefaultEigenDAConfig.Durability, "status a blob must reach before its dispersal is considered durable (\"confirmed\" or \"finalized\")")
+	f.Uint64(prefix+".confirmation-depth", DefaultEigenDAConfig.ConfirmationDepth, "number of L1 blocks a blob's confirmation must be buried under before its dispersal is considered durable (0 to accept any confirmation)")
+	KZGConfigAddOptions(prefix+".kzg", f)
+	EigenDAAuthConfigAddOptions(prefix+".auth", f)
+	OnChainVerificationConfigAddOptions(prefix+".on-chain-verification", f)
+	ReadCacheConfigAddOptions(prefix+".read-cache", f)
+}
+
+func EigenDAAuthConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultEigenDAAuthConfig.Enable, "sign EigenDA dispersal requests so the disperser can authenticate them")
+	f.String(prefix+".private-key", DefaultEigenDAAuthConfig.PrivateKey, "hex encoded ECDSA private key of the account EigenDA dispersal requests are signed by")
+}
+
+func (c *EigenDAAuthConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	_, err := c.Signer()
+	return err
+}
+
+// Signer parses the configured key, returning nil if authenticated dispersal is disabled
+func (c *EigenDAAuthConfig) Signer() (*ecdsa.PrivateKey, error) {
+	if !c.Enable {
+		return nil, nil
+	}
+	if c.PrivateKey == "" {
+		return nil, errors.New("EigenDA authenticated dispersal is enabled but no private key is configured")
+	}
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(c.PrivateKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid EigenDA auth private key: %w", err)
+	}
+	return key, nil
+}
+
+func (c *EigenDAConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if _, err := ParseDurabilityLevel(c.Durability); err != nil {
+		return err
+	}
+	if err := c.Auth.Validate(); err != nil {
+		return err
+	}
+	if err := c.OnChainVerification.Validate(); err != nil {
+		return err
+	}
+	if err := c.ReadCache.Validate(); err != nil {
+		return err
+	}
+	if c.OnChainVerification.Enable && !c.KZG.Enabled() {
+		return errors.New("EigenDA on-chain verification requires a KZG setup")
+	}
+	return c.KZG.Validate()
+}
+
+// DurabilityLevel is the status a dispersed blob must reach before it's treated as durable
+type DurabilityLevel uint8
+
+const (
+	// DurabilityConfirmed accepts a blob once its batch is confirmed on L1 and the confirmation is deep enough
+	DurabilityConfirmed DurabilityLevel = iota
+	// DurabilityFinalized waits for the blob's confirmation to be finalized on L1
+	DurabilityFinalized
+)
+
+func ParseDurabilityLevel(level string) (DurabilityLevel, error) {
+	switch strings.ToLower(level) {
+	case "confirmed":
+		return DurabilityConfirmed, nil
+	case "finalized":
+		return DurabilityFinalized, nil
+	default:
+		return 0, fmt.Errorf("unknown EigenDA durability level %q, expected \"confirmed\" or \"finalized\"", level)
+	}
+}
+
+func (l DurabilityLevel) String() string {
+	switch l {
+	case DurabilityConfirmed:
+		return "confirmed"
+	case DurabilityFinalized:
+		return "finalized"
+	default:
+		return fmt.Sprintf("DurabilityLevel(%d)", uint8(l))
+	}
 }
 
 func (ec *EigenDAConfig) String() {
@@ -52,6 +208,10 @@ func (ec *EigenDAConfig) String() {
 type EigenDARef struct {
 	BatchHeaderHash []byte
 	BlobIndex       uint32
+
+	// Certificate is the disperser's proof that the blob is in its batch, which refs posted before certificates
+	// were carried don't have. It isn't part of the serialized ref.
+	Certificate *disperser.BlobInfo
 }
 
 func (b *EigenDARef) Serialize() ([]byte, error) {
@@ -81,35 +241,201 @@ func (b *EigenDARef) Deserialize(data []byte) error {
 	return nil
 }
 
+// L1HeaderReader provides the L1 head used to measure the depth of blob confirmations
+type L1HeaderReader interface {
+	LastHeader(ctx context.Context) (*types.Header, error)
+}
+
+// how often the disperser is polled for the status of a pending blob
+const defaultStatusPollInterval = time.Second * 5
+
 type EigenDA struct {
-	client disperser.DisperserClient
+	client           disperser.DisperserClient
+	dispersalTimeout time.Duration
+	retrievalTimeout time.Duration
+	pollInterval     time.Duration
+	kzgSetup         *KZGSetup
+
+	durability        DurabilityLevel
+	confirmationDepth uint64
+	l1Reader          L1HeaderReader
+
+	// if set, blobs that are read are checked against the batches confirmed on L1
+	onChainVerifier *onChainVerifier
+
+	// if set, blobs that have been read are served from memory
+	readCache *blobCache
+
+	// if set, dispersals are authenticated as the account of this key
+	signer    *ecdsa.PrivateKey
+	accountId string
 }
 
-func NewEigenDA(rpc string) (*EigenDA, error) {
+func NewEigenDA(config *EigenDAConfig, l1Reader L1HeaderReader) (*EigenDA, error) {
+	durability, err := ParseDurabilityLevel(config.Durability)
+	if err != nil {
+		return nil, err
+	}
+	if config.ConfirmationDepth > 0 && l1Reader == nil {
+		return nil, errors.New("EigenDA confirmation depth requires an L1 reader")
+	}
+	// load the trusted setup up front so that a bad setup file fails at startup rather than when verifying dispersals
+	var kzgSetup *KZGSetup
+	if config.KZG.Enabled() {
+		kzgSetup, err = LoadKZGSetup(&config.KZG)
+		if err != nil {
+			return nil, err
+		}
+	}
+	signer, err := config.Auth.Signer()
+	if err != nil {
+		return nil, err
+	}
+	var verifier *onChainVerifier
+	if config.OnChainVerification.Enable {
+		l1Client, err := ethclient.Dial(config.OnChainVerification.L1Rpc)
+		if err != nil {
+			return nil, err
+		}
+		serviceManager := common.HexToAddress(config.OnChainVerification.ServiceManager)
+		verifier, err = newOnChainVerifier(l1Client, serviceManager, kzgSetup)
+		if err != nil {
+			return nil, err
+		}
+	}
 	creds := credentials.NewTLS(&tls.Config{
 		InsecureSkipVerify: true,
 	})
-	conn, err := grpc.Dial(rpc, grpc.WithTransportCredentials(creds))
+	conn, err := grpc.Dial(config.Rpc, grpc.WithTransportCredentials(creds))
 	if err != nil {
 		return nil, err
 	}
+	eigenDA := newEigenDAWithClient(disperser.NewDisperserClient(conn), config)
+	eigenDA.kzgSetup = kzgSetup
+	eigenDA.durability = durability
+	eigenDA.l1Reader = l1Reader
+	eigenDA.onChainVerifier = verifier
+	if signer != nil {
+		eigenDA.setSigner(signer)
+	}
+	return eigenDA, nil
+}
+
+func newEigenDAWithClient(client disperser.DisperserClient, config *EigenDAConfig) *EigenDA {
 	return &EigenDA{
-		client: disperser.NewDisperserClient(conn),
-	}, nil
+		client:            client,
+		dispersalTimeout:  config.DispersalTimeout,
+		retrievalTimeout:  config.RetrievalTimeout,
+		pollInterval:      defaultStatusPollInterval,
+		confirmationDepth: config.ConfirmationDepth,
+		readCache:         newBlobCache(&config.ReadCache),
+	}
+}
+
+// setSigner authenticates future dispersals with the key, whose account id is its hex encoded uncompressed public key
+func (e *EigenDA) setSigner(key *ecdsa.PrivateKey) {
+	e.signer = key
+	e.accountId = hexutil.Encode(crypto.FromECDSAPub(&key.PublicKey))
+}
+
+// withTimeout bounds ctx by timeout, treating a non-positive timeout as unlimited
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
 }
 
+// QueryBlob reads the ref's blob, which is verified the same way whether it's retrieved or served from the cache
 func (e *EigenDA) QueryBlob(ctx context.Context, ref *EigenDARef) ([]byte, error) {
-	res, err := e.client.RetrieveBlob(ctx, &disperser.RetrieveBlobRequest{
-		BatchHeaderHash: ref.BatchHeaderHash,
-		BlobIndex:       ref.BlobIndex,
-	})
+	ctx, cancel := withTimeout(ctx, e.retrievalTimeout)
+	defer cancel()
+	var data []byte
+	cached := false
+	if e.readCache != nil {
+		data, cached = e.readCache.get(ref)
+	}
+	if !cached {
+		res, err := e.client.RetrieveBlob(ctx, &disperser.RetrieveBlobRequest{
+			BatchHeaderHash: ref.BatchHeaderHash,
+			BlobIndex:       ref.BlobIndex,
+		})
+		if err != nil {
+			return nil, err
+		}
+		data = res.GetData()
+	}
+	if e.onChainVerifier != nil {
+		if err := e.onChainVerifier.verify(ctx, ref, data); err != nil {
+			return nil, fmt.Errorf("EigenDA blob failed on-chain verification: %w", err)
+		}
+	}
+	if e.readCache != nil && !cached {
+		e.readCache.add(ref, data)
+	}
+	return data, nil
+}
+
+func (e *EigenDA) Store(ctx context.Context, data []byte) (*EigenDARef, error) {
+	result, err := e.StoreWithResult(ctx, data)
 	if err != nil {
 		return nil, err
 	}
-	return res.GetData(), nil
+	return result.Ref, nil
 }
 
-func (e *EigenDA) Store(ctx context.Context, data []byte) (*EigenDARef, error) {
+// DispersalResult is a durable blob's ref along with how each of its quorums confirmed it
+type DispersalResult struct {
+	Ref     *EigenDARef
+	Quorums []QuorumConfirmation
+}
+
+// QuorumConfirmation is the percentage of a required quorum's stake that signed for a blob, and the thresholds the
+// blob was dispersed with
+type QuorumConfirmation struct {
+	QuorumId           uint32
+	SignedPercentage   uint32
+	AdversaryThreshold uint32
+	QuorumThreshold    uint32
+}
+
+// Degraded reports whether the quorum signed for less than its threshold but more than the adversary bound,
+// which still leaves the blob recoverable
+func (q *QuorumConfirmation) Degraded() bool {
+	return q.SignedPercentage < q.QuorumThreshold && q.SignedPercentage > q.AdversaryThreshold
+}
+
+// parseQuorumConfirmations matches the blob's required quorums with the signed percentages of its batch.
+// A required quorum that's missing from the batch is reported as not having signed.
+func parseQuorumConfirmations(info *disperser.BlobInfo) ([]QuorumConfirmation, error) {
+	batchHeader := info.GetBlobVerificationProof().GetBatchMetadata().GetBatchHeader()
+	quorumNumbers := batchHeader.GetQuorumNumbers()
+	signedPercentages := batchHeader.GetQuorumSignedPercentages()
+	if len(quorumNumbers) != len(signedPercentages) {
+		return nil, fmt.Errorf("EigenDA batch header has %v quorums but %v signed percentages", len(quorumNumbers), len(signedPercentages))
+	}
+	signed := make(map[uint32]uint32, len(quorumNumbers))
+	for i, quorum := range quorumNumbers {
+		signed[uint32(quorum)] = uint32(signedPercentages[i])
+	}
+	params := info.GetBlobHeader().GetBlobQuorumParams()
+	confirmations := make([]QuorumConfirmation, len(params))
+	for i, param := range params {
+		confirmations[i] = QuorumConfirmation{
+			QuorumId:           param.GetQuorumNumber(),
+			SignedPercentage:   signed[param.GetQuorumNumber()],
+			AdversaryThreshold: param.GetAdversaryThresholdPercentage(),
+			QuorumThreshold:    param.GetQuorumThresholdPercentage(),
+		}
+	}
+	return confirmations, nil
+}
+
+// StoreWithResult disperses the blob like Store, also reporting how its quorums confirmed it
+func (e *EigenDA) StoreWithResult(ctx context.Context, data []byte) (*DispersalResult, error) {
+	ctx, cancel := withTimeout(ctx, e.dispersalTimeout)
+	defer cancel()
+
 	disperseBlobRequest := &disperser.DisperseBlobRequest{
 		Data: data,
 		SecurityParams: []*disperser.SecurityParams{
@@ -117,16 +443,26 @@ func (e *EigenDA) Store(ctx context.Context, data []byte) (*EigenDARef, error) {
 		},
 	}
 
-	res, err := e.client.DisperseBlob(ctx, disperseBlobRequest)
+	var res *disperser.DisperseBlobReply
+	var err error
+	if e.signer != nil {
+		res, err = e.disperseAuthenticated(ctx, disperseBlobRequest)
+	} else {
+		res, err = e.client.DisperseBlob(ctx, disperseBlobRequest)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	ticker := time.NewTicker(time.Second * 5)
+	ticker := time.NewTicker(e.pollInterval)
 	defer ticker.Stop()
 
-	var ref *EigenDARef
-	for range ticker.C {
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("disperser blob query status timeout: %w", ctx.Err())
+		}
 		statusReply, err := e.GetBlobStatus(ctx, res.GetRequestId())
 		if err != nil {
 			log.Error("[eigenda]: GetBlobStatus: ", "error", err.Error())
@@ -134,19 +470,119 @@ func (e *EigenDA) Store(ctx context.Context, data []byte) (*EigenDARef, error) {
 		}
 		switch statusReply.GetStatus() {
 		case disperser.BlobStatus_CONFIRMED, disperser.BlobStatus_FINALIZED:
-			ref = &EigenDARef{
-				BatchHeaderHash: statusReply.GetInfo().GetBlobVerificationProof().GetBatchMetadata().GetBatchHeaderHash(),
-				BlobIndex:       statusReply.GetInfo().GetBlobVerificationProof().GetBlobIndex(),
+			if statusReply.GetStatus() == disperser.BlobStatus_CONFIRMED {
+				if e.durability == DurabilityFinalized {
+					continue
+				}
+				deepEnough, err := e.confirmationIsDeep(ctx, statusReply)
+				if err != nil {
+					log.Warn("[eigenda]: failed to check blob confirmation depth", "err", err)
+					continue
+				}
+				if !deepEnough {
+					continue
+				}
+			}
+			// a ref is only posted if the disperser committed to the data it was given
+			if e.kzgSetup != nil {
+				err := verifyDispersedCommitment(e.kzgSetup, data, statusReply.GetInfo().GetBlobHeader().GetCommitment())
+				if err != nil {
+					return nil, fmt.Errorf("EigenDA disperser reported a bad commitment for the blob: %w", err)
+				}
+			}
+			ref := eigenDARefFromCertificate(statusReply.GetInfo())
+			// the blob is durable either way, so a malformed report is only logged
+			quorums, err := parseQuorumConfirmations(statusReply.GetInfo())
+			if err != nil {
+				log.Warn("[eigenda]: failed to parse blob quorum confirmations", "err", err)
 			}
-			return ref, nil
+			for _, quorum := range quorums {
+				if quorum.Degraded() {
+					log.Warn("[eigenda]: blob quorum confirmed below its threshold",
+						"quorum", quorum.QuorumId, "signed", quorum.SignedPercentage,
+						"threshold", quorum.QuorumThreshold, "adversaryThreshold", quorum.AdversaryThreshold)
+				}
+			}
+			return &DispersalResult{Ref: ref, Quorums: quorums}, nil
 		case disperser.BlobStatus_FAILED:
 			return nil, errors.New("disperser blob failed")
 		default:
 			continue
 		}
 	}
-	return nil, errors.New("disperser blob query status timeout")
+}
+
+// disperseAuthenticated disperses a blob over the disperser's authenticated stream. The disperser answers the
+// request with a challenge nonce, and only accepts the blob once the nonce is signed by the request's account.
+func (e *EigenDA) disperseAuthenticated(ctx context.Context, request *disperser.DisperseBlobRequest) (*disperser.DisperseBlobReply, error) {
+	stream, err := e.client.DisperseBlobAuthenticated(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = stream.CloseSend() }()
 
+	request.AccountId = e.accountId
+	err = stream.Send(&disperser.AuthenticatedRequest{
+		Payload: &disperser.AuthenticatedRequest_DisperseRequest{DisperseRequest: request},
+	})
+	if err != nil {
+		return nil, err
+	}
+	reply, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	authHeader, ok := reply.GetPayload().(*disperser.AuthenticatedReply_BlobAuthHeader)
+	if !ok {
+		return nil, fmt.Errorf("expected an auth challenge from the EigenDA disperser but got %T", reply.GetPayload())
+	}
+
+	signature, err := signAuthChallenge(e.signer, authHeader.BlobAuthHeader.GetChallengeParameter())
+	if err != nil {
+		return nil, err
+	}
+	err = stream.Send(&disperser.AuthenticatedRequest{
+		Payload: &disperser.AuthenticatedRequest_AuthenticationData{
+			AuthenticationData: &disperser.AuthenticationData{AuthenticationData: signature},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	reply, err = stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	disperseReply, ok := reply.GetPayload().(*disperser.AuthenticatedReply_DisperseReply)
+	if !ok {
+		return nil, fmt.Errorf("expected a dispersal reply from the EigenDA disperser but got %T", reply.GetPayload())
+	}
+	return disperseReply.DisperseReply, nil
+}
+
+// signAuthChallenge signs the keccak hash of the big endian challenge nonce, as the disperser expects
+func signAuthChallenge(key *ecdsa.PrivateKey, challenge uint32) ([]byte, error) {
+	var nonce [4]byte
+	binary.BigEndian.PutUint32(nonce[:], challenge)
+	return crypto.Sign(crypto.Keccak256(nonce[:]), key)
+}
+
+// confirmationIsDeep reports whether a confirmed blob's confirmation is buried under enough L1 blocks
+func (e *EigenDA) confirmationIsDeep(ctx context.Context, statusReply *disperser.BlobStatusReply) (bool, error) {
+	if e.confirmationDepth == 0 {
+		return true, nil
+	}
+	confirmedAt := uint64(statusReply.GetInfo().GetBlobVerificationProof().GetBatchMetadata().GetConfirmationBlockNumber())
+	header, err := e.l1Reader.LastHeader(ctx)
+	if err != nil {
+		return false, err
+	}
+	head := header.Number.Uint64()
+	if head < confirmedAt+e.confirmationDepth {
+		log.Debug("[eigenda]: waiting for blob confirmation depth", "confirmedAt", confirmedAt, "head", head, "depth", e.confirmationDepth)
+		return false, nil
+	}
+	return true, nil
 }
 
 func (e *EigenDA) GetBlobStatus(ctx context.Context, reqeustId []byte) (*disperser.BlobStatusReply, error) {
@@ -156,29 +592,22 @@ func (e *EigenDA) GetBlobStatus(ctx context.Context, reqeustId []byte) (*dispers
 	return e.client.GetBlobStatus(ctx, blockStatusRequest)
 }
 
-// Serialize implements EigenDAWriter.
+// Serialize implements EigenDAWriter, posting the ref's certificate in its place. Certified batches are read as
+// blobs encoded by EncodeForEigenDA, so only refs with a certificate can be posted. Plain refs are only read from
+// batches posted before blobs were encoded.
 func (e *EigenDA) Serialize(eigenDARef *EigenDARef) ([]byte, error) {
-	eigenDARefData, err := eigenDARef.Serialize()
-	if err != nil {
-		log.Warn("eigenDARef serialize error", "err", err)
-		return nil, err
-	}
-	buf := new(bytes.Buffer)
-	err = binary.Write(buf, binary.BigEndian, EigenDAMessageHeaderFlag)
-	if err != nil {
-		log.Warn("batch type byte serialization failed", "err", err)
-		return nil, err
+	if eigenDARef.Certificate == nil {
+		return nil, errors.New("EigenDA ref has no certificate to post")
 	}
-	err = binary.Write(buf, binary.BigEndian, eigenDARefData)
-
+	certificate, err := proto.Marshal(eigenDARef.Certificate)
 	if err != nil {
-		log.Warn("data pointer serialization failed", "err", err)
+		log.Warn("EigenDA certificate serialization failed", "err", err)
 		return nil, err
 	}
-	serializedBlobPointerData := buf.Bytes()
-	return serializedBlobPointerData, nil
+	return append([]byte{EigenDACertifiedMessageHeaderFlag}, certificate...), nil
 }
 
+// RecoverPayloadFromEigenDABatch reads the payload of an EigenDA batch, which is given with its header byte
 func RecoverPayloadFromEigenDABatch(ctx context.Context,
 	sequencerMsg []byte,
 	daReader EigenDAReader,
@@ -192,22 +621,112 @@ func RecoverPayloadFromEigenDABatch(ctx context.Context,
 		}
 		shaPreimages = preimages[arbutil.Sha2_256PreimageType]
 	}
-	var daRef EigenDARef
-	daRef.BlobIndex = binary.BigEndian.Uint32(sequencerMsg[:4])
-	daRef.BatchHeaderHash = sequencerMsg[4:]
+	daRef, err := eigenDARefFromBatch(sequencerMsg)
+	if err != nil {
+		return nil, err
+	}
 	log.Info("Data pointer: ", "info", hex.EncodeToString(daRef.BatchHeaderHash), "index", daRef.BlobIndex)
-	data, err := daReader.QueryBlob(ctx, &daRef)
+	data, err := daReader.QueryBlob(ctx, daRef)
 	if err != nil {
 		log.Error("Failed to query data from EigenDA", "err", err)
 		return nil, err
 	}
 	// record preimage data
 	log.Info("Recording preimage data for EigenDA")
-	shaDataHash := sha256.New()
-	shaDataHash.Write(sequencerMsg)
-	dataHash := shaDataHash.Sum([]byte{})
 	if shaPreimages != nil {
-		shaPreimages[common.BytesToHash(dataHash)] = data
+		preimageHash, err := eigenDAPreimageHash(daRef)
+		if err != nil {
+			return nil, err
+		}
+		shaPreimages[preimageHash] = data
 	}
-	return data, nil
+	return payloadFromBlob(daRef, data), nil
+}
+
+// payloadFromBlob decodes the blob of a certified batch, which EncodeForEigenDA encoded. Blobs of plain refs were
+// posted unencoded. A certified blob that doesn't decode is read as an empty batch, so that it can't stall the inbox.
+func payloadFromBlob(ref *EigenDARef, blob []byte) []byte {
+	if ref.Certificate == nil {
+		return blob
+	}
+	payload, err := DecodeFromEigenDA(blob)
+	if err != nil {
+		log.Warn("Discarding EigenDA batch whose blob isn't encoded", "batchHeaderHash", hex.EncodeToString(ref.BatchHeaderHash), "blobIndex", ref.BlobIndex, "err", err)
+		return nil
+	}
+	return payload
+}
+
+// eigenDARefFromBatch parses the ref of an EigenDA batch, reading it from its certificate if it's certified
+func eigenDARefFromBatch(sequencerMsg []byte) (*EigenDARef, error) {
+	if len(sequencerMsg) > 0 && IsEigenDACertifiedMessageHeaderByte(sequencerMsg[0]) {
+		certificate := new(disperser.BlobInfo)
+		if err := proto.Unmarshal(sequencerMsg[1:], certificate); err != nil {
+			return nil, fmt.Errorf("malformed EigenDA certificate: %w", err)
+		}
+		return eigenDARefFromCertificate(certificate), nil
+	}
+	if len(sequencerMsg) < 5 || !IsEigenDAMessageHeaderByte(sequencerMsg[0]) {
+		return nil, errors.New("malformed EigenDA batch")
+	}
+	return &EigenDARef{
+		BlobIndex:       binary.BigEndian.Uint32(sequencerMsg[1:5]),
+		BatchHeaderHash: sequencerMsg[5:],
+	}, nil
+}
+
+func eigenDARefFromCertificate(certificate *disperser.BlobInfo) *EigenDARef {
+	return &EigenDARef{
+		BatchHeaderHash: certificate.GetBlobVerificationProof().GetBatchMetadata().GetBatchHeaderHash(),
+		BlobIndex:       certificate.GetBlobVerificationProof().GetBlobIndex(),
+		Certificate:     certificate,
+	}
+}
+
+// eigenDAPreimageHash is the key a batch's payload is recorded under for replay, which is the hash of its serialized
+// ref whether or not the batch is certified
+func eigenDAPreimageHash(ref *EigenDARef) (common.Hash, error) {
+	serialized, err := ref.Serialize()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return sha256.Sum256(serialized), nil
+}
+
+// SerializeDualBatch encodes a batch that was posted to EigenDA and is also carried as calldata.
+// The layout is the dual header byte, the length of the serialized ref, the ref itself, then the raw payload.
+func SerializeDualBatch(eigenDARef *EigenDARef, payload []byte) ([]byte, error) {
+	eigenDARefData, err := eigenDARef.Serialize()
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	buf.WriteByte(EigenDADualMessageHeaderFlag)
+	err = binary.Write(buf, binary.BigEndian, uint32(len(eigenDARefData)))
+	if err != nil {
+		return nil, err
+	}
+	buf.Write(eigenDARefData)
+	buf.Write(payload)
+	return buf.Bytes(), nil
+}
+
+// ParseDualBatch splits a dual batch (without its header byte) into the serialized ref and the calldata payload.
+func ParseDualBatch(sequencerMsg []byte) ([]byte, []byte, error) {
+	if len(sequencerMsg) < 4 {
+		return nil, nil, errors.New("dual EigenDA batch missing ref length")
+	}
+	refLen := binary.BigEndian.Uint32(sequencerMsg[:4])
+	rest := sequencerMsg[4:]
+	if uint64(len(rest)) < uint64(refLen) || refLen < 4 {
+		return nil, nil, fmt.Errorf("dual EigenDA batch has invalid ref length %v", refLen)
+	}
+	return rest[:refLen], rest[refLen:], nil
+}
+
+// PayloadFromEigenDADualBatch returns the calldata copy of a dual posted batch. The batch is always derived from
+// this copy, so that derivation doesn't depend on EigenDA being available or serving the same data as L1.
+func PayloadFromEigenDADualBatch(sequencerMsg []byte) ([]byte, error) {
+	_, calldata, err := ParseDualBatch(sequencerMsg)
+	return calldata, err
 }
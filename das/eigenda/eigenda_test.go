@@ -0,0 +1,573 @@
+// Copyright 2024-2024, Alt Research, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package eigenda
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	eigendacommon "github.com/Layr-Labs/eigenda/api/grpc/common"
+	"github.com/Layr-Labs/eigenda/api/grpc/disperser"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"google.golang.org/grpc"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+// mockDisperserClient serves a single confirmed blob, sleeping before answering each kind of request
+type mockDisperserClient struct {
+	disperser.DisperserClient
+	data            []byte
+	batchHeaderHash []byte
+	blobIndex       uint32
+	disperseDelay   time.Duration
+	statusDelay     time.Duration
+	retrieveDelay   time.Duration
+	retrievals      int
+
+	// statuses are reported in order by successive status queries, after which the blob stays confirmed
+	statuses          []disperser.BlobStatus
+	statusQueries     int
+	confirmationBlock uint32
+
+	// the blob's required quorums, and the quorums that signed for its batch
+	quorumParams      []*disperser.BlobQuorumParam
+	quorumNumbers     []byte
+	signedPercentages []byte
+
+	// the commitment reported for the blob
+	commitment *eigendacommon.G1Commitment
+
+	// the most recent authenticated dispersal, if any
+	authStream *mockAuthenticatedStream
+}
+
+func newMockDisperserClient() *mockDisperserClient {
+	return &mockDisperserClient{
+		batchHeaderHash: bytes.Repeat([]byte{0xab}, 32),
+		blobIndex:       7,
+	}
+}
+
+func waitOrCancel(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m *mockDisperserClient) DisperseBlob(ctx context.Context, req *disperser.DisperseBlobRequest, opts ...grpc.CallOption) (*disperser.DisperseBlobReply, error) {
+	if err := waitOrCancel(ctx, m.disperseDelay); err != nil {
+		return nil, err
+	}
+	m.data = req.GetData()
+	return &disperser.DisperseBlobReply{
+		Result:    disperser.BlobStatus_PROCESSING,
+		RequestId: []byte("request"),
+	}, nil
+}
+
+func (m *mockDisperserClient) DisperseBlobAuthenticated(ctx context.Context, opts ...grpc.CallOption) (disperser.Disperser_DisperseBlobAuthenticatedClient, error) {
+	m.authStream = &mockAuthenticatedStream{client: m, challenge: 0x5eed}
+	return m.authStream, nil
+}
+
+// mockAuthenticatedStream challenges the dispersal request, recording what's sent in response
+type mockAuthenticatedStream struct {
+	grpc.ClientStream
+	client    *mockDisperserClient
+	challenge uint32
+	request   *disperser.DisperseBlobRequest
+	signature []byte
+	replies   []*disperser.AuthenticatedReply
+}
+
+func (s *mockAuthenticatedStream) Send(req *disperser.AuthenticatedRequest) error {
+	switch payload := req.GetPayload().(type) {
+	case *disperser.AuthenticatedRequest_DisperseRequest:
+		s.request = payload.DisperseRequest
+		s.replies = append(s.replies, &disperser.AuthenticatedReply{
+			Payload: &disperser.AuthenticatedReply_BlobAuthHeader{
+				BlobAuthHeader: &disperser.BlobAuthHeader{ChallengeParameter: s.challenge},
+			},
+		})
+	case *disperser.AuthenticatedRequest_AuthenticationData:
+		s.signature = payload.AuthenticationData.GetAuthenticationData()
+		s.client.data = s.request.GetData()
+		s.replies = append(s.replies, &disperser.AuthenticatedReply{
+			Payload: &disperser.AuthenticatedReply_DisperseReply{
+				DisperseReply: &disperser.DisperseBlobReply{
+					Result:    disperser.BlobStatus_PROCESSING,
+					RequestId: []byte("request"),
+				},
+			},
+		})
+	}
+	return nil
+}
+
+func (s *mockAuthenticatedStream) Recv() (*disperser.AuthenticatedReply, error) {
+	if len(s.replies) == 0 {
+		return nil, errors.New("no reply pending")
+	}
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	return reply, nil
+}
+
+func (s *mockAuthenticatedStream) CloseSend() error {
+	return nil
+}
+
+func (m *mockDisperserClient) GetBlobStatus(ctx context.Context, req *disperser.BlobStatusRequest, opts ...grpc.CallOption) (*disperser.BlobStatusReply, error) {
+	if err := waitOrCancel(ctx, m.statusDelay); err != nil {
+		return nil, err
+	}
+	m.statusQueries++
+	status := disperser.BlobStatus_CONFIRMED
+	if len(m.statuses) > 0 {
+		status = m.statuses[0]
+		m.statuses = m.statuses[1:]
+	}
+	return &disperser.BlobStatusReply{
+		Status: status,
+		Info: &disperser.BlobInfo{
+			BlobHeader: &disperser.BlobHeader{Commitment: m.commitment, BlobQuorumParams: m.quorumParams},
+			BlobVerificationProof: &disperser.BlobVerificationProof{
+				BlobIndex: m.blobIndex,
+				BatchMetadata: &disperser.BatchMetadata{
+					BatchHeader: &disperser.BatchHeader{
+						QuorumNumbers:           m.quorumNumbers,
+						QuorumSignedPercentages: m.signedPercentages,
+					},
+					BatchHeaderHash:         m.batchHeaderHash,
+					ConfirmationBlockNumber: m.confirmationBlock,
+				},
+			},
+		},
+	}, nil
+}
+
+func (m *mockDisperserClient) RetrieveBlob(ctx context.Context, req *disperser.RetrieveBlobRequest, opts ...grpc.CallOption) (*disperser.RetrieveBlobReply, error) {
+	if err := waitOrCancel(ctx, m.retrieveDelay); err != nil {
+		return nil, err
+	}
+	m.retrievals++
+	return &disperser.RetrieveBlobReply{Data: m.data}, nil
+}
+
+func newTestEigenDA(client disperser.DisperserClient, dispersalTimeout, retrievalTimeout time.Duration) *EigenDA {
+	config := DefaultEigenDAConfig
+	config.DispersalTimeout = dispersalTimeout
+	config.RetrievalTimeout = retrievalTimeout
+	eigenDA := newEigenDAWithClient(client, &config)
+	eigenDA.pollInterval = time.Millisecond * 10
+	return eigenDA
+}
+
+func TestEigenDADispersalTimeout(t *testing.T) {
+	ctx := context.Background()
+	client := newMockDisperserClient()
+	client.statusDelay = time.Millisecond * 200
+
+	// a slow confirmation fits within a generous dispersal timeout, even when reads are held to a short one
+	eigenDA := newTestEigenDA(client, time.Second*5, time.Millisecond*50)
+	ref, err := eigenDA.Store(ctx, []byte("data"))
+	Require(t, err)
+	if ref.BlobIndex != client.blobIndex || !bytes.Equal(ref.BatchHeaderHash, client.batchHeaderHash) {
+		Fail(t, "unexpected ref", ref)
+	}
+
+	// and fails once the dispersal timeout is shorter than the confirmation
+	eigenDA = newTestEigenDA(client, time.Millisecond*50, time.Second*5)
+	_, err = eigenDA.Store(ctx, []byte("data"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		Fail(t, "expected dispersal to time out, got", err)
+	}
+}
+
+// mockL1Reader advances its head by a fixed number of blocks every time it's read
+type mockL1Reader struct {
+	head    uint64
+	advance uint64
+}
+
+func (r *mockL1Reader) LastHeader(ctx context.Context) (*types.Header, error) {
+	header := &types.Header{Number: new(big.Int).SetUint64(r.head)}
+	r.head += r.advance
+	return header, nil
+}
+
+func TestEigenDAConfirmationDepth(t *testing.T) {
+	ctx := context.Background()
+	const depth = 3
+
+	// the blob is only durable once it's confirmed and that confirmation is deep enough
+	client := newMockDisperserClient()
+	client.statuses = []disperser.BlobStatus{disperser.BlobStatus_PROCESSING, disperser.BlobStatus_PROCESSING, disperser.BlobStatus_CONFIRMED}
+	client.confirmationBlock = 100
+	l1Reader := &mockL1Reader{head: 100, advance: 1}
+	eigenDA := newTestEigenDA(client, time.Second*5, time.Second*5)
+	eigenDA.confirmationDepth = depth
+	eigenDA.l1Reader = l1Reader
+	ref, err := eigenDA.Store(ctx, []byte("data"))
+	Require(t, err)
+	if ref.BlobIndex != client.blobIndex || !bytes.Equal(ref.BatchHeaderHash, client.batchHeaderHash) {
+		Fail(t, "unexpected ref", ref)
+	}
+	// two queries while processing, then one per L1 head until the confirmation is buried
+	if client.statusQueries != 2+depth+1 {
+		Fail(t, "blob was accepted after", client.statusQueries, "status queries")
+	}
+	if l1Reader.head <= uint64(client.confirmationBlock)+depth {
+		Fail(t, "blob was accepted before its confirmation was", depth, "blocks deep")
+	}
+
+	// a finalized blob is durable regardless of the L1 head
+	client = newMockDisperserClient()
+	client.statuses = []disperser.BlobStatus{disperser.BlobStatus_FINALIZED}
+	client.confirmationBlock = 100
+	eigenDA = newTestEigenDA(client, time.Second*5, time.Second*5)
+	eigenDA.confirmationDepth = depth
+	eigenDA.l1Reader = &mockL1Reader{head: 100}
+	_, err = eigenDA.Store(ctx, []byte("data"))
+	Require(t, err)
+
+	// a confirmation that never gets deep enough runs into the dispersal deadline
+	client = newMockDisperserClient()
+	client.confirmationBlock = 100
+	eigenDA = newTestEigenDA(client, time.Millisecond*200, time.Second*5)
+	eigenDA.confirmationDepth = depth
+	eigenDA.l1Reader = &mockL1Reader{head: 101}
+	_, err = eigenDA.Store(ctx, []byte("data"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		Fail(t, "expected a shallow confirmation to time out, got", err)
+	}
+
+	// a failed blob isn't retried
+	client = newMockDisperserClient()
+	client.statuses = []disperser.BlobStatus{disperser.BlobStatus_PROCESSING, disperser.BlobStatus_FAILED}
+	eigenDA = newTestEigenDA(client, time.Second*5, time.Second*5)
+	if _, err := eigenDA.Store(ctx, []byte("data")); err == nil {
+		Fail(t, "stored a failed blob")
+	}
+}
+
+func TestEigenDADurabilityLevel(t *testing.T) {
+	ctx := context.Background()
+
+	// at the confirmed level, dispersal returns as soon as the blob is confirmed
+	client := newMockDisperserClient()
+	client.statuses = []disperser.BlobStatus{disperser.BlobStatus_PROCESSING, disperser.BlobStatus_CONFIRMED}
+	eigenDA := newTestEigenDA(client, time.Second*5, time.Second*5)
+	_, err := eigenDA.Store(ctx, []byte("data"))
+	Require(t, err)
+	if client.statusQueries != 2 {
+		Fail(t, "confirmed blob was accepted after", client.statusQueries, "status queries")
+	}
+
+	// at the finalized level, confirmations are waited through until the blob is finalized
+	client = newMockDisperserClient()
+	client.statuses = []disperser.BlobStatus{
+		disperser.BlobStatus_PROCESSING, disperser.BlobStatus_CONFIRMED, disperser.BlobStatus_CONFIRMED, disperser.BlobStatus_FINALIZED,
+	}
+	eigenDA = newTestEigenDA(client, time.Second*5, time.Second*5)
+	eigenDA.durability = DurabilityFinalized
+	ref, err := eigenDA.Store(ctx, []byte("data"))
+	Require(t, err)
+	if client.statusQueries != 4 {
+		Fail(t, "finalized blob was accepted after", client.statusQueries, "status queries")
+	}
+	if ref.BlobIndex != client.blobIndex || !bytes.Equal(ref.BatchHeaderHash, client.batchHeaderHash) {
+		Fail(t, "unexpected ref", ref)
+	}
+
+	// a blob that's confirmed but never finalized runs into the dispersal deadline
+	client = newMockDisperserClient()
+	eigenDA = newTestEigenDA(client, time.Millisecond*200, time.Second*5)
+	eigenDA.durability = DurabilityFinalized
+	_, err = eigenDA.Store(ctx, []byte("data"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		Fail(t, "expected an unfinalized blob to time out, got", err)
+	}
+
+	// the config names levels case insensitively and defaults to confirmed
+	for name, expected := range map[string]DurabilityLevel{"confirmed": DurabilityConfirmed, "Finalized": DurabilityFinalized} {
+		level, err := ParseDurabilityLevel(name)
+		Require(t, err)
+		if level != expected {
+			Fail(t, "parsed", name, "as", level)
+		}
+	}
+	config := DefaultEigenDAConfig
+	config.Enable = true
+	Require(t, config.Validate())
+	config.Durability = "included"
+	if err := config.Validate(); err == nil {
+		Fail(t, "accepted an unknown durability level")
+	}
+}
+
+func TestEigenDAQuorumConfirmations(t *testing.T) {
+	ctx := context.Background()
+	client := newMockDisperserClient()
+	client.quorumParams = []*disperser.BlobQuorumParam{
+		{QuorumNumber: 0, AdversaryThresholdPercentage: 33, QuorumThresholdPercentage: 55},
+		{QuorumNumber: 1, AdversaryThresholdPercentage: 33, QuorumThresholdPercentage: 55},
+		{QuorumNumber: 2, AdversaryThresholdPercentage: 25, QuorumThresholdPercentage: 50},
+		{QuorumNumber: 3, AdversaryThresholdPercentage: 25, QuorumThresholdPercentage: 50},
+	}
+	// quorum 3 didn't sign, and quorum 4 signed without being required
+	client.quorumNumbers = []byte{2, 0, 1, 4}
+	client.signedPercentages = []byte{20, 80, 45, 90}
+	eigenDA := newTestEigenDA(client, time.Second*5, time.Second*5)
+	result, err := eigenDA.StoreWithResult(ctx, []byte("data"))
+	Require(t, err)
+	if result.Ref.BlobIndex != client.blobIndex || !bytes.Equal(result.Ref.BatchHeaderHash, client.batchHeaderHash) {
+		Fail(t, "unexpected ref", result.Ref)
+	}
+
+	expected := []struct {
+		signed   uint32
+		degraded bool
+	}{{80, false}, {45, true}, {20, false}, {0, false}}
+	if len(result.Quorums) != len(expected) {
+		Fail(t, "reported", len(result.Quorums), "quorums instead of", len(expected))
+	}
+	for i, quorum := range result.Quorums {
+		if quorum.QuorumId != uint32(i) || quorum.QuorumThreshold != client.quorumParams[i].QuorumThresholdPercentage {
+			Fail(t, "quorum", i, "reported as", quorum)
+		}
+		if quorum.SignedPercentage != expected[i].signed || quorum.Degraded() != expected[i].degraded {
+			Fail(t, "quorum", i, "signed", quorum.SignedPercentage, "degraded", quorum.Degraded())
+		}
+	}
+
+	// a malformed report doesn't fail an otherwise durable dispersal
+	client.signedPercentages = client.signedPercentages[1:]
+	if _, err := parseQuorumConfirmations(&disperser.BlobInfo{
+		BlobVerificationProof: &disperser.BlobVerificationProof{
+			BatchMetadata: &disperser.BatchMetadata{
+				BatchHeader: &disperser.BatchHeader{QuorumNumbers: client.quorumNumbers, QuorumSignedPercentages: client.signedPercentages},
+			},
+		},
+	}); err == nil {
+		Fail(t, "parsed a batch header with mismatched quorums and signed percentages")
+	}
+	result, err = eigenDA.StoreWithResult(ctx, []byte("data"))
+	Require(t, err)
+	if result.Ref == nil || result.Quorums != nil {
+		Fail(t, "unexpected result for a malformed quorum report", result)
+	}
+}
+
+func TestEigenDARetrievalTimeout(t *testing.T) {
+	ctx := context.Background()
+	client := newMockDisperserClient()
+	client.data = []byte("data")
+	client.retrieveDelay = time.Millisecond * 200
+	ref := &EigenDARef{BatchHeaderHash: client.batchHeaderHash, BlobIndex: client.blobIndex}
+
+	// a short dispersal timeout must not affect reads
+	eigenDA := newTestEigenDA(client, time.Millisecond*50, time.Second*5)
+	data, err := eigenDA.QueryBlob(ctx, ref)
+	Require(t, err)
+	if !bytes.Equal(data, client.data) {
+		Fail(t, "unexpected data", data)
+	}
+
+	eigenDA = newTestEigenDA(client, time.Second*5, time.Millisecond*50)
+	_, err = eigenDA.QueryBlob(ctx, ref)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		Fail(t, "expected retrieval to time out, got", err)
+	}
+}
+
+func TestEigenDADispersedCommitment(t *testing.T) {
+	ctx := context.Background()
+	g1Path, g2Path := writeTestSetup(t, 4)
+	setup, err := LoadKZGSetup(&KZGConfig{G1Path: g1Path, G2Path: g2Path, SRSOrder: 4})
+	Require(t, err)
+	client := newMockDisperserClient()
+	eigenDA := newTestEigenDA(client, time.Second*5, time.Second*5)
+	eigenDA.kzgSetup = setup
+
+	// the first point of the test setup is the generator, so a blob of a single field element commits to its multiple
+	data := make([]byte, BytesPerEncodedChunk)
+	data[BytesPerEncodedChunk-1] = 5
+	_, _, g1Gen, _ := bn254.Generators()
+	var commitment bn254.G1Affine
+	commitment.ScalarMultiplication(&g1Gen, big.NewInt(5))
+	client.commitment = &eigendacommon.G1Commitment{X: commitment.X.Marshal(), Y: commitment.Y.Marshal()}
+	_, err = eigenDA.Store(ctx, data)
+	Require(t, err)
+
+	commitment.ScalarMultiplication(&g1Gen, big.NewInt(6))
+	client.commitment = &eigendacommon.G1Commitment{X: commitment.X.Marshal(), Y: commitment.Y.Marshal()}
+	if _, err := eigenDA.Store(ctx, data); !errors.Is(err, ErrCommitmentMismatch) {
+		Fail(t, "stored a blob the disperser committed to the wrong data for", err)
+	}
+}
+
+func TestEigenDADualBatch(t *testing.T) {
+	ctx := context.Background()
+	client := newMockDisperserClient()
+	eigenDA := newTestEigenDA(client, time.Second*5, time.Second*5)
+
+	payload := []byte("raw batch payload")
+	ref, err := eigenDA.Store(ctx, payload)
+	Require(t, err)
+	sequencerMsg, err := SerializeDualBatch(ref, payload)
+	Require(t, err)
+	if !IsEigenDADualMessageHeaderByte(sequencerMsg[0]) || IsEigenDAMessageHeaderByte(sequencerMsg[0]) {
+		Fail(t, "dual batch has wrong header byte", sequencerMsg[0])
+	}
+
+	// the calldata copy is what's derived from, even if EigenDA would now serve something else
+	client.data = []byte("eigenda payload")
+	data, err := PayloadFromEigenDADualBatch(sequencerMsg[1:])
+	Require(t, err)
+	if !bytes.Equal(data, payload) {
+		Fail(t, "dual batch wasn't read from calldata", data)
+	}
+
+	if _, _, err := ParseDualBatch([]byte{0, 0, 1, 0, 1}); err == nil {
+		Fail(t, "parsed dual batch with truncated ref")
+	}
+}
+
+func TestEigenDACertifiedBatch(t *testing.T) {
+	ctx := context.Background()
+	client := newMockDisperserClient()
+	eigenDA := newTestEigenDA(client, time.Second*5, time.Second*5)
+
+	// batches are dispersed encoded and posted as their certificate, which reads back as the payload
+	payload := []byte("raw batch payload")
+	ref, err := eigenDA.Store(ctx, EncodeForEigenDA(payload))
+	Require(t, err)
+	sequencerMsg, err := eigenDA.Serialize(ref)
+	Require(t, err)
+	if !IsEigenDACertifiedMessageHeaderByte(sequencerMsg[0]) {
+		Fail(t, "certified batch has wrong header byte", sequencerMsg[0])
+	}
+	preimages := make(map[arbutil.PreimageType]map[common.Hash][]byte)
+	data, err := RecoverPayloadFromEigenDABatch(ctx, sequencerMsg, eigenDA, preimages)
+	Require(t, err)
+	if !bytes.Equal(data, payload) {
+		Fail(t, "certified batch read back as", data)
+	}
+
+	// replay resolves the encoded blob by the serialized ref, as it does for plain refs
+	serialized, err := ref.Serialize()
+	Require(t, err)
+	if recorded := preimages[arbutil.Sha2_256PreimageType][sha256.Sum256(serialized)]; !bytes.Equal(recorded, client.data) {
+		Fail(t, "recorded preimage", recorded, "isn't the encoded blob")
+	}
+
+	// a certified blob that isn't encoded is read as an empty batch
+	client.data = payload
+	data, err = RecoverPayloadFromEigenDABatch(ctx, sequencerMsg, eigenDA, nil)
+	Require(t, err)
+	if data != nil {
+		Fail(t, "read a certified blob that isn't encoded as", data)
+	}
+
+	// while the blobs of plain refs were posted unencoded, and are read as they are
+	plainMsg := append([]byte{EigenDAMessageHeaderFlag}, serialized...)
+	data, err = RecoverPayloadFromEigenDABatch(ctx, plainMsg, eigenDA, nil)
+	Require(t, err)
+	if !bytes.Equal(data, payload) {
+		Fail(t, "plain batch read back as", data)
+	}
+
+	// and refs without a certificate aren't posted
+	if _, err := eigenDA.Serialize(&EigenDARef{BatchHeaderHash: client.batchHeaderHash, BlobIndex: client.blobIndex}); err == nil {
+		Fail(t, "posted a ref without a certificate")
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
+
+func TestEigenDAAuthenticatedDispersal(t *testing.T) {
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	Require(t, err)
+
+	// with auth enabled, the dispersal goes over the authenticated stream and the challenge is signed by the account
+	client := newMockDisperserClient()
+	eigenDA := newTestEigenDA(client, time.Second*5, time.Second*5)
+	eigenDA.setSigner(key)
+	_, err = eigenDA.Store(ctx, []byte("authenticated"))
+	Require(t, err)
+	stream := client.authStream
+	if stream == nil {
+		Fail(t, "authenticated dispersal didn't use the authenticated stream")
+	}
+	accountId := hexutil.Encode(crypto.FromECDSAPub(&key.PublicKey))
+	if stream.request.GetAccountId() != accountId {
+		Fail(t, "dispersal request has account id", stream.request.GetAccountId(), "instead of", accountId)
+	}
+	if !bytes.Equal(client.data, []byte("authenticated")) {
+		Fail(t, "disperser received", client.data)
+	}
+	nonce := []byte{0, 0, 0x5e, 0xed}
+	signer, err := crypto.SigToPub(crypto.Keccak256(nonce), stream.signature)
+	Require(t, err)
+	if hexutil.Encode(crypto.FromECDSAPub(signer)) != accountId {
+		Fail(t, "challenge wasn't signed by the account's key")
+	}
+
+	// with auth disabled, the blob is dispersed directly without an account or signature
+	client = newMockDisperserClient()
+	eigenDA = newTestEigenDA(client, time.Second*5, time.Second*5)
+	_, err = eigenDA.Store(ctx, []byte("unauthenticated"))
+	Require(t, err)
+	if client.authStream != nil {
+		Fail(t, "unauthenticated dispersal used the authenticated stream")
+	}
+	if !bytes.Equal(client.data, []byte("unauthenticated")) {
+		Fail(t, "disperser received", client.data)
+	}
+
+	// the config only yields a signer when auth is enabled, and requires a valid key when it is
+	config := DefaultEigenDAAuthConfig
+	config.PrivateKey = hexutil.Encode(crypto.FromECDSA(key))
+	signerKey, err := config.Signer()
+	Require(t, err)
+	if signerKey != nil {
+		Fail(t, "disabled auth config produced a signer")
+	}
+	config.Enable = true
+	signerKey, err = config.Signer()
+	Require(t, err)
+	if signerKey == nil || !signerKey.Equal(key) {
+		Fail(t, "auth config didn't parse the configured key")
+	}
+	config.PrivateKey = "not a key"
+	if err := config.Validate(); err == nil {
+		Fail(t, "accepted an invalid auth private key")
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2024-2024, Alt Research, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package eigenda
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	eigendacommon "github.com/Layr-Labs/eigenda/api/grpc/common"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	flag "github.com/spf13/pflag"
+)
+
+type KZGConfig struct {
+	G1Path   string `koanf:"g1-path"`
+	G2Path   string `koanf:"g2-path"`
+	SRSOrder uint64 `koanf:"srs-order"`
+}
+
+var DefaultKZGConfig = KZGConfig{
+	G1Path:   "",
+	G2Path:   "",
+	SRSOrder: 3000,
+}
+
+func KZGConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".g1-path", DefaultKZGConfig.G1Path, "path to the compressed G1 points of the EigenDA KZG trusted setup (empty to not load the setup)")
+	f.String(prefix+".g2-path", DefaultKZGConfig.G2Path, "path to the compressed G2 points of the EigenDA KZG trusted setup")
+	f.Uint64(prefix+".srs-order", DefaultKZGConfig.SRSOrder, "number of points to load from each of the EigenDA KZG trusted setup files")
+}
+
+func (c *KZGConfig) Enabled() bool {
+	return c.G1Path != ""
+}
+
+func (c *KZGConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if c.G2Path == "" {
+		return errors.New("EigenDA KZG setup has a G1 path but no G2 path")
+	}
+	if c.SRSOrder == 0 {
+		return errors.New("EigenDA KZG setup must load at least one point")
+	}
+	return nil
+}
+
+// A blob's field elements are the coefficients of the polynomial it commits to, so blobs are zero padded to a whole
+// number of field elements without changing their commitment.
+const BytesPerFieldElement = fr.Bytes
+
+var ErrCommitmentMismatch = errors.New("EigenDA blob doesn't match its KZG commitment")
+
+// KZGSetup holds the parsed points of the trusted setup used to verify EigenDA blob commitments
+type KZGSetup struct {
+	G1 []bn254.G1Affine
+	G2 []bn254.G2Affine
+}
+
+var kzgSetupCache = struct {
+	mutex  sync.Mutex
+	setups map[KZGConfig]*KZGSetup
+}{setups: make(map[KZGConfig]*KZGSetup)}
+
+// LoadKZGSetup reads and validates the trusted setup, reusing a previously parsed setup for the same config.
+func LoadKZGSetup(config *KZGConfig) (*KZGSetup, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if !config.Enabled() {
+		return nil, errors.New("EigenDA KZG setup isn't configured")
+	}
+
+	kzgSetupCache.mutex.Lock()
+	defer kzgSetupCache.mutex.Unlock()
+	if setup, ok := kzgSetupCache.setups[*config]; ok {
+		return setup, nil
+	}
+
+	g1Data, err := readSetupFile(config.G1Path, bn254.SizeOfG1AffineCompressed, config.SRSOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load EigenDA KZG G1 setup: %w", err)
+	}
+	g2Data, err := readSetupFile(config.G2Path, bn254.SizeOfG2AffineCompressed, config.SRSOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load EigenDA KZG G2 setup: %w", err)
+	}
+
+	setup := &KZGSetup{
+		G1: make([]bn254.G1Affine, config.SRSOrder),
+		G2: make([]bn254.G2Affine, config.SRSOrder),
+	}
+	for i := range setup.G1 {
+		point := &setup.G1[i]
+		chunk := g1Data[i*bn254.SizeOfG1AffineCompressed : (i+1)*bn254.SizeOfG1AffineCompressed]
+		if _, err := point.SetBytes(chunk); err != nil {
+			return nil, fmt.Errorf("EigenDA KZG G1 point %v in %v is invalid: %w", i, config.G1Path, err)
+		}
+		if !point.IsOnCurve() || !point.IsInSubGroup() {
+			return nil, fmt.Errorf("EigenDA KZG G1 point %v in %v is not on the curve", i, config.G1Path)
+		}
+	}
+	for i := range setup.G2 {
+		point := &setup.G2[i]
+		chunk := g2Data[i*bn254.SizeOfG2AffineCompressed : (i+1)*bn254.SizeOfG2AffineCompressed]
+		if _, err := point.SetBytes(chunk); err != nil {
+			return nil, fmt.Errorf("EigenDA KZG G2 point %v in %v is invalid: %w", i, config.G2Path, err)
+		}
+		if !point.IsOnCurve() || !point.IsInSubGroup() {
+			return nil, fmt.Errorf("EigenDA KZG G2 point %v in %v is not on the curve", i, config.G2Path)
+		}
+	}
+
+	kzgSetupCache.setups[*config] = setup
+	return setup, nil
+}
+
+// readSetupFile reads the first count points of a setup file, making sure the file isn't truncated
+func readSetupFile(path string, pointSize int, count uint64) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%pointSize != 0 {
+		return nil, fmt.Errorf("%v is truncated: size %v isn't a multiple of the %v byte point size", path, len(data), pointSize)
+	}
+	points := uint64(len(data) / pointSize)
+	if points < count {
+		return nil, fmt.Errorf("%v only has %v points but %v are required", path, points, count)
+	}
+	return data[:count*uint64(pointSize)], nil
+}
+
+// verifyDispersedCommitment checks that the commitment the disperser reported for a blob is to the data dispersed
+func verifyDispersedCommitment(setup *KZGSetup, data []byte, reported *eigendacommon.G1Commitment) error {
+	var commitment bn254.G1Affine
+	commitment.X.SetBytes(reported.GetX())
+	commitment.Y.SetBytes(reported.GetY())
+	computed, err := ComputePayloadCommitment(setup, data)
+	if err != nil {
+		return err
+	}
+	if !computed.Equal(&commitment) {
+		return ErrCommitmentMismatch
+	}
+	return nil
+}
+
+// BlobToFieldElements parses the zero padded blob, rejecting field elements that aren't canonically encoded
+func BlobToFieldElements(blob []byte) ([]fr.Element, error) {
+	elements := make([]fr.Element, (len(blob)+BytesPerFieldElement-1)/BytesPerFieldElement)
+	for i := range elements {
+		var chunk [BytesPerFieldElement]byte
+		copy(chunk[:], blob[i*BytesPerFieldElement:])
+		if err := elements[i].SetBytesCanonical(chunk[:]); err != nil {
+			return nil, fmt.Errorf("EigenDA blob field element %v is invalid: %w", i, err)
+		}
+	}
+	return elements, nil
+}
+
+// PayloadToFieldElements splits arbitrary bytes into field elements the way the disperser encodes them, with 31 bytes
+// of the payload behind a zero byte in each element, so that every payload has a canonical encoding.
+func PayloadToFieldElements(payload []byte) []fr.Element {
+	elements := make([]fr.Element, (len(payload)+BytesPerEncodedChunk-1)/BytesPerEncodedChunk)
+	for i := range elements {
+		var chunk [BytesPerFieldElement]byte
+		copy(chunk[1:], payload[i*BytesPerEncodedChunk:])
+		elements[i].SetBytes(chunk[:])
+	}
+	return elements
+}
+
+// ComputeCommitment commits to the blob with the trusted setup
+func ComputeCommitment(setup *KZGSetup, blob []byte) (*bn254.G1Affine, error) {
+	elements, err := BlobToFieldElements(blob)
+	if err != nil {
+		return nil, err
+	}
+	return commitToFieldElements(setup, elements)
+}
+
+// ComputePayloadCommitment commits to the payload as the disperser encodes it, which is what dispersed blobs commit to
+func ComputePayloadCommitment(setup *KZGSetup, payload []byte) (*bn254.G1Affine, error) {
+	return commitToFieldElements(setup, PayloadToFieldElements(payload))
+}
+
+func commitToFieldElements(setup *KZGSetup, elements []fr.Element) (*bn254.G1Affine, error) {
+	if len(elements) > len(setup.G1) {
+		return nil, fmt.Errorf("EigenDA blob has %v field elements but the KZG setup only has %v points", len(elements), len(setup.G1))
+	}
+	var commitment bn254.G1Affine
+	if len(elements) == 0 {
+		return &commitment, nil
+	}
+	if _, err := commitment.MultiExp(setup.G1[:len(elements)], elements, ecc.MultiExpConfig{}); err != nil {
+		return nil, err
+	}
+	return &commitment, nil
+}
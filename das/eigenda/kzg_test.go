@@ -0,0 +1,181 @@
+// Copyright 2024-2024, Alt Research, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package eigenda
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	eigendacommon "github.com/Layr-Labs/eigenda/api/grpc/common"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func writeTestSetup(t *testing.T, points int) (string, string) {
+	t.Helper()
+	_, _, g1Gen, g2Gen := bn254.Generators()
+	var g1Data, g2Data []byte
+	for i := 1; i <= points; i++ {
+		var g1 bn254.G1Affine
+		var g2 bn254.G2Affine
+		g1.ScalarMultiplication(&g1Gen, big.NewInt(int64(i)))
+		g2.ScalarMultiplication(&g2Gen, big.NewInt(int64(i)))
+		g1Bytes := g1.Bytes()
+		g2Bytes := g2.Bytes()
+		g1Data = append(g1Data, g1Bytes[:]...)
+		g2Data = append(g2Data, g2Bytes[:]...)
+	}
+	dir := t.TempDir()
+	g1Path := filepath.Join(dir, "g1.point")
+	g2Path := filepath.Join(dir, "g2.point")
+	Require(t, os.WriteFile(g1Path, g1Data, 0600))
+	Require(t, os.WriteFile(g2Path, g2Data, 0600))
+	return g1Path, g2Path
+}
+
+func TestLoadKZGSetup(t *testing.T) {
+	g1Path, g2Path := writeTestSetup(t, 8)
+
+	// files may contain more points than are loaded
+	config := &KZGConfig{G1Path: g1Path, G2Path: g2Path, SRSOrder: 4}
+	setup, err := LoadKZGSetup(config)
+	Require(t, err)
+	if len(setup.G1) != 4 || len(setup.G2) != 4 {
+		Fail(t, "wrong number of points loaded", len(setup.G1), len(setup.G2))
+	}
+	_, _, g1Gen, g2Gen := bn254.Generators()
+	if !setup.G1[0].Equal(&g1Gen) || !setup.G2[0].Equal(&g2Gen) {
+		Fail(t, "first points of the setup should be the generators")
+	}
+
+	cached, err := LoadKZGSetup(config)
+	Require(t, err)
+	if cached != setup {
+		Fail(t, "setup wasn't reused from the cache")
+	}
+
+	config = &KZGConfig{G1Path: g1Path, G2Path: g2Path, SRSOrder: 9}
+	if _, err := LoadKZGSetup(config); err == nil || !strings.Contains(err.Error(), "only has 8 points") {
+		Fail(t, "loaded more points than the setup has", err)
+	}
+}
+
+func TestLoadKZGSetupTruncated(t *testing.T) {
+	g1Path, g2Path := writeTestSetup(t, 4)
+	g1Data, err := os.ReadFile(g1Path)
+	Require(t, err)
+	Require(t, os.WriteFile(g1Path, g1Data[:len(g1Data)-1], 0600))
+
+	_, err = LoadKZGSetup(&KZGConfig{G1Path: g1Path, G2Path: g2Path, SRSOrder: 4})
+	if err == nil || !strings.Contains(err.Error(), "truncated") {
+		Fail(t, "loaded a truncated setup", err)
+	}
+}
+
+func TestLoadKZGSetupOffCurve(t *testing.T) {
+	g1Path, g2Path := writeTestSetup(t, 4)
+	g1Data, err := os.ReadFile(g1Path)
+	Require(t, err)
+
+	// find an x coordinate near the generator's for which no point exists on the curve
+	_, _, g1Gen, _ := bn254.Generators()
+	offCurve := g1Gen.Bytes()
+	for {
+		offCurve[len(offCurve)-1]++
+		var point bn254.G1Affine
+		if _, err := point.SetBytes(offCurve[:]); err != nil {
+			break
+		}
+	}
+	copy(g1Data[2*bn254.SizeOfG1AffineCompressed:], offCurve[:])
+	Require(t, os.WriteFile(g1Path, g1Data, 0600))
+
+	_, err = LoadKZGSetup(&KZGConfig{G1Path: g1Path, G2Path: g2Path, SRSOrder: 4})
+	if err == nil || !strings.Contains(err.Error(), "G1 point 2") {
+		Fail(t, "loaded a setup with an off-curve point", err)
+	}
+}
+
+func TestComputeCommitment(t *testing.T) {
+	g1Path, g2Path := writeTestSetup(t, 4)
+	setup, err := LoadKZGSetup(&KZGConfig{G1Path: g1Path, G2Path: g2Path, SRSOrder: 4})
+	Require(t, err)
+
+	// the i-th point of the test setup is (i+1) times the generator, so the commitment is known in advance
+	blob := make([]byte, 2*BytesPerFieldElement+2)
+	blob[BytesPerFieldElement-1] = 5
+	blob[2*BytesPerFieldElement-1] = 7
+	blob[2*BytesPerFieldElement+1] = 1
+	padded := make([]byte, 3*BytesPerFieldElement)
+	copy(padded, blob)
+	lastElement := new(big.Int).SetBytes(padded[2*BytesPerFieldElement:])
+	scalar := new(big.Int).Add(big.NewInt(5*1+7*2), new(big.Int).Mul(lastElement, big.NewInt(3)))
+	scalar.Mod(scalar, fr.Modulus())
+	_, _, g1Gen, _ := bn254.Generators()
+	var commitment bn254.G1Affine
+	commitment.ScalarMultiplication(&g1Gen, scalar)
+
+	computed, err := ComputeCommitment(setup, blob)
+	Require(t, err)
+	if !computed.Equal(&commitment) {
+		Fail(t, "computed the wrong commitment")
+	}
+
+	// field elements must be canonical, and the blob can't be longer than the setup
+	if _, err := ComputeCommitment(setup, bytes.Repeat([]byte{0xff}, BytesPerFieldElement)); err == nil {
+		Fail(t, "committed to a non-canonical field element")
+	}
+	if _, err := ComputeCommitment(setup, make([]byte, 4*BytesPerFieldElement+1)); err == nil {
+		Fail(t, "committed to a blob longer than the setup")
+	}
+}
+
+func TestComputePayloadCommitment(t *testing.T) {
+	g1Path, g2Path := writeTestSetup(t, 4)
+	setup, err := LoadKZGSetup(&KZGConfig{G1Path: g1Path, G2Path: g2Path, SRSOrder: 4})
+	Require(t, err)
+
+	// random payloads are mostly not canonical field elements when read 32 bytes at a time, but they're always encodable
+	payload := testhelpers.RandomizeSlice(make([]byte, 3*BytesPerEncodedChunk+5))
+	payload[0] = 0xff
+	if _, err := BlobToFieldElements(payload); err == nil {
+		Fail(t, "test payload is already canonical")
+	}
+
+	// each element holds the next 31 bytes of the payload, and the last is zero padded on the right
+	scalar := new(big.Int)
+	for i := 0; i < 4; i++ {
+		var chunk [BytesPerFieldElement]byte
+		copy(chunk[1:], payload[i*BytesPerEncodedChunk:])
+		element := new(big.Int).SetBytes(chunk[:])
+		scalar.Add(scalar, element.Mul(element, big.NewInt(int64(i+1))))
+	}
+	scalar.Mod(scalar, fr.Modulus())
+	_, _, g1Gen, _ := bn254.Generators()
+	var commitment bn254.G1Affine
+	commitment.ScalarMultiplication(&g1Gen, scalar)
+
+	computed, err := ComputePayloadCommitment(setup, payload)
+	Require(t, err)
+	if !computed.Equal(&commitment) {
+		Fail(t, "computed the wrong commitment")
+	}
+	reported := &eigendacommon.G1Commitment{X: commitment.X.Marshal(), Y: commitment.Y.Marshal()}
+	Require(t, verifyDispersedCommitment(setup, payload, reported))
+
+	payload[len(payload)-1] ^= 1
+	if err := verifyDispersedCommitment(setup, payload, reported); !errors.Is(err, ErrCommitmentMismatch) {
+		Fail(t, "verified a payload against another payload's commitment", err)
+	}
+	if _, err := ComputePayloadCommitment(setup, make([]byte, 4*BytesPerEncodedChunk+1)); err == nil {
+		Fail(t, "committed to a payload longer than the setup")
+	}
+}
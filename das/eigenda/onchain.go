@@ -0,0 +1,273 @@
+// Copyright 2024-2024, Alt Research, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package eigenda
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Layr-Labs/eigenda/api/grpc/disperser"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	flag "github.com/spf13/pflag"
+)
+
+// OnChainVerificationConfig checks blobs read from EigenDA against the batches confirmed by the service manager on L1,
+// so that reads don't have to trust the disperser
+type OnChainVerificationConfig struct {
+	Enable         bool   `koanf:"enable"`
+	L1Rpc          string `koanf:"l1-rpc"`
+	ServiceManager string `koanf:"service-manager"`
+}
+
+var DefaultOnChainVerificationConfig = OnChainVerificationConfig{
+	Enable:         false,
+	L1Rpc:          "",
+	ServiceManager: "",
+}
+
+func OnChainVerificationConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultOnChainVerificationConfig.Enable, "verify blobs read from EigenDA against the batches confirmed by the EigenDA service manager on L1")
+	f.String(prefix+".l1-rpc", DefaultOnChainVerificationConfig.L1Rpc, "L1 RPC endpoint the EigenDA service manager is read from")
+	f.String(prefix+".service-manager", DefaultOnChainVerificationConfig.ServiceManager, "address of the EigenDA service manager contract")
+}
+
+func (c *OnChainVerificationConfig) Validate() error {
+	if !c.Enable {
+		return nil
+	}
+	if c.L1Rpc == "" {
+		return errors.New("EigenDA on-chain verification is enabled but no L1 RPC is configured")
+	}
+	if !common.IsHexAddress(c.ServiceManager) {
+		return fmt.Errorf("invalid EigenDA service manager address %q", c.ServiceManager)
+	}
+	return nil
+}
+
+// the part of the service manager's interface that exposes the metadata of confirmed batches
+const serviceManagerABI = `[{
+	"type": "function",
+	"name": "batchIdToBatchMetadataHash",
+	"stateMutability": "view",
+	"inputs": [
+		{"name": "batchId", "type": "uint32"}
+	],
+	"outputs": [
+		{"name": "", "type": "bytes32"}
+	]
+}]`
+
+var (
+	ErrBatchNotConfirmed     = errors.New("EigenDA service manager hasn't confirmed the blob's batch")
+	ErrBatchMetadataMismatch = errors.New("EigenDA blob certificate doesn't match the batch metadata confirmed on L1")
+	ErrInvalidInclusionProof = errors.New("EigenDA blob certificate's inclusion proof doesn't prove the blob is in its batch")
+	ErrInvalidCertificate    = errors.New("EigenDA blob certificate doesn't match its ref")
+)
+
+// solidity encodings of the service manager's BatchHeader and BlobHeader structs, which its hashes are taken over
+var (
+	batchHeaderArguments = mustNewArguments(abi.ArgumentMarshaling{Name: "batchHeader", Type: "tuple", Components: []abi.ArgumentMarshaling{
+		{Name: "blobHeadersRoot", Type: "bytes32"},
+		{Name: "quorumNumbers", Type: "bytes"},
+		{Name: "signedStakeForQuorums", Type: "bytes"},
+		{Name: "referenceBlockNumber", Type: "uint32"},
+	}})
+	blobHeaderArguments = mustNewArguments(abi.ArgumentMarshaling{Name: "blobHeader", Type: "tuple", Components: []abi.ArgumentMarshaling{
+		{Name: "commitment", Type: "tuple", Components: []abi.ArgumentMarshaling{
+			{Name: "X", Type: "uint256"},
+			{Name: "Y", Type: "uint256"},
+		}},
+		{Name: "dataLength", Type: "uint32"},
+		{Name: "quorumBlobParams", Type: "tuple[]", Components: []abi.ArgumentMarshaling{
+			{Name: "quorumNumber", Type: "uint8"},
+			{Name: "adversaryThresholdPercentage", Type: "uint8"},
+			{Name: "confirmationThresholdPercentage", Type: "uint8"},
+			{Name: "chunkLength", Type: "uint32"},
+		}},
+	}})
+)
+
+func mustNewArguments(argument abi.ArgumentMarshaling) abi.Arguments {
+	typ, err := abi.NewType(argument.Type, "", argument.Components)
+	if err != nil {
+		panic(err)
+	}
+	return abi.Arguments{{Name: argument.Name, Type: typ}}
+}
+
+type solidityBatchHeader struct {
+	BlobHeadersRoot       [32]byte
+	QuorumNumbers         []byte
+	SignedStakeForQuorums []byte
+	ReferenceBlockNumber  uint32
+}
+
+type solidityG1Point struct {
+	X *big.Int
+	Y *big.Int
+}
+
+type solidityQuorumBlobParam struct {
+	QuorumNumber                    uint8
+	AdversaryThresholdPercentage    uint8
+	ConfirmationThresholdPercentage uint8
+	ChunkLength                     uint32
+}
+
+type solidityBlobHeader struct {
+	Commitment       solidityG1Point
+	DataLength       uint32
+	QuorumBlobParams []solidityQuorumBlobParam
+}
+
+// hashBatchHeader is the hash the service manager identifies a batch by, which refs carry as their batch header hash
+func hashBatchHeader(header *disperser.BatchHeader) (common.Hash, error) {
+	encoded, err := batchHeaderArguments.Pack(solidityBatchHeader{
+		BlobHeadersRoot:       common.BytesToHash(header.GetBatchRoot()),
+		QuorumNumbers:         header.GetQuorumNumbers(),
+		SignedStakeForQuorums: header.GetQuorumSignedPercentages(),
+		ReferenceBlockNumber:  header.GetReferenceBlockNumber(),
+	})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(encoded), nil
+}
+
+// hashBatchMetadata is the hash the service manager records for a batch when it's confirmed
+func hashBatchMetadata(batchHeaderHash common.Hash, signatoryRecordHash []byte, confirmationBlockNumber uint32) common.Hash {
+	var confirmedAt [4]byte
+	binary.BigEndian.PutUint32(confirmedAt[:], confirmationBlockNumber)
+	return crypto.Keccak256Hash(batchHeaderHash.Bytes(), common.BytesToHash(signatoryRecordHash).Bytes(), confirmedAt[:])
+}
+
+// hashBlobLeaf is the leaf of the blob's header in the merkle tree its batch's blob headers root is the root of
+func hashBlobLeaf(header *disperser.BlobHeader) (common.Hash, error) {
+	params := make([]solidityQuorumBlobParam, len(header.GetBlobQuorumParams()))
+	for i, param := range header.GetBlobQuorumParams() {
+		params[i] = solidityQuorumBlobParam{
+			QuorumNumber:                    uint8(param.GetQuorumNumber()),
+			AdversaryThresholdPercentage:    uint8(param.GetAdversaryThresholdPercentage()),
+			ConfirmationThresholdPercentage: uint8(param.GetQuorumThresholdPercentage()),
+			ChunkLength:                     param.GetChunkLength(),
+		}
+	}
+	encoded, err := blobHeaderArguments.Pack(solidityBlobHeader{
+		Commitment: solidityG1Point{
+			X: new(big.Int).SetBytes(header.GetCommitment().GetX()),
+			Y: new(big.Int).SetBytes(header.GetCommitment().GetY()),
+		},
+		DataLength:       header.GetDataLength(),
+		QuorumBlobParams: params,
+	})
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return crypto.Keccak256Hash(crypto.Keccak256(encoded)), nil
+}
+
+// verifyInclusion checks a keccak merkle proof of the leaf at the index, as the service manager's Merkle library does
+func verifyInclusion(proof []byte, root common.Hash, leaf common.Hash, index uint32) bool {
+	if len(proof)%32 != 0 {
+		return false
+	}
+	computed := leaf
+	for i := 0; i < len(proof); i += 32 {
+		if index%2 == 0 {
+			computed = crypto.Keccak256Hash(computed.Bytes(), proof[i:i+32])
+		} else {
+			computed = crypto.Keccak256Hash(proof[i:i+32], computed.Bytes())
+		}
+		index /= 2
+	}
+	return computed == root
+}
+
+// onChainVerifier checks the certificates of fetched blobs against the batches the service manager confirmed,
+// and the blobs against the commitments in their certificates
+type onChainVerifier struct {
+	contract *bind.BoundContract
+	kzgSetup *KZGSetup
+}
+
+func newOnChainVerifier(caller bind.ContractCaller, serviceManager common.Address, kzgSetup *KZGSetup) (*onChainVerifier, error) {
+	if kzgSetup == nil {
+		return nil, errors.New("EigenDA on-chain verification requires a KZG setup")
+	}
+	parsed, err := abi.JSON(strings.NewReader(serviceManagerABI))
+	if err != nil {
+		return nil, err
+	}
+	return &onChainVerifier{
+		contract: bind.NewBoundContract(serviceManager, parsed, caller, nil, nil),
+		kzgSetup: kzgSetup,
+	}, nil
+}
+
+// confirmedBatchMetadataHash is the metadata hash the service manager recorded when it confirmed the batch
+func (v *onChainVerifier) confirmedBatchMetadataHash(ctx context.Context, batchId uint32) (common.Hash, error) {
+	var output []interface{}
+	err := v.contract.Call(&bind.CallOpts{Context: ctx}, &output, "batchIdToBatchMetadataHash", batchId)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to read the EigenDA batch metadata hash from L1: %w", err)
+	}
+	hash := common.Hash(output[0].([32]byte))
+	if hash == (common.Hash{}) {
+		return common.Hash{}, ErrBatchNotConfirmed
+	}
+	return hash, nil
+}
+
+// verifyCertificate checks that the ref's certificate is for a blob of a batch the service manager confirmed
+func (v *onChainVerifier) verifyCertificate(ctx context.Context, ref *EigenDARef) error {
+	proof := ref.Certificate.GetBlobVerificationProof()
+	metadata := proof.GetBatchMetadata()
+	batchHeaderHash, err := hashBatchHeader(metadata.GetBatchHeader())
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(batchHeaderHash.Bytes(), ref.BatchHeaderHash) || proof.GetBlobIndex() != ref.BlobIndex {
+		return ErrInvalidCertificate
+	}
+	confirmed, err := v.confirmedBatchMetadataHash(ctx, proof.GetBatchId())
+	if err != nil {
+		return err
+	}
+	if hashBatchMetadata(batchHeaderHash, metadata.GetSignatoryRecordHash(), metadata.GetConfirmationBlockNumber()) != confirmed {
+		return ErrBatchMetadataMismatch
+	}
+	leaf, err := hashBlobLeaf(ref.Certificate.GetBlobHeader())
+	if err != nil {
+		return err
+	}
+	root := common.BytesToHash(metadata.GetBatchHeader().GetBatchRoot())
+	if !verifyInclusion(proof.GetInclusionProof(), root, leaf, proof.GetBlobIndex()) {
+		return ErrInvalidInclusionProof
+	}
+	return nil
+}
+
+// verify checks that the blob is the data its certificate commits to, and that the certificate is for a blob the
+// service manager confirmed. Refs posted before certificates were carried can't be verified, so their blobs are
+// trusted as the disperser serves them.
+func (v *onChainVerifier) verify(ctx context.Context, ref *EigenDARef, data []byte) error {
+	if ref.Certificate == nil {
+		log.Warn("EigenDA ref has no certificate to verify against L1, trusting the disperser", "batchHeaderHash", hexutil.Encode(ref.BatchHeaderHash), "blobIndex", ref.BlobIndex)
+		return nil
+	}
+	if err := v.verifyCertificate(ctx, ref); err != nil {
+		return err
+	}
+	return verifyDispersedCommitment(v.kzgSetup, data, ref.Certificate.GetBlobHeader().GetCommitment())
+}
@@ -0,0 +1,202 @@
+// Copyright 2024-2024, Alt Research, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package eigenda
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	eigendacommon "github.com/Layr-Labs/eigenda/api/grpc/common"
+	"github.com/Layr-Labs/eigenda/api/grpc/disperser"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// mockServiceManager is an L1 that serves the metadata hashes of the batches the service manager confirmed
+type mockServiceManager struct {
+	abi       abi.ABI
+	confirmed map[uint32]common.Hash
+	requests  int
+}
+
+func newMockServiceManager(t *testing.T) *mockServiceManager {
+	parsed, err := abi.JSON(strings.NewReader(serviceManagerABI))
+	Require(t, err)
+	return &mockServiceManager{abi: parsed, confirmed: make(map[uint32]common.Hash)}
+}
+
+// confirm records the batch of the certificate as the service manager would on confirming it
+func (m *mockServiceManager) confirm(t *testing.T, certificate *disperser.BlobInfo) {
+	t.Helper()
+	proof := certificate.GetBlobVerificationProof()
+	metadata := proof.GetBatchMetadata()
+	batchHeaderHash, err := hashBatchHeader(metadata.GetBatchHeader())
+	Require(t, err)
+	m.confirmed[proof.GetBatchId()] = hashBatchMetadata(batchHeaderHash, metadata.GetSignatoryRecordHash(), metadata.GetConfirmationBlockNumber())
+}
+
+func (m *mockServiceManager) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{0}, nil
+}
+
+func (m *mockServiceManager) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	m.requests++
+	method := m.abi.Methods["batchIdToBatchMetadataHash"]
+	args, err := method.Inputs.Unpack(call.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	return method.Outputs.Pack([32]byte(m.confirmed[args[0].(uint32)]))
+}
+
+// testCertificate certifies a blob with the commitment as the second of two blobs in a batch
+func testCertificate(t *testing.T, commitment *bn254.G1Affine) *disperser.BlobInfo {
+	t.Helper()
+	x, y := commitment.X.Bytes(), commitment.Y.Bytes()
+	blobHeader := &disperser.BlobHeader{
+		Commitment: &eigendacommon.G1Commitment{X: x[:], Y: y[:]},
+		DataLength: 1,
+		BlobQuorumParams: []*disperser.BlobQuorumParam{
+			{QuorumNumber: 0, AdversaryThresholdPercentage: 25, QuorumThresholdPercentage: 50, ChunkLength: 1},
+		},
+	}
+	leaf, err := hashBlobLeaf(blobHeader)
+	Require(t, err)
+	sibling := crypto.Keccak256Hash([]byte("the batch's other blob"))
+	batchHeader := &disperser.BatchHeader{
+		BatchRoot:               crypto.Keccak256(sibling.Bytes(), leaf.Bytes()),
+		QuorumNumbers:           []byte{0},
+		QuorumSignedPercentages: []byte{80},
+		ReferenceBlockNumber:    100,
+	}
+	batchHeaderHash, err := hashBatchHeader(batchHeader)
+	Require(t, err)
+	return &disperser.BlobInfo{
+		BlobHeader: blobHeader,
+		BlobVerificationProof: &disperser.BlobVerificationProof{
+			BatchId:   3,
+			BlobIndex: 1,
+			BatchMetadata: &disperser.BatchMetadata{
+				BatchHeader:             batchHeader,
+				SignatoryRecordHash:     crypto.Keccak256([]byte("signatories")),
+				ConfirmationBlockNumber: 110,
+				BatchHeaderHash:         batchHeaderHash.Bytes(),
+			},
+			InclusionProof: sibling.Bytes(),
+		},
+	}
+}
+
+func TestEigenDAOnChainVerification(t *testing.T) {
+	ctx := context.Background()
+	g1Path, g2Path := writeTestSetup(t, 4)
+	setup, err := LoadKZGSetup(&KZGConfig{G1Path: g1Path, G2Path: g2Path, SRSOrder: 4})
+	Require(t, err)
+	client := newMockDisperserClient()
+	l1 := newMockServiceManager(t)
+	verifier, err := newOnChainVerifier(l1, common.HexToAddress("0x5e41ce"), setup)
+	Require(t, err)
+	eigenDA := newTestEigenDA(client, time.Second*5, time.Second*5)
+	eigenDA.onChainVerifier = verifier
+	// blobs served from the cache are verified like retrieved ones
+	eigenDA.readCache = newBlobCache(&ReadCacheConfig{Enable: true, Size: 4})
+
+	// the first point of the test setup is the generator, so a blob of a single field element commits to its multiple
+	client.data = make([]byte, BytesPerEncodedChunk)
+	client.data[BytesPerEncodedChunk-1] = 5
+	_, _, g1Gen, _ := bn254.Generators()
+	var commitment bn254.G1Affine
+	commitment.ScalarMultiplication(&g1Gen, big.NewInt(5))
+
+	// refs are read back from the certificates posted in their place
+	written, err := eigenDA.Serialize(eigenDARefFromCertificate(testCertificate(t, &commitment)))
+	Require(t, err)
+	if !IsEigenDACertifiedMessageHeaderByte(written[0]) {
+		Fail(t, "certified ref was posted with header byte", written[0])
+	}
+	ref, err := eigenDARefFromBatch(written)
+	Require(t, err)
+	query := func(expected error) {
+		t.Helper()
+		data, err := eigenDA.QueryBlob(ctx, ref)
+		if !errors.Is(err, expected) {
+			Fail(t, "expected", expected, "but got", err)
+		}
+		if err == nil && !bytes.Equal(data, client.data) {
+			Fail(t, "unexpected data", data)
+		}
+	}
+
+	// nothing's confirmed for the blob's batch yet
+	query(ErrBatchNotConfirmed)
+	l1.confirm(t, ref.Certificate)
+	query(nil)
+
+	// the certificate must match the batch that was confirmed
+	ref.Certificate.GetBlobVerificationProof().GetBatchMetadata().ConfirmationBlockNumber++
+	query(ErrBatchMetadataMismatch)
+	ref.Certificate.GetBlobVerificationProof().GetBatchMetadata().ConfirmationBlockNumber--
+
+	// and prove the blob is in it
+	proof := ref.Certificate.GetBlobVerificationProof()
+	proof.InclusionProof = crypto.Keccak256([]byte("some other blob"))
+	query(ErrInvalidInclusionProof)
+	proof.InclusionProof = nil
+	query(ErrInvalidInclusionProof)
+
+	// and be for the ref's batch
+	ref = eigenDARefFromCertificate(testCertificate(t, &commitment))
+	ref.BatchHeaderHash = crypto.Keccak256([]byte("another batch"))
+	query(ErrInvalidCertificate)
+
+	// a valid certificate for a commitment the blob doesn't match is rejected too
+	commitment.ScalarMultiplication(&g1Gen, big.NewInt(6))
+	ref = eigenDARefFromCertificate(testCertificate(t, &commitment))
+	l1.confirm(t, ref.Certificate)
+	query(ErrCommitmentMismatch)
+
+	// refs without a certificate can't be verified, so their blobs are read without L1
+	requests := l1.requests
+	ref = &EigenDARef{BatchHeaderHash: client.batchHeaderHash, BlobIndex: client.blobIndex}
+	query(nil)
+	if l1.requests != requests {
+		Fail(t, "read L1 for a ref without a certificate")
+	}
+
+	// without verification the disperser is trusted and L1 isn't read
+	eigenDA.onChainVerifier = nil
+	ref = eigenDARefFromCertificate(testCertificate(t, &commitment))
+	query(nil)
+	if l1.requests != requests {
+		Fail(t, "read L1 with on-chain verification disabled")
+	}
+}
+
+func TestOnChainVerificationConfig(t *testing.T) {
+	config := DefaultEigenDAConfig
+	config.Enable = true
+	config.OnChainVerification = OnChainVerificationConfig{Enable: true, L1Rpc: "http://l1", ServiceManager: "0x5e41ce"}
+	if err := config.Validate(); err == nil {
+		Fail(t, "enabled on-chain verification without a KZG setup")
+	}
+	g1Path, g2Path := writeTestSetup(t, 4)
+	config.KZG = KZGConfig{G1Path: g1Path, G2Path: g2Path, SRSOrder: 4}
+	if err := config.Validate(); err == nil {
+		Fail(t, "accepted a malformed service manager address")
+	}
+	config.OnChainVerification.ServiceManager = common.HexToAddress("0x5e41ce").Hex()
+	Require(t, config.Validate())
+	config.OnChainVerification.L1Rpc = ""
+	if err := config.Validate(); err == nil {
+		Fail(t, "enabled on-chain verification without an L1 RPC")
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2024-2024, Alt Research, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package eigenda
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+// QueryBlobs fetches the blobs of the refs with up to concurrency queries in flight, returning their data in the
+// order of the refs. The first failed query cancels the rest and its error is returned.
+func QueryBlobs(ctx context.Context, daReader EigenDAReader, refs []*EigenDARef, concurrency int) ([][]byte, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([][]byte, len(refs))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	var failOnce sync.Once
+	var failure error
+	for i := 0; i < concurrency && i < len(refs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indices {
+				data, err := daReader.QueryBlob(ctx, refs[index])
+				if err != nil {
+					failOnce.Do(func() {
+						failure = fmt.Errorf("failed to query EigenDA blob %v: %w", index, err)
+						cancel()
+					})
+					return
+				}
+				results[index] = data
+			}
+		}()
+	}
+
+feed:
+	for i := range refs {
+		select {
+		case indices <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indices)
+	wg.Wait()
+
+	if failure != nil {
+		return nil, failure
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// RecoverPayloadsFromEigenDABatches is RecoverPayloadFromEigenDABatch for many batches, whose blobs are fetched
+// concurrently. The payloads are returned in the order of the batches.
+func RecoverPayloadsFromEigenDABatches(ctx context.Context,
+	sequencerMsgs [][]byte,
+	daReader EigenDAReader,
+	preimages map[arbutil.PreimageType]map[common.Hash][]byte,
+	concurrency int,
+) ([][]byte, error) {
+	refs := make([]*EigenDARef, len(sequencerMsgs))
+	for i, sequencerMsg := range sequencerMsgs {
+		ref, err := eigenDARefFromBatch(sequencerMsg)
+		if err != nil {
+			return nil, err
+		}
+		refs[i] = ref
+	}
+	blobs, err := QueryBlobs(ctx, daReader, refs, concurrency)
+	if err != nil {
+		return nil, err
+	}
+	payloads := make([][]byte, len(blobs))
+	for i, blob := range blobs {
+		payloads[i] = payloadFromBlob(refs[i], blob)
+	}
+	if preimages != nil {
+		if preimages[arbutil.Sha2_256PreimageType] == nil {
+			preimages[arbutil.Sha2_256PreimageType] = make(map[common.Hash][]byte)
+		}
+		shaPreimages := preimages[arbutil.Sha2_256PreimageType]
+		for i, ref := range refs {
+			preimageHash, err := eigenDAPreimageHash(ref)
+			if err != nil {
+				return nil, err
+			}
+			shaPreimages[preimageHash] = blobs[i]
+		}
+	}
+	return payloads, nil
+}
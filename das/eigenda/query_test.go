@@ -0,0 +1,171 @@
+// Copyright 2024-2024, Alt Research, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package eigenda
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+// delayingEigenDAReader serves each blob after a delay given by its index, tracking how many reads overlap
+type delayingEigenDAReader struct {
+	delays      []time.Duration
+	failAt      int
+	mutex       sync.Mutex
+	started     int
+	inFlight    int
+	maxInFlight int
+	canceled    int
+}
+
+func (r *delayingEigenDAReader) QueryBlob(ctx context.Context, ref *EigenDARef) ([]byte, error) {
+	r.mutex.Lock()
+	r.started++
+	r.inFlight++
+	if r.inFlight > r.maxInFlight {
+		r.maxInFlight = r.inFlight
+	}
+	r.mutex.Unlock()
+	defer func() {
+		r.mutex.Lock()
+		r.inFlight--
+		r.mutex.Unlock()
+	}()
+
+	index := int(ref.BlobIndex)
+	if index == r.failAt {
+		return nil, errors.New("blob unavailable")
+	}
+	if err := waitOrCancel(ctx, r.delays[index]); err != nil {
+		r.mutex.Lock()
+		r.canceled++
+		r.mutex.Unlock()
+		return nil, err
+	}
+	return []byte{byte(index)}, nil
+}
+
+func testRefs(count int) []*EigenDARef {
+	refs := make([]*EigenDARef, count)
+	for i := range refs {
+		refs[i] = &EigenDARef{BatchHeaderHash: bytes.Repeat([]byte{0xab}, 32), BlobIndex: uint32(i)}
+	}
+	return refs
+}
+
+func TestQueryBlobsConcurrently(t *testing.T) {
+	ctx := context.Background()
+	const count = 8
+	const delay = time.Millisecond * 100
+
+	// later blobs are faster, so they finish first and the results must be reordered
+	reader := &delayingEigenDAReader{failAt: -1}
+	for i := 0; i < count; i++ {
+		reader.delays = append(reader.delays, delay*time.Duration(count-i)/count)
+	}
+	var serial time.Duration
+	for _, d := range reader.delays {
+		serial += d
+	}
+
+	start := time.Now()
+	results, err := QueryBlobs(ctx, reader, testRefs(count), 4)
+	Require(t, err)
+	elapsed := time.Since(start)
+	if elapsed >= serial {
+		Fail(t, "concurrent reads took", elapsed, "which is no faster than reading serially", serial)
+	}
+	if reader.maxInFlight != 4 {
+		Fail(t, "expected 4 reads in flight, but had", reader.maxInFlight)
+	}
+	for i, data := range results {
+		if !bytes.Equal(data, []byte{byte(i)}) {
+			Fail(t, "blob", i, "was returned out of order as", data)
+		}
+	}
+
+	// a concurrency of one reads serially
+	reader.maxInFlight = 0
+	_, err = QueryBlobs(ctx, reader, testRefs(count), 1)
+	Require(t, err)
+	if reader.maxInFlight != 1 {
+		Fail(t, "serial reads overlapped", reader.maxInFlight)
+	}
+}
+
+func TestQueryBlobsFailure(t *testing.T) {
+	ctx := context.Background()
+	const count = 8
+
+	// a failed read is returned and cancels the slow reads still in flight
+	reader := &delayingEigenDAReader{failAt: 3}
+	for i := 0; i < count; i++ {
+		reader.delays = append(reader.delays, time.Minute)
+	}
+	start := time.Now()
+	_, err := QueryBlobs(ctx, reader, testRefs(count), count)
+	if err == nil {
+		Fail(t, "a failed read wasn't reported")
+	}
+	if time.Since(start) >= time.Minute {
+		Fail(t, "the failure didn't cancel the other reads")
+	}
+	if reader.canceled != reader.started-1 {
+		Fail(t, "only canceled", reader.canceled, "of the", reader.started-1, "other reads")
+	}
+
+	// canceling the caller's context stops the reads too
+	ctx, cancel := context.WithTimeout(ctx, time.Millisecond*50)
+	defer cancel()
+	reader = &delayingEigenDAReader{failAt: -1}
+	for i := 0; i < count; i++ {
+		reader.delays = append(reader.delays, time.Minute)
+	}
+	_, err = QueryBlobs(ctx, reader, testRefs(count), 2)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		Fail(t, "expected the reads to time out, got", err)
+	}
+}
+
+func TestRecoverPayloadsFromEigenDABatches(t *testing.T) {
+	ctx := context.Background()
+	const count = 4
+	reader := &delayingEigenDAReader{failAt: -1, delays: make([]time.Duration, count)}
+	var batches [][]byte
+	for _, ref := range testRefs(count) {
+		serialized, err := ref.Serialize()
+		Require(t, err)
+		batches = append(batches, append([]byte{EigenDAMessageHeaderFlag}, serialized...))
+	}
+
+	// the payloads and recorded preimages match reading the batches one at a time
+	preimages := make(map[arbutil.PreimageType]map[common.Hash][]byte)
+	payloads, err := RecoverPayloadsFromEigenDABatches(ctx, batches, reader, preimages, 2)
+	Require(t, err)
+	serialPreimages := make(map[arbutil.PreimageType]map[common.Hash][]byte)
+	for i, batch := range batches {
+		payload, err := RecoverPayloadFromEigenDABatch(ctx, batch, reader, serialPreimages)
+		Require(t, err)
+		if !bytes.Equal(payload, payloads[i]) {
+			Fail(t, "batch", i, "payload", payloads[i], "doesn't match reading it alone", payload)
+		}
+	}
+	got, expected := preimages[arbutil.Sha2_256PreimageType], serialPreimages[arbutil.Sha2_256PreimageType]
+	if len(got) != count || len(got) != len(expected) {
+		Fail(t, "recorded", len(got), "preimages instead of", len(expected))
+	}
+	for hash, data := range expected {
+		if !bytes.Equal(got[hash], data) {
+			Fail(t, "preimage", hash, "is", got[hash], "instead of", data)
+		}
+	}
+}
@@ -406,6 +406,19 @@ func (s *ExecutionEngine) GetGenesisBlockNumber() uint64 {
 	return s.bc.Config().ArbitrumChainParams.GenesisBlockNum
 }
 
+// BrotliCompressionLevel reads the compression level ArbOS prices transactions at, as of the head block
+func (s *ExecutionEngine) BrotliCompressionLevel() (uint64, error) {
+	statedb, err := s.bc.State()
+	if err != nil {
+		return 0, err
+	}
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return 0, err
+	}
+	return arbState.BrotliCompressionLevel()
+}
+
 func (s *ExecutionEngine) BlockNumberToMessageIndex(blockNum uint64) (arbutil.MessageIndex, error) {
 	genesis := s.GetGenesisBlockNumber()
 	if blockNum < genesis {
@@ -9,21 +9,28 @@ import (
 	"fmt"
 	"math/big"
 	"sort"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/arbitrum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/offchainlabs/nitro/arbnode"
 	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
 	"github.com/offchainlabs/nitro/arbos/retryables"
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/precompiles"
 	"github.com/offchainlabs/nitro/staker"
 	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/util/merkletree"
@@ -49,10 +56,13 @@ type NodeInterface struct {
 var merkleTopic common.Hash
 var l2ToL1TxTopic common.Hash
 var l2ToL1TransactionTopic common.Hash
+var l2ToL1TxEvent abi.Event
 
 var blockInGenesis = errors.New("")
 var blockAfterLatestBatch = errors.New("")
 
+var ErrSimulationGasExceeded = errors.New("simulation gas exceeded")
+
 func (n NodeInterface) NitroGenesisBlock(c ctx) (huge, error) {
 	block := n.backend.ChainConfig().ArbitrumChainParams.GenesisBlockNum
 	return arbmath.UintToBig(block), nil
@@ -66,6 +76,46 @@ func (n NodeInterface) FindBatchContainingBlock(c ctx, evm mech, blockNum uint64
 	return findBatchContainingBlock(node, node.TxStreamer.GenesisBlockNumber(), blockNum)
 }
 
+// GetBatchCount gets how many sequencer batches the node has read from the parent chain
+func (n NodeInterface) GetBatchCount(c ctx, evm mech) (uint64, error) {
+	node, err := arbNodeFromNodeInterfaceBackend(n.backend)
+	if err != nil {
+		return 0, err
+	}
+	return node.InboxTracker.GetBatchCount()
+}
+
+// GetBatchMetadata gets the parent chain block a sequencer batch was posted in, and the first and last L2 blocks
+// it contains. A batch without any messages has a first block one past its last.
+func (n NodeInterface) GetBatchMetadata(c ctx, evm mech, batchNum uint64) (uint64, uint64, uint64, error) {
+	node, err := arbNodeFromNodeInterfaceBackend(n.backend)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	count, err := node.InboxTracker.GetBatchCount()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if batchNum >= count {
+		return 0, 0, 0, fmt.Errorf("batch %v doesn't exist, as only %v batches have been read", batchNum, count)
+	}
+	meta, err := node.InboxTracker.GetBatchMetadata(batchNum)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	var prevMessageCount arbutil.MessageIndex
+	if batchNum > 0 {
+		prevMessageCount, err = node.InboxTracker.GetBatchMessageCount(batchNum - 1)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	genesis := node.TxStreamer.GenesisBlockNumber()
+	firstBlock := arbutil.MessageCountToBlockNumber(prevMessageCount, genesis) + 1
+	lastBlock := arbutil.MessageCountToBlockNumber(meta.MessageCount, genesis)
+	return meta.ParentChainBlock, uint64(firstBlock), uint64(lastBlock), nil
+}
+
 func (n NodeInterface) GetL1Confirmations(c ctx, evm mech, blockHash bytes32) (uint64, error) {
 	node, err := arbNodeFromNodeInterfaceBackend(n.backend)
 	if err != nil {
@@ -133,6 +183,12 @@ func (n NodeInterface) EstimateRetryableTicket(
 	l1BaseFee, _ := c.State.L1PricingState().PricePerUnit()
 	maxSubmissionFee := retryables.RetryableSubmissionFee(len(data), l1BaseFee)
 
+	// the submission's auto-redeem is simulated with the caller's gas, so it's bounded like other simulations
+	gas, _, err := n.simulationGasCap(c, n.sourceMessage.GasLimit)
+	if err != nil {
+		return err
+	}
+
 	submitTx := &types.ArbitrumSubmitRetryableTx{
 		ChainId:          nil,
 		RequestId:        hash{},
@@ -140,7 +196,7 @@ func (n NodeInterface) EstimateRetryableTicket(
 		L1BaseFee:        l1BaseFee,
 		DepositValue:     deposit,
 		GasFeeCap:        n.sourceMessage.GasPrice,
-		Gas:              n.sourceMessage.GasLimit,
+		Gas:              gas,
 		RetryTo:          pRetryTo,
 		RetryValue:       l2CallValue,
 		Beneficiary:      callValueRefundAddress,
@@ -161,6 +217,110 @@ func (n NodeInterface) EstimateRetryableTicket(
 	return nil
 }
 
+// SimulateSubmitRetryable dry-runs the submission of a retryable through the inbox as the given delayed message, where
+// l1BaseFee is the L1 basefee the message is delivered with. The other parameters are those of the inbox's
+// createRetryableTicket, with refund addresses the inbox aliases given as aliased, since the ticket id commits to them all.
+// Returns the ticket id, the submission fee, and whether the auto-redeem would succeed, running it and reverting its effects.
+// The auto-redeem runs as a plain call from the sender, so targets that inspect the redeem they're called from may differ.
+func (n NodeInterface) SimulateSubmitRetryable(
+	c ctx,
+	evm mech,
+	sender addr,
+	deposit huge,
+	to addr,
+	l2CallValue huge,
+	maxSubmissionFee huge,
+	excessFeeRefundAddress addr,
+	callValueRefundAddress addr,
+	gasLimit uint64,
+	maxFeePerGas huge,
+	data []byte,
+	l1BaseFee huge,
+	messageNum uint64,
+) (bytes32, huge, bool, error) {
+	var pRetryTo *addr
+	if to != (addr{}) {
+		pRetryTo = &to
+	}
+	from := util.RemapL1Address(sender)
+	if c.State.ArbOSVersion() >= 20 {
+		retryableState := c.State.RetryableState()
+		if err := retryableState.CheckCreator(from); err != nil {
+			return bytes32{}, nil, false, err
+		}
+		if err := retryableState.CheckDeposit(deposit); err != nil {
+			return bytes32{}, nil, false, err
+		}
+		if err := retryables.CheckTarget(pRetryTo); err != nil {
+			return bytes32{}, nil, false, err
+		}
+	}
+	if len(data) > retryables.MaxL2SubmissionDataSize {
+		return bytes32{}, nil, false, fmt.Errorf("retryable calldata exceeds the %v byte limit", retryables.MaxL2SubmissionDataSize)
+	}
+
+	ticketId := retryables.InboxTicketId(
+		evm.ChainConfig().ChainID, messageNum, from, l1BaseFee, deposit, pRetryTo, l2CallValue,
+		maxSubmissionFee, excessFeeRefundAddress, callValueRefundAddress, gasLimit, maxFeePerGas, data,
+	)
+	submissionFee := retryables.RetryableSubmissionFee(len(data), l1BaseFee)
+
+	// the deposit is minted to the sender, which pays the submission fee and escrows the callvalue
+	balance := arbmath.BigAdd(evm.StateDB.GetBalance(from), deposit)
+	if arbmath.BigLessThan(balance, maxSubmissionFee) {
+		return bytes32{}, nil, false, fmt.Errorf(
+			"insufficient funds for max submission fee: address %v have %v want %v", from, balance, maxSubmissionFee,
+		)
+	}
+	if arbmath.BigLessThan(maxSubmissionFee, submissionFee) {
+		return bytes32{}, nil, false, fmt.Errorf(
+			"max submission fee %v is less than the actual submission fee %v", maxSubmissionFee, submissionFee,
+		)
+	}
+	balance = arbmath.BigSub(balance, submissionFee)
+
+	// the excess submission fee is refunded out of what's left of the deposit after the callvalue and fee
+	if excessFeeRefundAddress != from {
+		available := arbmath.BigMax(arbmath.BigSub(deposit, arbmath.BigAdd(l2CallValue, submissionFee)), common.Big0)
+		balance = arbmath.BigSub(balance, arbmath.BigMin(arbmath.BigSub(maxSubmissionFee, submissionFee), available))
+	}
+	if arbmath.BigLessThan(balance, l2CallValue) {
+		return bytes32{}, nil, false, fmt.Errorf(
+			"insufficient funds for callvalue: address %v have %v want %v", from, balance, l2CallValue,
+		)
+	}
+	balance = arbmath.BigSub(balance, l2CallValue)
+
+	// the ticket is created without an auto-redeem if the sender can't pay for one
+	maxGasCost := arbmath.BigMulByUint(maxFeePerGas, gasLimit)
+	if arbmath.BigLessThan(balance, maxGasCost) || gasLimit < params.TxGas || arbmath.BigLessThan(maxFeePerGas, evm.Context.BaseFee) {
+		return ticketId, submissionFee, false, nil
+	}
+	intrinsic, err := core.IntrinsicGas(data, nil, pRetryTo == nil, true, true, true)
+	if err != nil || intrinsic > gasLimit {
+		return ticketId, submissionFee, false, nil
+	}
+	gas, _, err := n.simulationGasCap(c, gasLimit-intrinsic)
+	if err != nil {
+		return bytes32{}, nil, false, err
+	}
+
+	// the retry is sent from the sender with the callvalue it's paid out of escrow
+	snapshot := evm.StateDB.Snapshot()
+	util.MintBalance(&from, l2CallValue, evm, util.TracingDuringEVM, "simulatedEscrow")
+	var leftOver uint64
+	if pRetryTo == nil {
+		_, _, leftOver, err = evm.Create(vm.AccountRef(from), data, gas, l2CallValue)
+	} else {
+		_, leftOver, err = evm.Call(vm.AccountRef(from), to, data, gas, l2CallValue)
+	}
+	evm.StateDB.RevertToSnapshot(snapshot)
+	if burnErr := c.Burn(gas - leftOver); burnErr != nil {
+		return bytes32{}, nil, false, burnErr
+	}
+	return ticketId, submissionFee, err == nil, nil
+}
+
 func (n NodeInterface) ConstructOutboxProof(c ctx, evm mech, size, leaf uint64) (bytes32, bytes32, []bytes32, error) {
 
 	hash0 := bytes32{}
@@ -448,9 +608,12 @@ func (n NodeInterface) GasEstimateL1Component(
 	c ctx, evm mech, value huge, to addr, contractCreation bool, data []byte,
 ) (uint64, huge, huge, error) {
 
-	// construct a similar message with a random gas limit to avoid underestimating
+	// construct a similar message with a random gas limit to avoid underestimating, within the simulation limit
 	args := n.messageArgs(evm, value, to, contractCreation, data)
-	randomGas := l1pricing.RandomGas
+	randomGas, _, err := n.simulationGasCap(c, l1pricing.RandomGas)
+	if err != nil {
+		return 0, nil, nil, err
+	}
 	args.Gas = (*hexutil.Uint64)(&randomGas)
 
 	// We set the run mode to eth_call mode here because we want an exact estimate, not a padded estimate
@@ -495,12 +658,18 @@ func (n NodeInterface) GasEstimateComponents(
 	}
 
 	context := n.context
-	gasCap := backend.RPCGasCap()
+	gasCap, limited, err := n.simulationGasCap(c, backend.RPCGasCap())
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
 	block := rpc.BlockNumberOrHashWithHash(n.header.Hash(), false)
 	args := n.messageArgs(evm, value, to, contractCreation, data)
 
 	totalRaw, err := arbitrum.EstimateGas(context, backend, args, block, nil, gasCap)
 	if err != nil {
+		if limited && strings.Contains(err.Error(), "gas required exceeds allowance") {
+			return 0, 0, nil, nil, fmt.Errorf("%w: needs more than the limit of %v", ErrSimulationGasExceeded, gasCap)
+		}
 		return 0, 0, nil, nil, err
 	}
 	total := uint64(totalRaw)
@@ -535,6 +704,19 @@ func (n NodeInterface) GasEstimateComponents(
 	return total, gasForL1, baseFee, l1BaseFeeEstimate, nil
 }
 
+// simulationGasCap bounds the gas requested for a simulation, where 0 means no bound, by ArbOS's simulation gas limit,
+// reporting whether the latter applies
+func (n NodeInterface) simulationGasCap(c ctx, rpcGasCap uint64) (uint64, bool, error) {
+	limit, err := c.State.SimulationGasLimit()
+	if err != nil {
+		return 0, false, err
+	}
+	if rpcGasCap != 0 && rpcGasCap <= limit {
+		return rpcGasCap, false, nil
+	}
+	return limit, true, nil
+}
+
 func findBatchContainingBlock(node *arbnode.Node, genesis uint64, block uint64) (uint64, error) {
 	if block <= genesis {
 		return 0, fmt.Errorf("%wblock %v is part of genesis", blockInGenesis, block)
@@ -678,3 +860,189 @@ func (n NodeInterface) L2BlockRangeForL1(c ctx, evm mech, l1BlockNum uint64) (ui
 	}
 	return firstBlock, lastBlock, nil
 }
+
+// LookupRedeemTx finds the retryable and sequence number of a scheduled redeem attempt, erroring if the
+// tx isn't a redeem or this node doesn't know of it
+func (n NodeInterface) LookupRedeemTx(c ctx, evm mech, redeemTxHash bytes32) (bytes32, uint64, error) {
+	apiBackend, ok := n.backend.(*arbitrum.APIBackend)
+	if !ok {
+		return bytes32{}, 0, errors.New("API backend isn't Arbitrum")
+	}
+	tx, _, _, _ := rawdb.ReadTransaction(apiBackend.ChainDb(), redeemTxHash)
+	if tx == nil {
+		return bytes32{}, 0, fmt.Errorf("unknown transaction %v", common.Hash(redeemTxHash))
+	}
+	inner, ok := tx.GetInner().(*types.ArbitrumRetryTx)
+	if !ok {
+		return bytes32{}, 0, fmt.Errorf("transaction %v isn't a retryable redeem", common.Hash(redeemTxHash))
+	}
+	return inner.TicketId, inner.Nonce, nil
+}
+
+// GetRetryableInfoAtBlock gets a retryable's beneficiary, timeout, and number of redeem attempts as of the given
+// L2 block, erroring if the ticket didn't exist or had expired then. Old blocks require an archive node.
+func (n NodeInterface) GetRetryableInfoAtBlock(c ctx, evm mech, ticketId bytes32, l2Block uint64) (addr, uint64, uint64, error) {
+	apiBackend, ok := n.backend.(*arbitrum.APIBackend)
+	if !ok {
+		return addr{}, 0, 0, errors.New("API backend isn't Arbitrum")
+	}
+	statedb, header, err := apiBackend.StateAndHeaderByNumber(n.context, rpc.BlockNumber(l2Block))
+	if err != nil {
+		return addr{}, 0, 0, fmt.Errorf("failed to open state at block %v: %w", l2Block, err)
+	}
+	state, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return addr{}, 0, 0, err
+	}
+	retryable, err := state.RetryableState().OpenRetryable(ticketId, header.Time)
+	if err != nil {
+		return addr{}, 0, 0, err
+	}
+	if retryable == nil {
+		return addr{}, 0, 0, fmt.Errorf("no retryable %v at block %v", common.Hash(ticketId), l2Block)
+	}
+	beneficiary, err := retryable.Beneficiary()
+	if err != nil {
+		return addr{}, 0, 0, err
+	}
+	timeout, err := retryable.CalculateTimeout()
+	if err != nil {
+		return addr{}, 0, 0, err
+	}
+	numTries, err := retryable.NumTries()
+	return beneficiary, timeout, numTries, err
+}
+
+// GetStatsAtBlock returns ArbStatistics' GetStats for the given L2 block, followed by the contract and account counts
+// as of the end of it, read from that block's state. Old blocks require an archive node.
+func (n NodeInterface) GetStatsAtBlock(c ctx, evm mech, l2Block uint64) (huge, huge, huge, huge, huge, huge, uint64, uint64, error) {
+	if current := n.backend.CurrentBlock().Number.Uint64(); l2Block > current {
+		return nil, nil, nil, nil, nil, nil, 0, 0, fmt.Errorf("block %v is in the future, the latest is %v", l2Block, current)
+	}
+	apiBackend, ok := n.backend.(*arbitrum.APIBackend)
+	if !ok {
+		return nil, nil, nil, nil, nil, nil, 0, 0, errors.New("API backend isn't Arbitrum")
+	}
+	statedb, _, err := apiBackend.StateAndHeaderByNumber(n.context, rpc.BlockNumber(l2Block))
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, 0, 0, fmt.Errorf("failed to open state at block %v: %w", l2Block, err)
+	}
+	state, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, 0, 0, err
+	}
+	contracts, err := state.ContractCount()
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, 0, 0, err
+	}
+	accounts, err := state.AccountCount()
+	if err != nil {
+		return nil, nil, nil, nil, nil, nil, 0, 0, err
+	}
+	_, classicNumAccounts, classicStorageSum, classicGasSum, classicNumTxes, classicNumContracts, err := precompiles.ArbStatistics{}.GetStats(c, evm)
+	blockNum := new(big.Int).SetUint64(l2Block)
+	return blockNum, classicNumAccounts, classicStorageSum, classicGasSum, classicNumTxes, classicNumContracts, contracts, accounts, err
+}
+
+// Approximate L1 costs of executing a send through the Outbox, which checks the proof against a confirmed
+// root, marks the leaf spent, records the L2-to-L1 context, and has the Bridge call the target.
+const (
+	outboxExecutionBaseGas   = 2*params.ColdSloadCostEIP2929 + params.SstoreSetGasEIP2200 // root lookup and spent bit
+	outboxContextGas         = 4 * params.SstoreResetGasEIP2200                           // set and restore the context
+	outboxBridgeCallGas      = 2*params.ColdAccountAccessCostEIP2929 + outboxContextGas/2 // bridge call and active outbox
+	outboxProofLevelGas      = params.Keccak256Gas + 2*params.Keccak256WordGas + 100      // hashing and bookkeeping
+	outboxExecutionHeadWords = 9                                                          // executeTransaction's static args
+	outboxLeafWords          = 6                                                          // the leaf's static fields
+)
+
+// EstimateOutboxExecution approximates the L1 gas needed to execute the send at the given leaf through the Outbox,
+// proving it against the current send root. The target's own execution depends on L1 state the node doesn't have,
+// so only the costs of calling it with the send's calldata and value are included.
+func (n NodeInterface) EstimateOutboxExecution(c ctx, evm mech, leafIndex uint64) (uint64, error) {
+	currentBlock := n.backend.CurrentBlock()
+	size := types.DeserializeHeaderExtraInformation(currentBlock).SendCount
+	if leafIndex >= size {
+		return 0, errors.New("leaf does not exist")
+	}
+	send, err := n.findSend(leafIndex, currentBlock.Number.Uint64())
+	if err != nil {
+		return 0, err
+	}
+	fields, err := l2ToL1TxEvent.Inputs.NonIndexed().Unpack(send.Data)
+	if err != nil {
+		return 0, err
+	}
+	value, _ := fields[4].(*big.Int)
+	data, _ := fields[5].([]byte)
+
+	proofLevels := arbmath.Log2ceil(size)
+	if size == arbmath.NextPowerOf2(size)/2 && proofLevels > 0 {
+		proofLevels -= 1 // a balanced tree's root isn't part of the proof
+	}
+	dataWords := arbmath.WordsForBytes(uint64(len(data)))
+
+	// the proof, args, and word-aligned lengths are priced as nonzero bytes, and the data by its contents
+	gas := params.TxGas + 4*params.TxDataNonZeroGasEIP2028
+	paddedWords := outboxExecutionHeadWords + 1 + proofLevels + 1
+	gas += paddedWords * 32 * params.TxDataNonZeroGasEIP2028
+	for _, b := range data {
+		if b == 0 {
+			gas += params.TxDataZeroGas
+		} else {
+			gas += params.TxDataNonZeroGasEIP2028
+		}
+	}
+	gas += (dataWords*32 - uint64(len(data))) * params.TxDataZeroGas
+
+	gas += outboxExecutionBaseGas + outboxContextGas + outboxBridgeCallGas
+	gas += proofLevels * outboxProofLevelGas
+	gas += params.Keccak256Gas + (outboxLeafWords+dataWords)*params.Keccak256WordGas
+	gas += 2 * dataWords * params.CopyGas // copied to the bridge, and then to the target
+	if value != nil && value.Sign() > 0 {
+		gas += params.CallValueTransferGas
+	}
+	return gas, nil
+}
+
+// findSend gets the L2ToL1Tx log for a leaf by searching for the block in which the send count passed it
+func (n NodeInterface) findSend(leaf uint64, latest uint64) (*types.Log, error) {
+	lo, hi := uint64(0), latest
+	for lo < hi {
+		mid := (lo + hi) / 2
+		header, err := n.backend.HeaderByNumber(n.context, rpc.BlockNumber(mid))
+		if err != nil {
+			return nil, err
+		}
+		if header == nil {
+			return nil, fmt.Errorf("nil header for l2 block: %d", mid)
+		}
+		if types.DeserializeHeaderExtraInformation(header).SendCount > leaf {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	block, err := n.backend.BlockByNumber(n.context, rpc.BlockNumber(lo))
+	if err != nil {
+		return nil, err
+	}
+	all, err := n.backend.GetLogs(n.context, block.Hash(), block.NumberU64())
+	if err != nil {
+		return nil, err
+	}
+	position := common.BigToHash(merkletree.NewLevelAndLeaf(0, leaf).ToBigInt())
+	for _, tx := range all {
+		for _, log := range tx {
+			if log.Address != types.ArbSysAddress || len(log.Topics) < 4 || log.Topics[3] != position {
+				continue
+			}
+			if log.Topics[0] == l2ToL1TransactionTopic {
+				return nil, errors.New("send predates L2ToL1Tx events")
+			}
+			if log.Topics[0] == l2ToL1TxTopic {
+				return log, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("send %v not found in block %v", leaf, lo)
+}
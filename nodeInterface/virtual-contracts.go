@@ -168,7 +168,8 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
-	l2ToL1TxTopic = arbSys.Events["L2ToL1Tx"].ID
+	l2ToL1TxEvent = arbSys.Events["L2ToL1Tx"]
+	l2ToL1TxTopic = l2ToL1TxEvent.ID
 	l2ToL1TransactionTopic = arbSys.Events["L2ToL1Transaction"].ID
 	merkleTopic = arbSys.Events["SendMerkleUpdate"].ID
 }
@@ -5,6 +5,7 @@ package precompiles
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 )
 
@@ -63,6 +64,24 @@ func (con ArbAddressTable) LookupIndex(c ctx, evm mech, index huge) (addr, error
 	return result, nil
 }
 
+// LookupAddresses resolves each index to its address in the table, erroring with the position of the first
+// index that doesn't exist
+func (con ArbAddressTable) LookupAddresses(c ctx, evm mech, indices []uint64) ([]addr, error) {
+	table := c.State.AddressTable()
+	results := make([]addr, len(indices))
+	for i, index := range indices {
+		result, exists, err := table.LookupIndex(index)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("index %v at position %v does not exist in AddressTable", index, i)
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
 // Register adds an account to the table, shrinking its compressed representation
 func (con ArbAddressTable) Register(c ctx, evm mech, addr addr) (huge, error) {
 	slot, err := c.State.AddressTable().Register(addr)
@@ -15,6 +15,8 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	templates "github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 	"github.com/offchainlabs/nitro/util/testhelpers"
 )
 
@@ -185,3 +187,65 @@ func Fail(t *testing.T, printables ...interface{}) {
 	t.Helper()
 	testhelpers.FailImpl(t, printables...)
 }
+
+func TestLookupAddresses(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	tableABI, err := PrecompileABI(templates.ArbAddressTableMetaData, "ArbAddressTable")
+	Require(t, err)
+	tableAddress := common.HexToAddress("0x66")
+
+	var registered []common.Address
+	for i := 0; i < 4; i++ {
+		address := testhelpers.RandomAddress()
+		_, err := context.State.AddressTable().Register(address)
+		Require(t, err)
+		registered = append(registered, address)
+	}
+
+	// lookup resolves the indices, returning the addresses and the gas it took
+	lookup := func(indices ...uint64) ([]common.Address, uint64, error) {
+		t.Helper()
+		input, err := tableABI.Pack("lookupAddresses", indices)
+		Require(t, err)
+		const gas = 1000000
+		output, gasLeft, err := Precompiles()[tableAddress].Call(
+			input, tableAddress, tableAddress, common.Address{}, big.NewInt(0), false, gas, evm,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		values, err := tableABI.Methods["lookupAddresses"].Outputs.Unpack(output)
+		Require(t, err)
+		return values[0].([]common.Address), gas - gasLeft, nil
+	}
+
+	addresses, oneCost, err := lookup(2)
+	Require(t, err)
+	if len(addresses) != 1 || addresses[0] != registered[2] {
+		Fail(t, "looked up", addresses, "instead of", registered[2])
+	}
+	addresses, manyCost, err := lookup(3, 0, 3, 1)
+	Require(t, err)
+	for i, index := range []int{3, 0, 3, 1} {
+		if addresses[i] != registered[index] {
+			Fail(t, "index", index, "resolved to", addresses[i], "instead of", registered[index])
+		}
+	}
+	if manyCost < oneCost+3*storage.StorageReadCost {
+		Fail(t, "four lookups cost", manyCost, "gas against one's", oneCost)
+	}
+	addresses, _, err = lookup()
+	Require(t, err)
+	if len(addresses) != 0 {
+		Fail(t, "no indices resolved to", addresses)
+	}
+
+	// a single index past the end of the table fails the whole lookup
+	for _, indices := range [][]uint64{{4}, {0, 1, 4, 2}, {1, 1 << 40}} {
+		if _, _, err := lookup(indices...); err == nil {
+			Fail(t, "looked up out of range indices", indices)
+		}
+	}
+}
@@ -36,6 +36,11 @@ func (con ArbAggregator) GetBatchPosters(c ctx, evm mech) ([]addr, error) {
 	return c.State.L1PricingState().BatchPosterTable().AllPosters(65536)
 }
 
+// IsBatchPoster checks if the account is a registered batch poster
+func (con ArbAggregator) IsBatchPoster(c ctx, evm mech, account addr) (bool, error) {
+	return c.State.L1PricingState().BatchPosterTable().ContainsPoster(account)
+}
+
 func (con ArbAggregator) AddBatchPoster(c ctx, evm mech, newBatchPoster addr) error {
 	isOwner, err := c.State.ChainOwners().IsMember(c.caller)
 	if err != nil {
@@ -89,14 +94,52 @@ func (con ArbAggregator) SetFeeCollector(c ctx, evm mech, batchPoster addr, newF
 	return posterInfo.SetPayTo(newFeeCollector)
 }
 
-// GetTxBaseFee gets an aggregator's current fixed fee to submit a tx
+// GetTxBaseFee gets an aggregator's current fixed fee to submit a tx, in L1 gas
 func (con ArbAggregator) GetTxBaseFee(c ctx, evm mech, aggregator addr) (huge, error) {
-	// This is deprecated and now always returns zero.
-	return big.NewInt(0), nil
+	if c.State.ArbOSVersion() < 20 {
+		// This was deprecated and always returned zero.
+		return big.NewInt(0), nil
+	}
+	posterInfo, err := c.State.L1PricingState().BatchPosterTable().OpenPoster(aggregator, false)
+	if errors.Is(err, l1pricing.ErrNotExist) {
+		return big.NewInt(0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return posterInfo.TxBaseFee()
 }
 
 // SetTxBaseFee sets an aggregator's fixed fee (caller must be the aggregator, its fee collector, or an owner)
 func (con ArbAggregator) SetTxBaseFee(c ctx, evm mech, aggregator addr, feeInL1Gas huge) error {
-	// This is deprecated and is now a no-op.
-	return nil
+	if c.State.ArbOSVersion() < 20 {
+		// This was deprecated and a no-op.
+		return nil
+	}
+	if feeInL1Gas.Sign() < 0 || feeInL1Gas.BitLen() > 256 {
+		return errors.New("tx base fee out of range")
+	}
+	posterInfo, err := c.State.L1PricingState().BatchPosterTable().OpenPoster(aggregator, false)
+	if err != nil {
+		return err
+	}
+	feeCollector, err := posterInfo.PayTo()
+	if err != nil {
+		return err
+	}
+	if c.caller != aggregator && c.caller != feeCollector {
+		isOwner, err := c.State.ChainOwners().IsMember(c.caller)
+		if err != nil {
+			return err
+		}
+		if !isOwner {
+			return errors.New("only an aggregator (or its fee collector / chain owner) may change its tx base fee")
+		}
+	}
+	return posterInfo.SetTxBaseFee(feeInL1Gas)
+}
+
+// GetLastBatchL1Block gets the L1 block the most recently reported batch was posted in, or 0 if there are none yet
+func (con ArbAggregator) GetLastBatchL1Block(c ctx, evm mech) (uint64, error) {
+	return c.State.L1PricingState().LastBatchL1Block()
 }
@@ -8,8 +8,11 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/util"
 )
 
 func TestArbAggregatorBatchPosters(t *testing.T) {
@@ -85,6 +88,8 @@ func TestTxBaseFee(t *testing.T) {
 	agg := ArbAggregator{}
 
 	aggAddr := common.BytesToAddress(crypto.Keccak256([]byte{0})[:20])
+	collectorAddr := common.BytesToAddress(crypto.Keccak256([]byte{1})[:20])
+	impostorAddr := common.BytesToAddress(crypto.Keccak256([]byte{2})[:20])
 	targetFee := big.NewInt(973)
 
 	aggCtx := testContext(aggAddr, evm)
@@ -97,15 +102,164 @@ func TestTxBaseFee(t *testing.T) {
 		Fail(t, fee)
 	}
 
-	// set base fee to value -- should be ignored
+	// before ArbOS 20, setting the base fee is ignored
 	if err := agg.SetTxBaseFee(aggCtx, evm, aggAddr, targetFee); err != nil {
 		Fail(t, err)
 	}
-
-	// base fee should still be zero
 	fee, err = agg.GetTxBaseFee(callerCtx, evm, aggAddr)
 	Require(t, err)
 	if fee.Cmp(big.NewInt(0)) != 0 {
 		Fail(t, fee)
 	}
+
+	callerCtx.State.SetFormatVersion(20)
+	aggCtx = testContext(aggAddr, evm)
+	callerCtx = testContext(common.Address{}, evm)
+	collectorCtx := testContext(collectorAddr, evm)
+	impostorCtx := testContext(impostorAddr, evm)
+	_, err = callerCtx.State.L1PricingState().BatchPosterTable().AddPoster(aggAddr, aggAddr)
+	Require(t, err)
+
+	// the aggregator can set its own base fee
+	Require(t, agg.SetTxBaseFee(aggCtx, evm, aggAddr, targetFee))
+	fee, err = agg.GetTxBaseFee(callerCtx, evm, aggAddr)
+	Require(t, err)
+	if fee.Cmp(targetFee) != 0 {
+		Fail(t, "set tx base fee", targetFee, "but read back", fee)
+	}
+
+	// and it's charged in wei at the current price per unit, to be paid to the aggregator's fee collector
+	pricing := callerCtx.State.L1PricingState()
+	price, err := pricing.PricePerUnit()
+	Require(t, err)
+	cost, payTo, err := pricing.TxBaseFeeCost(aggAddr)
+	Require(t, err)
+	if cost.Cmp(new(big.Int).Mul(price, targetFee)) != 0 || payTo != aggAddr {
+		Fail(t, "tx base fee", targetFee, "at price", price, "cost", cost, "paid to", payTo)
+	}
+	cost, _, err = pricing.TxBaseFeeCost(l1pricing.BatchPosterAddress)
+	Require(t, err)
+	if cost.Sign() != 0 {
+		Fail(t, "another aggregator's tx base fee was charged for the default poster", cost)
+	}
+
+	// someone else can't change it
+	if err := agg.SetTxBaseFee(impostorCtx, evm, aggAddr, big.NewInt(1)); err == nil {
+		Fail(t, "impostor changed the aggregator's tx base fee")
+	}
+	fee, err = agg.GetTxBaseFee(callerCtx, evm, aggAddr)
+	Require(t, err)
+	if fee.Cmp(targetFee) != 0 {
+		Fail(t, "rejected change altered the tx base fee to", fee)
+	}
+
+	// but the fee collector and chain owners can
+	Require(t, agg.SetFeeCollector(aggCtx, evm, aggAddr, collectorAddr))
+	Require(t, agg.SetTxBaseFee(collectorCtx, evm, aggAddr, big.NewInt(5)))
+	Require(t, ArbDebug{}.BecomeChainOwner(impostorCtx, evm))
+	Require(t, agg.SetTxBaseFee(impostorCtx, evm, aggAddr, big.NewInt(0)))
+	fee, err = agg.GetTxBaseFee(callerCtx, evm, aggAddr)
+	Require(t, err)
+	if fee.Sign() != 0 {
+		Fail(t, "owner didn't reset the tx base fee", fee)
+	}
+
+	// addresses that don't post batches have no base fee to set
+	fee, err = agg.GetTxBaseFee(callerCtx, evm, collectorAddr)
+	Require(t, err)
+	if fee.Sign() != 0 {
+		Fail(t, "non-poster has a tx base fee", fee)
+	}
+	if err := agg.SetTxBaseFee(collectorCtx, evm, collectorAddr, targetFee); err == nil {
+		Fail(t, "set a tx base fee for a non-poster")
+	}
+}
+
+func TestIsBatchPoster(t *testing.T) {
+	evm := newMockEVMForTesting()
+	agg := ArbAggregator{}
+	owner := ArbOwner{}
+	context := testContext(common.Address{}, evm)
+
+	poster := l1pricing.BatchPosterAddress
+	addr := common.BytesToAddress(crypto.Keccak256([]byte{1})[:20])
+
+	// the default batch poster is registered
+	isPoster, err := agg.IsBatchPoster(context, evm, poster)
+	Require(t, err)
+	if !isPoster {
+		Fail(t)
+	}
+
+	// a random address isn't
+	isPoster, err = agg.IsBatchPoster(context, evm, addr)
+	Require(t, err)
+	if isPoster {
+		Fail(t)
+	}
+
+	// toggle membership on and off, setting it twice each time to make sure that's a no-op
+	for _, want := range []bool{true, true, false, false} {
+		Require(t, owner.SetIsBatchPoster(context, evm, addr, want))
+		isPoster, err = agg.IsBatchPoster(context, evm, addr)
+		Require(t, err)
+		if isPoster != want {
+			Fail(t, "expected membership", want)
+		}
+	}
+
+	// a batch poster that's still owed funds can't be removed
+	Require(t, owner.SetIsBatchPoster(context, evm, addr, true))
+	posterInfo, err := context.State.L1PricingState().BatchPosterTable().OpenPoster(addr, false)
+	Require(t, err)
+	Require(t, posterInfo.SetFundsDue(big.NewInt(1)))
+	if err := owner.SetIsBatchPoster(context, evm, addr, false); err == nil {
+		Fail(t, "removed a batch poster that's owed funds")
+	}
+	isPoster, err = agg.IsBatchPoster(context, evm, addr)
+	Require(t, err)
+	if !isPoster {
+		Fail(t)
+	}
+}
+
+func TestGetLastBatchL1Block(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	agg := ArbAggregator{}
+	chainId := evm.ChainConfig().ChainID
+
+	// reportBatch delivers a batch posting report in the given L1 block
+	reportBatch := func(l1Block uint64, batchNum uint64) {
+		t.Helper()
+		Require(t, context.State.Blockhashes().RecordNewL1Block(l1Block-1, common.Hash{}, context.State.ArbOSVersion()))
+		data, err := util.PackInternalTxDataBatchPostingReport(
+			big.NewInt(int64(evm.Context.Time)), l1pricing.BatchPosterAddress, batchNum, uint64(10000), big.NewInt(1000000000),
+		)
+		Require(t, err)
+		Require(t, arbos.ApplyInternalTxUpdate(&types.ArbitrumInternalTx{ChainId: chainId, Data: data}, context.State, evm))
+	}
+	lastBatchL1Block := func() uint64 {
+		t.Helper()
+		block, err := agg.GetLastBatchL1Block(context, evm)
+		Require(t, err)
+		return block
+	}
+
+	// reports before ArbOS 20 aren't recorded
+	reportBatch(100, 0)
+	if block := lastBatchL1Block(); block != 0 {
+		Fail(t, "recorded a batch before ArbOS 20 at L1 block", block)
+	}
+
+	context.State.SetFormatVersion(20)
+	if block := lastBatchL1Block(); block != 0 {
+		Fail(t, "no batches have been reported, but got L1 block", block)
+	}
+	for batchNum, l1Block := range []uint64{120, 121, 150} {
+		reportBatch(l1Block, uint64(batchNum+1))
+		if block := lastBatchL1Block(); block != l1Block {
+			Fail(t, "batch posted in L1 block", l1Block, "was recorded at", block)
+		}
+	}
 }
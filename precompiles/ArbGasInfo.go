@@ -4,9 +4,11 @@
 package precompiles
 
 import (
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
 	"github.com/offchainlabs/nitro/arbos/storage"
@@ -139,6 +141,23 @@ func (con ArbGasInfo) GetPricesInArbGas(c ctx, evm mech) (huge, huge, huge, erro
 	return con.GetPricesInArbGasWithAggregator(c, evm, addr{})
 }
 
+// GetGasPricesInArbGas gets the base, congestion, and total components of the price of ArbGas, denominated in ArbGas.
+// These are in basis points of a unit of ArbGas, so the total is always one whole unit, and multiplying a component
+// by the basefee converts it back to the wei value GetPricesInWei reports, to within a basis point's rounding.
+// When the basefee is zero there's nothing to split, and each component is zero.
+func (con ArbGasInfo) GetGasPricesInArbGas(c ctx, evm mech) (huge, huge, huge, error) {
+	_, _, _, perArbGasBase, _, perArbGasTotal, err := con.GetPricesInWei(c, evm)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if perArbGasTotal.Sign() == 0 {
+		return common.Big0, common.Big0, common.Big0, nil
+	}
+	oneArbGas := big.NewInt(int64(arbmath.OneInBips))
+	base := arbmath.BigDiv(arbmath.BigMul(perArbGasBase, oneArbGas), perArbGasTotal)
+	return base, arbmath.BigSub(oneArbGas, base), oneArbGas, nil
+}
+
 // GetGasAccountingParams gets the rollup's speed limit, pool size, and tx gas limit
 func (con ArbGasInfo) GetGasAccountingParams(c ctx, evm mech) (huge, huge, huge, error) {
 	l2pricing := c.State.L2PricingState()
@@ -147,7 +166,8 @@ func (con ArbGasInfo) GetGasAccountingParams(c ctx, evm mech) (huge, huge, huge,
 	return arbmath.UintToBig(speedLimit), arbmath.UintToBig(maxTxGasLimit), arbmath.UintToBig(maxTxGasLimit), err
 }
 
-// GetMinimumGasPrice gets the minimum gas price needed for a transaction to succeed
+// GetMinimumGasPrice gets the minimum L2 basefee, which is the floor the basefee can't drop below no matter how idle
+// the chain is, and so the least gas price a transaction can pay and succeed
 func (con ArbGasInfo) GetMinimumGasPrice(c ctx, evm mech) (huge, error) {
 	return c.State.L2PricingState().MinBaseFeeWei()
 }
@@ -157,7 +177,24 @@ func (con ArbGasInfo) GetL1BaseFeeEstimate(c ctx, evm mech) (huge, error) {
 	return c.State.L1PricingState().PricePerUnit()
 }
 
-// GetL1BaseFeeEstimateInertia gets how slowly ArbOS updates its estimate of the L1 basefee
+// GetPricingParams gets what a fee quote needs in one call, so the values can't come from different blocks.
+// In order, these are the L2 basefee, the minimum L2 basefee, the L1 basefee estimate, and the price of a
+// byte of L1 calldata, each of which matches the value of its individual getter.
+func (con ArbGasInfo) GetPricingParams(c ctx, evm mech) (huge, huge, huge, huge, error) {
+	minBaseFee, err := con.GetMinimumGasPrice(c, evm)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	l1BaseFee, err := con.GetL1BaseFeeEstimate(c, evm)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	weiForL1Calldata := arbmath.BigMulByUint(l1BaseFee, params.TxDataNonZeroGasEIP2028)
+	return evm.Context.BaseFee, minBaseFee, l1BaseFee, weiForL1Calldata, nil
+}
+
+// GetL1BaseFeeEstimateInertia gets the L1 pricing inertia, which divides the equilibration units that damp each
+// update of the L1 basefee estimate. Smaller values make the estimate respond more slowly.
 func (con ArbGasInfo) GetL1BaseFeeEstimateInertia(c ctx, evm mech) (uint64, error) {
 	return c.State.L1PricingState().Inertia()
 }
@@ -182,11 +219,47 @@ func (con ArbGasInfo) GetCurrentTxL1GasFees(c ctx, evm mech) (huge, error) {
 	return c.txProcessor.PosterFee, nil
 }
 
+// GetCurrentL1DataFeePerByte gets the L1 data fee charged per byte of a tx, counted after brotli compression
+func (con ArbGasInfo) GetCurrentL1DataFeePerByte(c ctx, evm mech) (huge, error) {
+	pricePerUnit, err := c.State.L1PricingState().PricePerUnit()
+	if err != nil {
+		return nil, err
+	}
+	return arbmath.BigMulByUint(pricePerUnit, params.TxDataNonZeroGasEIP2028), nil
+}
+
 // GetGasBacklog gets the backlogged amount of gas burnt in excess of the speed limit
 func (con ArbGasInfo) GetGasBacklog(c ctx, evm mech) (uint64, error) {
 	return c.State.L2PricingState().GasBacklog()
 }
 
+// GetBrotliCompressionLevel gets the brotli compression level transactions are priced at, which batch
+// posters won't compress below
+func (con ArbGasInfo) GetBrotliCompressionLevel(c ctx, evm mech) (uint64, error) {
+	return c.State.BrotliCompressionLevel()
+}
+
+// GetCollectTips gets whether priority fees are collected rather than dropped
+func (con ArbGasInfo) GetCollectTips(c ctx, evm mech) (bool, error) {
+	return c.State.L2PricingState().CollectTips()
+}
+
+// GetSpeedLimitUsage gets the gas speed limit and the backlog of gas burnt in excess of it
+func (con ArbGasInfo) GetSpeedLimitUsage(c ctx, evm mech) (limit uint64, currentBacklog uint64, err error) {
+	l2pricing := c.State.L2PricingState()
+	limit, err = l2pricing.SpeedLimitPerSecond()
+	if err != nil {
+		return 0, 0, err
+	}
+	currentBacklog, err = l2pricing.GasBacklog()
+	return limit, currentBacklog, err
+}
+
+// GetBlockGasUsedSoFar gets the gas used by the current block's transactions before this one
+func (con ArbGasInfo) GetBlockGasUsedSoFar(c ctx, evm mech) (uint64, error) {
+	return c.State.L2PricingState().BlockGasUsed(evm.Context.BlockNumber.Uint64())
+}
+
 // GetPricingInertia gets the L2 basefee in response to backlogged gas
 func (con ArbGasInfo) GetPricingInertia(c ctx, evm mech) (uint64, error) {
 	return c.State.L2PricingState().PricingInertia()
@@ -244,3 +317,52 @@ func (con ArbGasInfo) GetAmortizedCostCapBips(c ctx, evm mech) (uint64, error) {
 func (con ArbGasInfo) GetL1FeesAvailable(c ctx, evm mech) (huge, error) {
 	return c.State.L1PricingState().L1FeesAvailable()
 }
+
+// GetL1PricingUpdateTime gets the current block's timestamp along with the L1 timestamp of the last batch posting
+// report, which is when the L1 base fee estimate was last updated. A stale estimate means batches aren't being posted.
+func (con ArbGasInfo) GetL1PricingUpdateTime(c ctx, evm mech) (l2BlockTime uint64, l1BaseFeeUpdateTime uint64, err error) {
+	l1BaseFeeUpdateTime, err = c.State.L1PricingState().LastUpdateTime()
+	return evm.Context.Time, l1BaseFeeUpdateTime, err
+}
+
+// GetPosterFundsDueByEpoch gets the funds that came due to the batch poster in each of the last numEpochs epochs,
+// starting with the current one. Epochs are FundsDueEpochSeconds long, and reported amounts ignore what's been paid.
+func (con ArbGasInfo) GetPosterFundsDueByEpoch(c ctx, evm mech, poster addr, numEpochs uint64) ([]huge, error) {
+	posterState, err := c.State.L1PricingState().BatchPosterTable().OpenPoster(poster, false)
+	if err != nil {
+		return nil, err
+	}
+	current := evm.Context.Time / l1pricing.FundsDueEpochSeconds
+	numEpochs = arbmath.MinInt(numEpochs, current+1)
+	accruals := make([]huge, numEpochs)
+	for i := range accruals {
+		accruals[i], err = posterState.AccruedInEpoch(current - uint64(i))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return accruals, nil
+}
+
+// GetGasEstimateForData estimates the L2 gas a call from the caller to the address would use, excluding intrinsic gas,
+// by running the call and reverting it. The simulation is paid for with this call's gas, which also bounds it along
+// with ArbOS's simulation gas limit, so the estimate is the gas to donate to a redeem that makes the same call.
+func (con ArbGasInfo) GetGasEstimateForData(c ctx, evm mech, to addr, data []byte) (uint64, error) {
+	limit, err := c.State.SimulationGasLimit()
+	if err != nil {
+		return 0, err
+	}
+	gas := arbmath.MinInt(arbmath.SaturatingUSub(c.gasLeft, c.returnDataCost), limit)
+
+	snapshot := evm.StateDB.Snapshot()
+	_, leftOver, err := evm.Call(vm.AccountRef(c.caller), to, data, gas, common.Big0)
+	evm.StateDB.RevertToSnapshot(snapshot)
+	gasUsed := gas - leftOver
+	if burnErr := c.Burn(gasUsed); burnErr != nil {
+		return 0, burnErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("simulated call to %v failed: %w", to, err)
+	}
+	return gasUsed, nil
+}
@@ -0,0 +1,544 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbcompress"
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+func TestGetCurrentTxL1GasFees(t *testing.T) {
+	gasInfo := ArbGasInfo{}
+	to := common.HexToAddress("0x06070809")
+	baseFee := big.NewInt(l2pricing.InitialBaseFeeWei)
+
+	// currentTxL1GasFees charges a tx with the given calldata, then reads back what the precompile reports
+	currentTxL1GasFees := func(calldata []byte) huge {
+		t.Helper()
+		evm := newMockEVMForTesting()
+		evm.Context.BaseFee = baseFee
+		evm.Context.Coinbase = l1pricing.BatchPosterAddress
+		tx := types.NewTx(&types.LegacyTx{
+			Nonce:    1,
+			GasPrice: baseFee,
+			Gas:      1000000,
+			To:       &to,
+			Value:    big.NewInt(0),
+			Data:     calldata,
+		})
+		msg := &core.Message{
+			Tx:        tx,
+			To:        &to,
+			GasLimit:  tx.Gas(),
+			GasPrice:  baseFee,
+			GasFeeCap: baseFee,
+			Value:     tx.Value(),
+			Data:      calldata,
+			TxRunMode: core.MessageCommitMode,
+		}
+		txProcessor := arbos.NewTxProcessor(evm, msg)
+		evm.ProcessingHook = txProcessor
+		gasRemaining := tx.Gas()
+		_, err := txProcessor.GasChargingHook(&gasRemaining)
+		Require(t, err)
+
+		context := testContext(common.Address{}, evm)
+		fees, err := gasInfo.GetCurrentTxL1GasFees(context, evm)
+		Require(t, err)
+
+		// the fee is whole units of gas at the basefee, bought to cover the l1pricing cost of the calldata
+		compressionLevel, err := context.State.BrotliCompressionLevel()
+		Require(t, err)
+		posterCost, _ := context.State.L1PricingState().GetPosterInfo(tx, l1pricing.BatchPosterAddress, compressionLevel)
+		posterGas := arbmath.BigDiv(posterCost, baseFee)
+		expected := arbmath.BigMul(posterGas, baseFee)
+		if !arbmath.BigEquals(fees, expected) {
+			Fail(t, "L1 gas fees", fees, "don't match l1pricing's", expected)
+		}
+		if tx.Gas()-gasRemaining != posterGas.Uint64() {
+			Fail(t, "gas charged", tx.Gas()-gasRemaining, "doesn't match the reported fees' gas", posterGas)
+		}
+		return fees
+	}
+
+	// use incompressible calldata so that it's sure to cost more to post
+	var calldata []byte
+	for i := byte(0); i < 8; i++ {
+		calldata = append(calldata, crypto.Keccak256([]byte{i})...)
+	}
+	smallFees := currentTxL1GasFees([]byte{})
+	largeFees := currentTxL1GasFees(calldata)
+	if smallFees.Sign() == 0 {
+		Fail(t, "tx wasn't charged for L1 data")
+	}
+	if !arbmath.BigLessThan(smallFees, largeFees) {
+		Fail(t, "more calldata didn't cost more", smallFees, largeFees)
+	}
+}
+
+func TestGetSpeedLimitUsage(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	gasInfo := ArbGasInfo{}
+	l2pricingState := context.State.L2PricingState()
+
+	limit, backlog, err := gasInfo.GetSpeedLimitUsage(context, evm)
+	Require(t, err)
+	if limit != l2pricing.InitialSpeedLimitPerSecondV6 {
+		Fail(t, "speed limit", limit, "doesn't match the configured", l2pricing.InitialSpeedLimitPerSecondV6)
+	}
+	if backlog != 0 {
+		Fail(t, "fresh chain has a backlog", backlog)
+	}
+
+	// a block burning more than a second's worth of gas should grow the backlog
+	heavyBlock := 3 * limit
+	for i := uint64(1); i <= 3; i++ {
+		Require(t, l2pricingState.AddToGasPool(-int64(heavyBlock)))
+		l2pricingState.UpdatePricingModel(nil, 1, false)
+		_, backlog, err = gasInfo.GetSpeedLimitUsage(context, evm)
+		Require(t, err)
+		if expected := i * (heavyBlock - limit); backlog != expected {
+			Fail(t, "backlog", backlog, "after", i, "heavy blocks, expected", expected)
+		}
+	}
+
+	Require(t, l2pricingState.SetSpeedLimitPerSecond(limit*2))
+	newLimit, _, err := gasInfo.GetSpeedLimitUsage(context, evm)
+	Require(t, err)
+	if newLimit != limit*2 {
+		Fail(t, "speed limit didn't follow the configuration", newLimit)
+	}
+}
+
+func TestBrotliCompressionLevel(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	gasInfo := ArbGasInfo{}
+	owner := ArbOwner{}
+
+	for _, level := range []uint64{0, 1, arbcompress.LEVEL_WELL} {
+		Require(t, owner.SetBrotliCompressionLevel(context, evm, level))
+		got, err := gasInfo.GetBrotliCompressionLevel(context, evm)
+		Require(t, err)
+		public, err := ArbOwnerPublic{}.GetBrotliCompressionLevel(context, evm)
+		Require(t, err)
+		if got != level || public != level {
+			Fail(t, "set brotli compression level", level, "but read back", got, public)
+		}
+	}
+
+	if err := owner.SetBrotliCompressionLevel(context, evm, arbcompress.LEVEL_WELL+1); err == nil {
+		Fail(t, "set an out of range brotli compression level")
+	}
+	got, err := gasInfo.GetBrotliCompressionLevel(context, evm)
+	Require(t, err)
+	if got != arbcompress.LEVEL_WELL {
+		Fail(t, "rejected level changed the brotli compression level to", got)
+	}
+}
+
+func TestL1RewardRateAndRecipient(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	gasInfo := ArbGasInfo{}
+	owner := ArbOwner{}
+
+	rate, err := gasInfo.GetL1RewardRate(context, evm)
+	Require(t, err)
+	if rate != l1pricing.InitialPerUnitReward {
+		Fail(t, "initial reward rate", rate, "isn't", l1pricing.InitialPerUnitReward)
+	}
+	for _, newRate := range []uint64{0, 1, 1 << 40} {
+		Require(t, owner.SetL1PricingRewardRate(context, evm, newRate))
+		rate, err := gasInfo.GetL1RewardRate(context, evm)
+		Require(t, err)
+		if rate != newRate {
+			Fail(t, "set reward rate", newRate, "but read back", rate)
+		}
+	}
+
+	initial, err := gasInfo.GetL1RewardRecipient(context, evm)
+	Require(t, err)
+	// the zero address is a valid recipient that's distinct from being unset
+	for _, recipient := range []common.Address{common.HexToAddress("0x0102030405"), {}, initial} {
+		Require(t, owner.SetL1PricingRewardRecipient(context, evm, recipient))
+		got, err := gasInfo.GetL1RewardRecipient(context, evm)
+		Require(t, err)
+		if got != recipient {
+			Fail(t, "set reward recipient", recipient, "but read back", got)
+		}
+	}
+}
+
+func TestGetMinimumGasPrice(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	gasInfo := ArbGasInfo{}
+	l2pricingState := context.State.L2PricingState()
+
+	minPrice := big.NewInt(l2pricing.InitialMinimumBaseFeeWei * 3)
+	Require(t, ArbOwner{}.SetMinimumL2BaseFee(context, evm, minPrice))
+	got, err := gasInfo.GetMinimumGasPrice(context, evm)
+	Require(t, err)
+	if !arbmath.BigEquals(got, minPrice) {
+		Fail(t, "minimum gas price", got, "doesn't track the owner's minimum basefee", minPrice)
+	}
+
+	// congest the chain so the basefee rises, then let it decay
+	limit, err := l2pricingState.SpeedLimitPerSecond()
+	Require(t, err)
+	Require(t, l2pricingState.AddToGasPool(-int64(limit*1000)))
+	l2pricingState.UpdatePricingModel(nil, 0, false)
+	baseFee, err := l2pricingState.BaseFeeWei()
+	Require(t, err)
+	if !arbmath.BigGreaterThan(baseFee, minPrice) {
+		Fail(t, "congestion didn't raise the basefee", baseFee)
+	}
+	for i := 0; i < 2000; i++ {
+		l2pricingState.UpdatePricingModel(nil, 1, false)
+		baseFee, err = l2pricingState.BaseFeeWei()
+		Require(t, err)
+		if arbmath.BigLessThan(baseFee, minPrice) {
+			Fail(t, "basefee", baseFee, "fell below the minimum gas price", minPrice)
+		}
+	}
+	if !arbmath.BigEquals(baseFee, minPrice) {
+		Fail(t, "basefee", baseFee, "didn't decay to the minimum gas price", minPrice)
+	}
+}
+
+func TestGetPricingParams(t *testing.T) {
+	evm := newMockEVMForTesting()
+	evm.Context.BaseFee = big.NewInt(l2pricing.InitialBaseFeeWei * 7)
+	context := testContext(common.Address{}, evm)
+	gasInfo := ArbGasInfo{}
+	Require(t, context.State.L1PricingState().SetPricePerUnit(big.NewInt(3000000000)))
+	Require(t, ArbOwner{}.SetMinimumL2BaseFee(context, evm, big.NewInt(l2pricing.InitialMinimumBaseFeeWei*2)))
+
+	baseFee, minBaseFee, l1BaseFee, perL1CalldataByte, err := gasInfo.GetPricingParams(context, evm)
+	Require(t, err)
+
+	minPrice, err := gasInfo.GetMinimumGasPrice(context, evm)
+	Require(t, err)
+	l1Estimate, err := gasInfo.GetL1BaseFeeEstimate(context, evm)
+	Require(t, err)
+	_, weiForL1Calldata, _, _, _, perArbGasTotal, err := gasInfo.GetPricesInWei(context, evm)
+	Require(t, err)
+
+	if !arbmath.BigEquals(baseFee, perArbGasTotal) || !arbmath.BigEquals(baseFee, evm.Context.BaseFee) {
+		Fail(t, "basefee", baseFee, "doesn't match the block's", evm.Context.BaseFee)
+	}
+	if !arbmath.BigEquals(minBaseFee, minPrice) {
+		Fail(t, "minimum basefee", minBaseFee, "doesn't match GetMinimumGasPrice", minPrice)
+	}
+	if !arbmath.BigEquals(l1BaseFee, l1Estimate) {
+		Fail(t, "L1 basefee", l1BaseFee, "doesn't match GetL1BaseFeeEstimate", l1Estimate)
+	}
+	if !arbmath.BigEquals(perL1CalldataByte, weiForL1Calldata) {
+		Fail(t, "L1 calldata price", perL1CalldataByte, "doesn't match GetPricesInWei", weiForL1Calldata)
+	}
+}
+
+func TestGetGasPricesInArbGas(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	gasInfo := ArbGasInfo{}
+	minBaseFee, err := context.State.L2PricingState().MinBaseFeeWei()
+	Require(t, err)
+
+	for _, baseFee := range []*big.Int{minBaseFee, arbmath.BigMulByUint(minBaseFee, 3), big.NewInt(123456789012)} {
+		evm.Context.BaseFee = baseFee
+		base, congestion, total, err := gasInfo.GetGasPricesInArbGas(context, evm)
+		Require(t, err)
+		_, _, _, weiBase, weiCongestion, weiTotal, err := gasInfo.GetPricesInWei(context, evm)
+		Require(t, err)
+
+		if total.Int64() != int64(arbmath.OneInBips) || !arbmath.BigEquals(arbmath.BigAdd(base, congestion), total) {
+			Fail(t, "components", base, "and", congestion, "don't add up to one ArbGas", total)
+		}
+		if !arbmath.BigEquals(arbmath.BigMulByBips(baseFee, arbmath.BigToBips(total)), weiTotal) {
+			Fail(t, "one ArbGas", total, "doesn't cost the total", weiTotal)
+		}
+
+		// converting back at the basefee recovers the wei values to within a basis point
+		tolerance := arbmath.BigAddByUint(arbmath.BigDivByUint(baseFee, uint64(arbmath.OneInBips)), 1)
+		check := func(name string, arbGas, wei huge) {
+			t.Helper()
+			converted := arbmath.BigMulByBips(baseFee, arbmath.BigToBips(arbGas))
+			if diff := arbmath.BigAbs(arbmath.BigSub(converted, wei)); arbmath.BigGreaterThan(diff, tolerance) {
+				Fail(t, name, arbGas, "converts to", converted, "wei instead of", wei, "at basefee", baseFee)
+			}
+		}
+		check("base", base, weiBase)
+		check("congestion", congestion, weiCongestion)
+	}
+
+	evm.Context.BaseFee = common.Big0
+	base, congestion, total, err := gasInfo.GetGasPricesInArbGas(context, evm)
+	Require(t, err)
+	if base.Sign() != 0 || congestion.Sign() != 0 || total.Sign() != 0 {
+		Fail(t, "free gas was split into", base, congestion, total)
+	}
+}
+
+func TestGetL1PricingUpdateTime(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	gasInfo := ArbGasInfo{}
+	l1p := context.State.L1PricingState()
+	poster := common.Address{3, 4, 5}
+	_, err := l1p.BatchPosterTable().AddPoster(poster, poster)
+	Require(t, err)
+
+	evm.Context.Time = 1000
+	l2Time, updateTime, err := gasInfo.GetL1PricingUpdateTime(context, evm)
+	Require(t, err)
+	if l2Time != evm.Context.Time || updateTime != 0 {
+		Fail(t, "unexpected times before any update", l2Time, updateTime)
+	}
+
+	// each batch posting report advances the update time to when the batch was posted
+	for _, posted := range []uint64{900, 990} {
+		Require(t, l1p.UpdateForBatchPosterSpending(
+			evm.StateDB, evm, context.State.ArbOSVersion(), posted, evm.Context.Time, poster,
+			big.NewInt(1000), big.NewInt(params.GWei), util.TracingDuringEVM,
+		))
+		l2Time, updateTime, err := gasInfo.GetL1PricingUpdateTime(context, evm)
+		Require(t, err)
+		if l2Time != evm.Context.Time || updateTime != posted {
+			Fail(t, "update at", posted, "reported as", updateTime, "in block at", l2Time)
+		}
+	}
+}
+
+func TestGetBlockGasUsedSoFar(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	gasInfo := ArbGasInfo{}
+	l2pricingState := context.State.L2PricingState()
+
+	used, err := gasInfo.GetBlockGasUsedSoFar(context, evm)
+	Require(t, err)
+	if used != 0 {
+		Fail(t, "fresh block already used", used, "gas")
+	}
+
+	// each finished tx adds its gas to the block's total
+	var expected uint64
+	for _, gas := range []uint64{21000, 100000, 53000} {
+		Require(t, l2pricingState.AddBlockGasUsed(evm.Context.BlockNumber.Uint64(), gas))
+		expected += gas
+		used, err := gasInfo.GetBlockGasUsedSoFar(context, evm)
+		Require(t, err)
+		if used != expected {
+			Fail(t, "block used", used, "gas instead of", expected)
+		}
+	}
+
+	// the next block starts counting over
+	evm.Context.BlockNumber = arbmath.BigAddByUint(evm.Context.BlockNumber, 1)
+	used, err = gasInfo.GetBlockGasUsedSoFar(context, evm)
+	Require(t, err)
+	if used != 0 {
+		Fail(t, "new block inherited", used, "gas")
+	}
+	Require(t, l2pricingState.AddBlockGasUsed(evm.Context.BlockNumber.Uint64(), 21000))
+	used, err = gasInfo.GetBlockGasUsedSoFar(context, evm)
+	Require(t, err)
+	if used != 21000 {
+		Fail(t, "new block used", used, "gas instead of", 21000)
+	}
+}
+
+func TestGetPosterFundsDueByEpoch(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	gasInfo := ArbGasInfo{}
+	l1p := context.State.L1PricingState()
+	poster := common.Address{3, 4, 5}
+	_, err := l1p.BatchPosterTable().AddPoster(poster, poster)
+	Require(t, err)
+
+	Require(t, l1p.SetAmortizedCostCapBips(0)) // report the full amounts
+	const epoch = l1pricing.FundsDueEpochSeconds
+	evm.Context.Time = 10 * epoch
+	report := func(postedAt uint64, wei int64) {
+		t.Helper()
+		Require(t, l1p.UpdateForBatchPosterSpending(
+			evm.StateDB, evm, context.State.ArbOSVersion(), postedAt, evm.Context.Time, poster,
+			big.NewInt(wei), big.NewInt(params.GWei), util.TracingDuringEVM,
+		))
+	}
+
+	// reports land in the epoch their batch was posted in, however much has been paid out since
+	report(7*epoch+10, 100)
+	report(7*epoch+500, 20)
+	report(9*epoch, 3)
+	report(10*epoch, 4000)
+	accruals, err := gasInfo.GetPosterFundsDueByEpoch(context, evm, poster, 5)
+	Require(t, err)
+	expected := []int64{4000, 3, 0, 120, 0}
+	if len(accruals) != len(expected) {
+		Fail(t, "got", len(accruals), "epochs instead of", len(expected))
+	}
+	for i, accrued := range accruals {
+		if !arbmath.BigEquals(accrued, big.NewInt(expected[i])) {
+			Fail(t, "epoch", 10-i, "accrued", accrued, "instead of", expected[i])
+		}
+	}
+
+	// there are no epochs before the first
+	accruals, err = gasInfo.GetPosterFundsDueByEpoch(context, evm, poster, 100)
+	Require(t, err)
+	if len(accruals) != 11 {
+		Fail(t, "got", len(accruals), "epochs since genesis instead of 11")
+	}
+
+	if _, err := gasInfo.GetPosterFundsDueByEpoch(context, evm, common.Address{9}, 1); err == nil {
+		Fail(t, "got epochs for an unknown poster")
+	}
+}
+
+func TestL1BaseFeeEstimateInertia(t *testing.T) {
+	poster := common.Address{3, 4, 5}
+
+	// response is how much the estimate moves for a single batch posted at a high L1 basefee
+	response := func(inertia uint64) *big.Int {
+		t.Helper()
+		evm := newMockEVMForTesting()
+		context := testContext(common.Address{}, evm)
+		context.State.SetFormatVersion(20)
+		l1p := context.State.L1PricingState()
+		_, err := l1p.BatchPosterTable().AddPoster(poster, poster)
+		Require(t, err)
+		Require(t, ArbOwner{}.SetL1BaseFeeEstimateInertia(context, evm, inertia))
+		got, err := ArbGasInfo{}.GetL1BaseFeeEstimateInertia(context, evm)
+		Require(t, err)
+		if got != inertia {
+			Fail(t, "set inertia", inertia, "but read back", got)
+		}
+
+		before, err := l1p.PricePerUnit()
+		Require(t, err)
+		const units = 100000
+		Require(t, l1p.AddToUnitsSinceUpdate(units))
+		evm.Context.Time = 100
+		spent := arbmath.BigMulByUint(arbmath.BigMulByUint(before, 1000), units)
+		Require(t, l1p.UpdateForBatchPosterSpending(
+			evm.StateDB, evm, context.State.ArbOSVersion(), evm.Context.Time, evm.Context.Time, poster,
+			spent, arbmath.BigMulByUint(before, 1000), util.TracingDuringEVM,
+		))
+		after, err := l1p.PricePerUnit()
+		Require(t, err)
+		return arbmath.BigSub(after, before)
+	}
+
+	// the equilibration units are divided by the inertia to damp each update,
+	// so it's a smaller inertia that makes the estimate respond less
+	slow, fast := response(2), response(200)
+	if slow.Sign() <= 0 || fast.Sign() <= 0 {
+		Fail(t, "estimate didn't rise after an expensive batch", slow, fast)
+	}
+	if slow.Cmp(fast) >= 0 {
+		Fail(t, "inertia 2 moved the estimate by", slow, "which isn't less than inertia 200's", fast)
+	}
+}
+
+func TestGetGasEstimateForData(t *testing.T) {
+	evm := newMockEVMForTesting()
+	evm.Context.CanTransfer, evm.Context.Transfer = core.CanTransfer, core.Transfer
+	caller := common.HexToAddress("0xca11e4")
+	context := testContext(caller, evm)
+	context.State.SetFormatVersion(20)
+	gasInfo := ArbGasInfo{}
+	target := common.HexToAddress("0xf0f0")
+	evm.StateDB.SetCode(target, []byte{0x60, 0x01, 0x60, 0x00, 0x55, 0x00}) // sstore(0, 1)
+
+	gasLeft := context.gasLeft
+	estimate, err := gasInfo.GetGasEstimateForData(context, evm, target, []byte{})
+	Require(t, err)
+	if evm.StateDB.GetState(target, common.Hash{}) != (common.Hash{}) {
+		Fail(t, "estimating the call didn't revert it")
+	}
+	if gasLeft-context.gasLeft != estimate {
+		Fail(t, "estimate of", estimate, "gas cost the caller", gasLeft-context.gasLeft)
+	}
+
+	// the estimate is what running the call for real uses
+	const gas = 1000000
+	_, leftOver, err := evm.Call(vm.AccountRef(caller), target, []byte{}, gas, common.Big0)
+	Require(t, err)
+	if gas-leftOver != estimate {
+		Fail(t, "call used", gas-leftOver, "gas but was estimated to use", estimate)
+	}
+	if evm.StateDB.GetState(target, common.Hash{}) != common.BigToHash(big.NewInt(1)) {
+		Fail(t, "call didn't run")
+	}
+
+	// a call that can't finish within the simulation gas limit has no estimate
+	evm.StateDB.SetCode(target, []byte{0x5b, 0x60, 0x00, 0x56}) // loop until out of gas
+	gasLeft = context.gasLeft
+	if _, err := gasInfo.GetGasEstimateForData(context, evm, target, []byte{}); err == nil {
+		Fail(t, "estimated a call that runs out of gas")
+	}
+	if gasLeft-context.gasLeft != arbosState.DefaultSimulationGasLimit {
+		Fail(t, "failed simulation cost", gasLeft-context.gasLeft, "gas")
+	}
+}
+
+func TestGetCurrentL1DataFeePerByte(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	l1p := context.State.L1PricingState()
+	Require(t, l1p.SetPricePerUnit(big.NewInt(3e9)))
+
+	// use incompressible calldata so that the tx's posted size is predictable
+	var calldata []byte
+	for i := byte(0); i < 8; i++ {
+		calldata = append(calldata, crypto.Keccak256([]byte{i})...)
+	}
+	to := common.HexToAddress("0x06070809")
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    1,
+		GasPrice: big.NewInt(l2pricing.InitialBaseFeeWei),
+		Gas:      1000000,
+		To:       &to,
+		Value:    big.NewInt(0),
+		Data:     calldata,
+	})
+	compressionLevel, err := context.State.BrotliCompressionLevel()
+	Require(t, err)
+	txBytes, err := tx.MarshalBinary()
+	Require(t, err)
+	compressed, err := arbcompress.CompressLevel(txBytes, int(compressionLevel))
+	Require(t, err)
+
+	posterCost, _ := l1p.GetPosterInfo(tx, l1pricing.BatchPosterAddress, compressionLevel)
+	expected := arbmath.BigDivByUint(posterCost, uint64(len(compressed)))
+	feePerByte, err := ArbGasInfo{}.GetCurrentL1DataFeePerByte(context, evm)
+	Require(t, err)
+	if !arbmath.BigEquals(feePerByte, expected) {
+		Fail(t, "L1 data fee per byte", feePerByte, "doesn't match the", posterCost, "charged for", len(compressed), "bytes")
+	}
+	if arbmath.BigMulByUint(feePerByte, uint64(len(compressed))).Cmp(posterCost) != 0 {
+		Fail(t, "the L1 data fee isn't a whole multiple of the fee per byte")
+	}
+}
@@ -21,6 +21,18 @@ func (con ArbInfo) GetBalance(c ctx, evm mech, account addr) (huge, error) {
 	return evm.StateDB.GetBalance(account), nil
 }
 
+// GetBalances retrieves the balance of each account, charging for each as GetBalance does
+func (con ArbInfo) GetBalances(c ctx, evm mech, accounts []addr) ([]huge, error) {
+	balances := make([]huge, len(accounts))
+	for i, account := range accounts {
+		if err := c.Burn(params.BalanceGasEIP1884); err != nil {
+			return nil, err
+		}
+		balances[i] = evm.StateDB.GetBalance(account)
+	}
+	return balances, nil
+}
+
 // GetCode retrieves a contract's deployed code
 func (con ArbInfo) GetCode(c ctx, evm mech, account addr) ([]byte, error) {
 	if err := c.Burn(params.ColdSloadCostEIP2929); err != nil {
@@ -0,0 +1,50 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+func TestGetBalances(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	info := ArbInfo{}
+
+	funded := common.HexToAddress("0x0102")
+	empty := common.HexToAddress("0x0304")
+	contract := common.HexToAddress("0x0506")
+	evm.StateDB.AddBalance(funded, big.NewInt(1e18))
+	evm.StateDB.SetCode(contract, []byte{0x00})
+	evm.StateDB.AddBalance(contract, big.NewInt(7))
+
+	accounts := []common.Address{funded, empty, contract, funded}
+	gasLeft := context.gasLeft
+	balances, err := info.GetBalances(context, evm, accounts)
+	Require(t, err)
+	if len(balances) != len(accounts) {
+		Fail(t, "got", len(balances), "balances for", len(accounts), "accounts")
+	}
+	for i, account := range accounts {
+		single, err := info.GetBalance(context, evm, account)
+		Require(t, err)
+		if !arbmath.BigEquals(balances[i], single) {
+			Fail(t, "batched balance of", account, "is", balances[i], "rather than", single)
+		}
+	}
+	if cost := gasLeft - context.gasLeft; cost != 2*uint64(len(accounts))*params.BalanceGasEIP1884 {
+		Fail(t, "looking up", len(accounts), "balances twice cost", cost, "gas")
+	}
+
+	// running out of gas partway through fails the lookup
+	context.gasLeft = 3*params.BalanceGasEIP1884 - 1
+	if _, err := info.GetBalances(context, evm, accounts); err == nil {
+		Fail(t, "looked up more balances than there was gas for")
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"math/big"
 
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/params"
@@ -24,6 +26,12 @@ type ArbOwner struct {
 	Address          addr // 0x70
 	OwnerActs        func(ctx, mech, bytes4, addr, []byte) error
 	OwnerActsGasCost func(bytes4, addr, []byte) (uint64, error)
+
+	L2BaseFeeSet        func(ctx, mech, huge) error
+	L2BaseFeeSetGasCost func(huge) (uint64, error)
+
+	L1PricingRewardRecipientChanged        func(ctx, mech, addr, addr) error
+	L1PricingRewardRecipientChangedGasCost func(addr, addr) (uint64, error)
 }
 
 var (
@@ -54,14 +62,35 @@ func (con ArbOwner) GetAllChainOwners(c ctx, evm mech) ([]common.Address, error)
 	return c.State.ChainOwners().AllMembers(65536)
 }
 
-// SetL1BaseFeeEstimateInertia sets how slowly ArbOS updates its estimate of the L1 basefee
+// SetL1BaseFeeEstimateInertia sets the L1 pricing inertia, which divides the equilibration units that damp each
+// update of the L1 basefee estimate. Smaller values make the estimate respond more slowly.
 func (con ArbOwner) SetL1BaseFeeEstimateInertia(c ctx, evm mech, inertia uint64) error {
 	return c.State.L1PricingState().SetInertia(inertia)
 }
 
-// SetL2BaseFee sets the L2 gas price directly, bypassing the pool calculus
+// UpdateL1BaseFeeEstimate nudges the L1 basefee estimate toward an observed L1 basefee, moving it 1/inertia of the way
+// there. Unlike SetL1PricePerUnit it doesn't override the estimate, so it's the gentler way to bootstrap L1 pricing.
+func (con ArbOwner) UpdateL1BaseFeeEstimate(c ctx, evm mech, observedL1BaseFee huge) error {
+	return c.State.L1PricingState().ObserveL1BaseFee(observedL1BaseFee)
+}
+
+// SetL2BaseFee sets the L2 gas price directly, bypassing the pool calculus. The next block is priced at it,
+// after which the pricing model takes over again. Since ArbOS 20 the price is raised to at least the minimum
+// basefee, and the price set is logged.
 func (con ArbOwner) SetL2BaseFee(c ctx, evm mech, priceInWei huge) error {
-	return c.State.L2PricingState().SetBaseFeeWei(priceInWei)
+	l2pricingState := c.State.L2PricingState()
+	if c.State.ArbOSVersion() < 20 {
+		return l2pricingState.SetBaseFeeWei(priceInWei)
+	}
+	minBaseFee, err := l2pricingState.MinBaseFeeWei()
+	if err != nil {
+		return err
+	}
+	baseFee := arbmath.BigMax(priceInWei, minBaseFee)
+	if err := l2pricingState.SetBaseFeeWei(baseFee); err != nil {
+		return err
+	}
+	return con.L2BaseFeeSet(c, evm, baseFee)
 }
 
 // SetMinimumL2BaseFee sets the minimum base fee needed for a transaction to succeed
@@ -74,6 +103,12 @@ func (con ArbOwner) SetSpeedLimit(c ctx, evm mech, limit uint64) error {
 	return c.State.L2PricingState().SetSpeedLimitPerSecond(limit)
 }
 
+// SetSpeedLimitPerSecond sets the computational speed limit for the chain, rescaling the gas backlog to match so
+// that the basefee doesn't jump
+func (con ArbOwner) SetSpeedLimitPerSecond(c ctx, evm mech, limit uint64) error {
+	return c.State.L2PricingState().RescaleSpeedLimitPerSecond(limit)
+}
+
 // SetMaxTxGasLimit sets the maximum size a tx (and block) can be
 func (con ArbOwner) SetMaxTxGasLimit(c ctx, evm mech, limit uint64) error {
 	return c.State.L2PricingState().SetMaxPerBlockGasLimit(limit)
@@ -122,8 +157,24 @@ func (con ArbOwner) SetL1PricingInertia(c ctx, evm mech, inertia uint64) error {
 	return c.State.L1PricingState().SetInertia(inertia)
 }
 
+// SetL1PricingRewardRecipient sets who the L1 pricing rewards are paid to. From ArbOS 20 the zero address is
+// rejected, and changes are logged with the previous recipient.
 func (con ArbOwner) SetL1PricingRewardRecipient(c ctx, evm mech, recipient addr) error {
-	return c.State.L1PricingState().SetPayRewardsTo(recipient)
+	l1p := c.State.L1PricingState()
+	if c.State.ArbOSVersion() < 20 {
+		return l1p.SetPayRewardsTo(recipient)
+	}
+	if recipient == (addr{}) {
+		return errors.New("L1 pricing rewards can't be paid to the zero address")
+	}
+	previous, err := l1p.PayRewardsTo()
+	if err != nil {
+		return err
+	}
+	if err := l1p.SetPayRewardsTo(recipient); err != nil {
+		return err
+	}
+	return con.L1PricingRewardRecipientChanged(c, evm, previous, recipient)
 }
 
 func (con ArbOwner) SetL1PricingRewardRate(c ctx, evm mech, weiPerUnit uint64) error {
@@ -146,7 +197,147 @@ func (con ArbOwner) SetBrotliCompressionLevel(c ctx, evm mech, level uint64) err
 	return c.State.SetBrotliCompressionLevel(level)
 }
 
+// SetMinimumKeepaliveCost sets the least gas a keepalive costs, so that small tickets can't be kept alive for free
+func (con ArbOwner) SetMinimumKeepaliveCost(c ctx, evm mech, cost uint64) error {
+	return c.State.RetryableState().SetMinKeepaliveCost(cost)
+}
+
+// SetCollectTips sets whether priority fees are paid to the infra fee account, or to the network fee account
+// if there isn't one, rather than being dropped
+func (con ArbOwner) SetCollectTips(c ctx, evm mech, collect bool) error {
+	return c.State.L2PricingState().SetCollectTips(collect)
+}
+
+// SetPrecompileMethodGas sets the minimum gas charged for calls to a precompile's method, or restores the default with 0.
+// While any method is overridden, calls to methods that don't donate their gas also pay a storage read for the lookup.
+func (con ArbOwner) SetPrecompileMethodGas(c ctx, evm mech, precompile addr, method [4]byte, gas uint64) error {
+	return c.State.SetPrecompileMethodGas(precompile, method, gas)
+}
+
+// SetRetryableCreationAllowlist sets whether only allowed senders may create retryables
+func (con ArbOwner) SetRetryableCreationAllowlist(c ctx, evm mech, enabled bool) error {
+	return c.State.RetryableState().SetCreatorAllowlistEnabled(enabled)
+}
+
+// AddRetryableCreator allows a sender to create retryables while the allowlist is enabled
+func (con ArbOwner) AddRetryableCreator(c ctx, evm mech, creator addr) error {
+	return c.State.RetryableState().Creators().Add(creator)
+}
+
+// RemoveRetryableCreator disallows a sender from creating retryables while the allowlist is enabled
+func (con ArbOwner) RemoveRetryableCreator(c ctx, evm mech, creator addr) error {
+	creators := c.State.RetryableState().Creators()
+	member, err := creators.IsMember(creator)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return errors.New("tried to remove a retryable creator that isn't allowed")
+	}
+	return creators.Remove(creator, c.State.ArbOSVersion())
+}
+
+// MigrateRetryableStorage upgrades the tickets to the current retryable storage layout, returning how many it changed
+func (con ArbOwner) MigrateRetryableStorage(c ctx, evm mech, ticketIds [][32]byte) (uint64, error) {
+	ids := make([]common.Hash, len(ticketIds))
+	for i, ticketId := range ticketIds {
+		ids[i] = ticketId
+	}
+	return c.State.RetryableState().MigrateRetryableStorage(ids)
+}
+
+// SetSimulationGasLimit sets the most gas NodeInterface methods and ArbGasInfo's gas estimates may give the
+// executions they simulate, or restores the default with 0
+func (con ArbOwner) SetSimulationGasLimit(c ctx, evm mech, limit uint64) error {
+	return c.State.SetSimulationGasLimit(limit)
+}
+
+// SetExpiryDeletionReward sets how much wei the network fee account pays per word of storage reclaimed by sweeping
+// expired retryables
+func (con ArbOwner) SetExpiryDeletionReward(c ctx, evm mech, weiPerWord huge) error {
+	return c.State.RetryableState().SetExpiryDeletionReward(weiPerWord)
+}
+
+// SetRetryableExpiryGracePeriod sets how many seconds after expiring a retryable can still be revived with Keepalive
+func (con ArbOwner) SetRetryableExpiryGracePeriod(c ctx, evm mech, seconds uint64) error {
+	return c.State.RetryableState().SetExpiryGracePeriod(seconds)
+}
+
+// SetMinRetryableDeposit sets the least a retryable's submission must deposit, where 0 removes the minimum
+func (con ArbOwner) SetMinRetryableDeposit(c ctx, evm mech, minimum huge) error {
+	return c.State.RetryableState().SetMinDeposit(minimum)
+}
+
+// SetRetryableMaxTries sets how many redeem attempts a retryable gets before it's deleted, where 0 removes the limit
+func (con ArbOwner) SetRetryableMaxTries(c ctx, evm mech, maxTries uint64) error {
+	return c.State.RetryableState().SetMaxTries(maxTries)
+}
+
+// SetMaxRetryablesPerBlock limits how many retryables may be created in each block, where 0 removes the limit
+func (con ArbOwner) SetMaxRetryablesPerBlock(c ctx, evm mech, max uint64) error {
+	return c.State.RetryableState().SetMaxRetryablesPerBlock(max)
+}
+
+// SetIsBatchPoster adds or removes account from the set of registered batch posters
+func (con ArbOwner) SetIsBatchPoster(c ctx, evm mech, batchPoster addr, isBatchPoster bool) error {
+	batchPosterTable := c.State.L1PricingState().BatchPosterTable()
+	wasBatchPoster, err := batchPosterTable.ContainsPoster(batchPoster)
+	if err != nil {
+		return err
+	}
+	if isBatchPoster && !wasBatchPoster {
+		_, err = batchPosterTable.AddPoster(batchPoster, batchPoster)
+		return err
+	}
+	if !isBatchPoster && wasBatchPoster {
+		return batchPosterTable.RemovePoster(batchPoster, c.State.ArbOSVersion())
+	}
+	return nil
+}
+
+// ReleaseL1PricerSurplusFunds sends up to maxWeiToRelease of the L1 pricer's surplus to the network fee account,
+// never touching what's owed to batch posters or the reward recipient, and returns the amount sent.
+// Before ArbOS 20, this instead recognized funds sent to the pool directly as L1 fees available.
 func (con ArbOwner) ReleaseL1PricerSurplusFunds(c ctx, evm mech, maxWeiToRelease huge) (huge, error) {
+	if c.State.ArbOSVersion() < 20 {
+		return con._preVersion20_ReleaseL1PricerSurplusFunds(c, evm, maxWeiToRelease)
+	}
+	if maxWeiToRelease.Sign() < 0 {
+		return nil, errors.New("cannot release a negative amount")
+	}
+	l1p := c.State.L1PricingState()
+	fundsDueForRefunds, err := l1p.BatchPosterTable().TotalFundsDue()
+	if err != nil {
+		return nil, err
+	}
+	fundsDueForRewards, err := l1p.FundsDueForRewards()
+	if err != nil {
+		return nil, err
+	}
+	networkFeeAccount, err := c.State.NetworkFeeAccount()
+	if err != nil {
+		return nil, err
+	}
+
+	// funds sent to the pool directly count toward the surplus too, so everything left behind is recognized
+	pool := l1pricing.L1PricerFundsPoolAddress
+	balance := evm.StateDB.GetBalance(pool)
+	surplus := arbmath.BigSub(balance, arbmath.BigAdd(fundsDueForRefunds, fundsDueForRewards))
+	if surplus.Sign() <= 0 {
+		return common.Big0, nil
+	}
+	weiToTransfer := arbmath.BigMin(surplus, maxWeiToRelease)
+	if err := l1p.SetL1FeesAvailable(arbmath.BigSub(balance, weiToTransfer)); err != nil {
+		return nil, err
+	}
+	err = util.TransferBalance(&pool, &networkFeeAccount, weiToTransfer, evm, util.TracingDuringEVM, "l1 pricer surplus")
+	if err != nil {
+		return nil, err
+	}
+	return weiToTransfer, nil
+}
+
+func (con ArbOwner) _preVersion20_ReleaseL1PricerSurplusFunds(c ctx, evm mech, maxWeiToRelease huge) (huge, error) {
 	balance := evm.StateDB.GetBalance(l1pricing.L1PricerFundsPoolAddress)
 	l1p := c.State.L1PricingState()
 	recognized, err := l1p.L1FeesAvailable()
@@ -166,6 +357,22 @@ func (con ArbOwner) ReleaseL1PricerSurplusFunds(c ctx, evm mech, maxWeiToRelease
 	return weiToTransfer, nil
 }
 
+// SetChainId sets the chain id in ArbOS's state, which is only allowed until the first block after genesis, since
+// changing it on a live chain would make transactions signed for one id replayable under the other
+func (con ArbOwner) SetChainId(c ctx, evm mech, chainId huge) error {
+	genesisBlockNum, err := c.State.GenesisBlockNum()
+	if err != nil {
+		return err
+	}
+	if evm.Context.BlockNumber.Uint64() > genesisBlockNum {
+		return fmt.Errorf("cannot set the chain id after genesis block %v", genesisBlockNum)
+	}
+	if chainId.Sign() <= 0 {
+		return errors.New("chain id must be positive")
+	}
+	return c.State.SetChainId(chainId)
+}
+
 func (con ArbOwner) SetChainConfig(c ctx, evm mech, serializedChainConfig []byte) error {
 	if c == nil {
 		return errors.New("nil context")
@@ -210,6 +417,9 @@ func (con ArbOwner) SetChainConfig(c ctx, evm mech, serializedChainConfig []byte
 		if err := currentConfig.CheckCompatible(&newConfig, evm.Context.BlockNumber.Uint64(), evm.Context.Time); err != nil {
 			return fmt.Errorf("invalid chain config, not compatible with EVM's chain config: %w", err)
 		}
+	} else if c.State.ArbOSVersion() >= 20 && !json.Valid(serializedChainConfig) {
+		// the full checks above only run when simulating, but malformed JSON is always rejected
+		return errors.New("invalid chain config, not valid JSON")
 	}
 	return c.State.SetChainConfig(serializedChainConfig)
 }
@@ -48,6 +48,11 @@ func (con ArbOwnerPublic) GetInfraFeeAccount(c ctx, evm mech) (addr, error) {
 	return c.State.InfraFeeAccount()
 }
 
+// GetMinimumKeepaliveCost gets the least gas a keepalive costs regardless of the ticket's size
+func (con ArbOwnerPublic) GetMinimumKeepaliveCost(c ctx, evm mech) (uint64, error) {
+	return c.State.RetryableState().MinKeepaliveCost()
+}
+
 // GetBrotliCompressionLevel gets the current brotli compression level used for fast compression
 func (con ArbOwnerPublic) GetBrotliCompressionLevel(c ctx, evm mech) (uint64, error) {
 	return c.State.BrotliCompressionLevel()
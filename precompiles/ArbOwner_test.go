@@ -11,6 +11,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
+	gethstate "github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
@@ -18,7 +19,10 @@ import (
 	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/burn"
 	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
 	"github.com/offchainlabs/nitro/arbos/util"
+	templates "github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/util/testhelpers"
 )
 
@@ -186,6 +190,67 @@ func TestArbOwnerSetChainConfig(t *testing.T) {
 	}
 }
 
+func TestArbOwnerChainConfigRoundTrip(t *testing.T) {
+	evm := newMockEVMForTestingWithVersionAndRunMode(nil, core.MessageGasEstimationMode)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	arbSys := &ArbSys{}
+
+	chainConfig := params.ArbitrumDevTestChainConfig()
+	chainConfig.ArbitrumChainParams.AllowDebugPrecompiles = false
+	serializedChainConfig, err := json.Marshal(chainConfig)
+	Require(t, err)
+	Require(t, prec.SetChainConfig(callCtx, evm, serializedChainConfig))
+
+	config, err := arbSys.GetChainConfig(callCtx, evm)
+	Require(t, err)
+	if !bytes.Equal(config, serializedChainConfig) {
+		Fail(t, config, serializedChainConfig)
+	}
+	var readBack params.ChainConfig
+	Require(t, json.Unmarshal(config, &readBack))
+	if readBack.ChainID.Cmp(chainConfig.ChainID) != 0 || readBack.ArbitrumChainParams.AllowDebugPrecompiles {
+		Fail(t, "chain config didn't round trip", readBack)
+	}
+}
+
+func TestArbOwnerSetChainConfigRejectsInvalidJSON(t *testing.T) {
+	invalid := [][]byte{
+		[]byte("{"),
+		[]byte("not json"),
+		[]byte(`{"chainId": }`),
+	}
+
+	// when simulating, the config is fully validated
+	evm := newMockEVMForTestingWithVersionAndRunMode(nil, core.MessageGasEstimationMode)
+	caller := common.BytesToAddress(crypto.Keccak256([]byte{})[:20])
+	callCtx := testContext(caller, evm)
+	prec := &ArbOwner{}
+	for _, config := range invalid {
+		if err := prec.SetChainConfig(callCtx, evm, config); err == nil {
+			Fail(t, "accepted invalid chain config when simulating", string(config))
+		}
+	}
+
+	// and malformed JSON is rejected on execution too
+	evm = newMockEVMForTestingWithVersionAndRunMode(nil, core.MessageCommitMode)
+	callCtx = testContext(caller, evm)
+	callCtx.State.SetFormatVersion(20)
+	before, err := callCtx.State.ChainConfig()
+	Require(t, err)
+	for _, config := range invalid {
+		if err := prec.SetChainConfig(callCtx, evm, config); err == nil {
+			Fail(t, "accepted invalid chain config on execution", string(config))
+		}
+	}
+	after, err := callCtx.State.ChainConfig()
+	Require(t, err)
+	if !bytes.Equal(before, after) {
+		Fail(t, "stored chain config was overwritten with invalid JSON", string(after))
+	}
+}
+
 func TestArbInfraFeeAccount(t *testing.T) {
 	version0 := uint64(0)
 	evm := newMockEVMForTestingWithVersion(&version0)
@@ -227,3 +292,357 @@ func TestArbInfraFeeAccount(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestReleaseL1PricerSurplusFunds(t *testing.T) {
+	version := uint64(20)
+	evm := newMockEVMForTestingWithVersion(&version)
+	callCtx := testContext(common.Address{}, evm)
+	prec := &ArbOwner{}
+	gasInfo := ArbGasInfo{}
+	l1p := callCtx.State.L1PricingState()
+	pool := l1pricing.L1PricerFundsPoolAddress
+	networkFeeAccount, err := callCtx.State.NetworkFeeAccount()
+	Require(t, err)
+
+	// the pool holds what it owes the poster and reward recipient, some surplus, and funds sent to it directly
+	dueToPoster := big.NewInt(300000)
+	dueForRewards := big.NewInt(200000)
+	recognized := big.NewInt(1000000)
+	unrecognized := big.NewInt(50000)
+	poster, err := l1p.BatchPosterTable().OpenPoster(l1pricing.BatchPosterAddress, false)
+	Require(t, err)
+	Require(t, poster.SetFundsDue(dueToPoster))
+	Require(t, l1p.SetFundsDueForRewards(dueForRewards))
+	Require(t, l1p.SetL1FeesAvailable(recognized))
+	evm.StateDB.AddBalance(pool, arbmath.BigAdd(recognized, unrecognized))
+	owed := arbmath.BigAdd(dueToPoster, dueForRewards)
+	surplus := arbmath.BigSub(arbmath.BigAdd(recognized, unrecognized), owed)
+
+	networkBefore := evm.StateDB.GetBalance(networkFeeAccount)
+	requested := big.NewInt(100000)
+	released, err := prec.ReleaseL1PricerSurplusFunds(callCtx, evm, requested)
+	Require(t, err)
+	if !arbmath.BigEquals(released, requested) {
+		Fail(t, "released", released, "instead of the requested", requested)
+	}
+	gotSurplus, err := gasInfo.GetL1PricingSurplus(callCtx, evm)
+	Require(t, err)
+	if !arbmath.BigEquals(gotSurplus, arbmath.BigSub(surplus, requested)) {
+		Fail(t, "surplus", gotSurplus, "didn't decrease by the amount released")
+	}
+
+	// asking for more than the surplus releases only the surplus
+	released, err = prec.ReleaseL1PricerSurplusFunds(callCtx, evm, arbmath.BigMul(surplus, big.NewInt(2)))
+	Require(t, err)
+	if !arbmath.BigEquals(released, arbmath.BigSub(surplus, requested)) {
+		Fail(t, "released", released, "which isn't the rest of the surplus")
+	}
+	released, err = prec.ReleaseL1PricerSurplusFunds(callCtx, evm, requested)
+	Require(t, err)
+	if released.Sign() != 0 {
+		Fail(t, "released", released, "with no surplus left")
+	}
+
+	// what's owed stays in the pool, and the rest went to the network
+	if balance := evm.StateDB.GetBalance(pool); !arbmath.BigEquals(balance, owed) {
+		Fail(t, "pool holds", balance, "instead of the", owed, "it owes")
+	}
+	available, err := l1p.L1FeesAvailable()
+	Require(t, err)
+	if !arbmath.BigEquals(available, owed) {
+		Fail(t, "L1 fees available", available, "don't match what's owed", owed)
+	}
+	gotDue, err := poster.FundsDue()
+	Require(t, err)
+	gotRewards, err := l1p.FundsDueForRewards()
+	Require(t, err)
+	if !arbmath.BigEquals(gotDue, dueToPoster) || !arbmath.BigEquals(gotRewards, dueForRewards) {
+		Fail(t, "funds owed changed to", gotDue, gotRewards)
+	}
+	networkGain := arbmath.BigSub(evm.StateDB.GetBalance(networkFeeAccount), networkBefore)
+	if !arbmath.BigEquals(networkGain, surplus) {
+		Fail(t, "network fee account got", networkGain, "instead of the surplus", surplus)
+	}
+}
+
+func TestSetL2BaseFee(t *testing.T) {
+	evm := newMockEVMForTesting()
+	state := testContext(common.Address{}, evm).State
+	state.SetFormatVersion(20)
+	l2pricingState := state.L2PricingState()
+	ownerABI, err := PrecompileABI(templates.ArbOwnerMetaData, "ArbOwner")
+	Require(t, err)
+	event := ownerABI.Events["L2BaseFeeSet"]
+	ownerAddress := common.HexToAddress("0x70")
+
+	// setL2BaseFee sets the basefee as the zero address, which is an owner by default, returning any logged price
+	setL2BaseFee := func(price *big.Int) *big.Int {
+		t.Helper()
+		input, err := ownerABI.Pack("setL2BaseFee", price)
+		Require(t, err)
+		statedb, ok := evm.StateDB.(*gethstate.StateDB)
+		if !ok {
+			Fail(t, "evm doesn't use a geth statedb")
+		}
+		logCount := len(statedb.Logs())
+		_, _, err = Precompiles()[ownerAddress].Call(input, ownerAddress, ownerAddress, common.Address{}, big.NewInt(0), false, 1000000, evm)
+		Require(t, err)
+		var logged *big.Int
+		for _, log := range statedb.Logs()[logCount:] {
+			if log.Topics[0] == event.ID {
+				values, err := event.Inputs.NonIndexed().Unpack(log.Data)
+				Require(t, err)
+				logged, _ = values[0].(*big.Int)
+			}
+		}
+		return logged
+	}
+	// the next block's header is priced at the stored basefee
+	nextBlockBaseFee := func() *big.Int {
+		t.Helper()
+		baseFee, err := l2pricingState.BaseFeeWei()
+		Require(t, err)
+		return baseFee
+	}
+
+	minBaseFee, err := l2pricingState.MinBaseFeeWei()
+	Require(t, err)
+	forced := arbmath.BigMulByUint(minBaseFee, 50)
+	logged := setL2BaseFee(forced)
+	if !arbmath.BigEquals(nextBlockBaseFee(), forced) {
+		Fail(t, "next block's basefee", nextBlockBaseFee(), "isn't the forced", forced)
+	}
+	if logged == nil || !arbmath.BigEquals(logged, forced) {
+		Fail(t, "logged basefee", logged, "instead of", forced)
+	}
+
+	// prices below the minimum are raised to it
+	logged = setL2BaseFee(arbmath.BigDivByUint(minBaseFee, 2))
+	if !arbmath.BigEquals(nextBlockBaseFee(), minBaseFee) {
+		Fail(t, "basefee", nextBlockBaseFee(), "was set below the minimum", minBaseFee)
+	}
+	if logged == nil || !arbmath.BigEquals(logged, minBaseFee) {
+		Fail(t, "logged basefee", logged, "instead of the minimum", minBaseFee)
+	}
+
+	// the pricing model takes over again afterward
+	setL2BaseFee(forced)
+	l2pricingState.UpdatePricingModel(nil, 1, false)
+	if !arbmath.BigEquals(nextBlockBaseFee(), minBaseFee) {
+		Fail(t, "an uncongested chain stayed at the forced basefee", nextBlockBaseFee())
+	}
+}
+
+func TestSetChainId(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	owner := ArbOwner{}
+
+	// ArbOS's chain id starts out as the chain config's, which ArbChainID reports
+	chainId, err := context.State.ChainId()
+	Require(t, err)
+	reported, err := (&ArbSys{}).ArbChainID(context, evm)
+	Require(t, err)
+	if !arbmath.BigEquals(chainId, evm.ChainConfig().ChainID) || !arbmath.BigEquals(reported, chainId) {
+		Fail(t, "ArbOS chain id", chainId, "and ArbChainID", reported, "don't match the chain config", evm.ChainConfig().ChainID)
+	}
+
+	// the id may be changed up to the genesis block
+	genesisBlockNum, err := context.State.GenesisBlockNum()
+	Require(t, err)
+	evm.Context.BlockNumber = arbmath.UintToBig(genesisBlockNum)
+	newChainId := big.NewInt(424242)
+	Require(t, owner.SetChainId(context, evm, newChainId))
+	chainId, err = context.State.ChainId()
+	Require(t, err)
+	if !arbmath.BigEquals(chainId, newChainId) {
+		Fail(t, "set chain id", newChainId, "but read back", chainId)
+	}
+	if err := owner.SetChainId(context, evm, common.Big0); err == nil {
+		Fail(t, "set a zero chain id")
+	}
+
+	// afterward it's fixed
+	evm.Context.BlockNumber = arbmath.UintToBig(genesisBlockNum + 1)
+	if err := owner.SetChainId(context, evm, big.NewInt(1)); err == nil {
+		Fail(t, "set the chain id after genesis")
+	}
+	chainId, err = context.State.ChainId()
+	Require(t, err)
+	if !arbmath.BigEquals(chainId, newChainId) {
+		Fail(t, "rejected change left the chain id at", chainId)
+	}
+}
+
+func TestSetExpiryDeletionReward(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	reward, err := context.State.RetryableState().ExpiryDeletionReward()
+	Require(t, err)
+	if reward.Sign() != 0 {
+		Fail(t, "default expiry deletion reward is", reward)
+	}
+	Require(t, ArbOwner{}.SetExpiryDeletionReward(context, evm, big.NewInt(params.GWei)))
+	reward, err = context.State.RetryableState().ExpiryDeletionReward()
+	Require(t, err)
+	if !arbmath.BigEquals(reward, big.NewInt(params.GWei)) {
+		Fail(t, "expiry deletion reward is", reward)
+	}
+}
+
+func TestSetSpeedLimitPerSecond(t *testing.T) {
+	// baseFeeAfter builds up a backlog, changes the speed limit, then reports the basefee before and after the change
+	baseFeeAfter := func(change func(ctx, mech, uint64) error, factor float64) (*big.Int, *big.Int) {
+		t.Helper()
+		evm := newMockEVMForTesting()
+		context := testContext(common.Address{}, evm)
+		context.State.SetFormatVersion(20)
+		l2pricingState := context.State.L2PricingState()
+		limit, err := l2pricingState.SpeedLimitPerSecond()
+		Require(t, err)
+		for i := 0; i < 20; i++ {
+			Require(t, l2pricingState.AddToGasPool(-int64(10*limit)))
+			l2pricingState.UpdatePricingModel(nil, 1, false)
+		}
+		before, err := l2pricingState.BaseFeeWei()
+		Require(t, err)
+		Require(t, change(context, evm, uint64(float64(limit)*factor)))
+		l2pricingState.UpdatePricingModel(nil, 0, false)
+		after, err := l2pricingState.BaseFeeWei()
+		Require(t, err)
+		return before, after
+	}
+	closeTo := func(a, b *big.Int) bool {
+		diff := arbmath.BigAbs(arbmath.BigSub(a, b))
+		return !arbmath.BigGreaterThan(arbmath.BigMulByUint(diff, 100), b) // within 1%
+	}
+	con := ArbOwner{}
+
+	for _, factor := range []float64{0.5, 2} {
+		before, after := baseFeeAfter(con.SetSpeedLimitPerSecond, factor)
+		if !arbmath.BigGreaterThan(before, big.NewInt(l2pricing.InitialMinimumBaseFeeWei)) {
+			Fail(t, "backlog didn't raise the basefee", before)
+		}
+		if !closeTo(after, before) {
+			Fail(t, "rescaling the speed limit by", factor, "moved the basefee from", before, "to", after)
+		}
+
+		// without rescaling the backlog the basefee jumps
+		before, after = baseFeeAfter(con.SetSpeedLimit, factor)
+		if closeTo(after, before) {
+			Fail(t, "changing the speed limit by", factor, "without rescaling left the basefee at", after)
+		}
+	}
+
+	evm := newMockEVMForTesting()
+	if err := con.SetSpeedLimitPerSecond(testContext(common.Address{}, evm), evm, 0); err == nil {
+		Fail(t, "set a zero speed limit")
+	}
+}
+
+func TestSetL1PricingRewardRecipient(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	ownerABI, err := PrecompileABI(templates.ArbOwnerMetaData, "ArbOwner")
+	Require(t, err)
+	event := ownerABI.Events["L1PricingRewardRecipientChanged"]
+	ownerAddress := common.HexToAddress("0x70")
+	statedb, ok := evm.StateDB.(*gethstate.StateDB)
+	if !ok {
+		Fail(t, "evm doesn't use a geth statedb")
+	}
+
+	// setRecipient sets the recipient as the zero address, which is an owner by default, returning any logged change
+	setRecipient := func(recipient common.Address) (*types.Log, error) {
+		t.Helper()
+		input, err := ownerABI.Pack("setL1PricingRewardRecipient", recipient)
+		Require(t, err)
+		logCount := len(statedb.Logs())
+		snapshot := statedb.Snapshot()
+		_, _, err = Precompiles()[ownerAddress].Call(input, ownerAddress, ownerAddress, common.Address{}, big.NewInt(0), false, 1000000, evm)
+		if err != nil {
+			statedb.RevertToSnapshot(snapshot)
+			return nil, err
+		}
+		for _, log := range statedb.Logs()[logCount:] {
+			if log.Topics[0] == event.ID {
+				return log, nil
+			}
+		}
+		return nil, nil
+	}
+
+	previous, err := context.State.L1PricingState().PayRewardsTo()
+	Require(t, err)
+	for _, recipient := range []common.Address{common.HexToAddress("0x0102030405"), common.HexToAddress("0x0607")} {
+		log, err := setRecipient(recipient)
+		Require(t, err)
+		if log == nil {
+			Fail(t, "changing the reward recipient wasn't logged")
+		}
+		if log.Topics[1] != common.BytesToHash(previous.Bytes()) || log.Topics[2] != common.BytesToHash(recipient.Bytes()) {
+			Fail(t, "logged a change from", log.Topics[1], "to", log.Topics[2], "rather than", previous, "to", recipient)
+		}
+		got, err := ArbGasInfo{}.GetL1RewardRecipient(context, evm)
+		Require(t, err)
+		if got != recipient {
+			Fail(t, "set reward recipient", recipient, "but read back", got)
+		}
+		previous = recipient
+	}
+
+	if _, err := setRecipient(common.Address{}); err == nil {
+		Fail(t, "set the reward recipient to the zero address")
+	}
+	got, err := ArbGasInfo{}.GetL1RewardRecipient(context, evm)
+	Require(t, err)
+	if got != previous {
+		Fail(t, "rejected recipient replaced", previous, "with", got)
+	}
+}
+
+func TestUpdateL1BaseFeeEstimate(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	l1p := context.State.L1PricingState()
+	owner := ArbOwner{}
+	const inertia = 10
+	Require(t, owner.SetL1BaseFeeEstimateInertia(context, evm, inertia))
+
+	distance := func(observed *big.Int) *big.Int {
+		t.Helper()
+		price, err := l1p.PricePerUnit()
+		Require(t, err)
+		return new(big.Int).Abs(arbmath.BigSub(price, observed))
+	}
+
+	// repeated observations close the gap a little each time, from either side
+	for _, observed := range []*big.Int{big.NewInt(1000 * params.GWei), big.NewInt(params.GWei / 10)} {
+		gap := distance(observed)
+		for i := 0; i < 500; i++ {
+			Require(t, owner.UpdateL1BaseFeeEstimate(context, evm, observed))
+			next := distance(observed)
+			if next.Cmp(gap) > 0 {
+				Fail(t, "observation", i, "of", observed, "moved the estimate from", gap, "to", next, "away")
+			}
+			gap = next
+		}
+		if gap.Cmp(big.NewInt(inertia)) >= 0 {
+			Fail(t, "estimate stayed", gap, "away from", observed, "after repeated observations")
+		}
+	}
+
+	// an inertia of 1 takes the observation as is
+	Require(t, owner.SetL1BaseFeeEstimateInertia(context, evm, 1))
+	observed := big.NewInt(42 * params.GWei)
+	Require(t, owner.UpdateL1BaseFeeEstimate(context, evm, observed))
+	if distance(observed).Sign() != 0 {
+		Fail(t, "inertia of 1 didn't take the observation")
+	}
+	if err := owner.UpdateL1BaseFeeEstimate(context, evm, big.NewInt(-1)); err == nil {
+		Fail(t, "observed a negative L1 basefee")
+	}
+}
@@ -5,10 +5,12 @@ package precompiles
 
 import (
 	"errors"
+	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/offchainlabs/nitro/arbos/retryables"
@@ -27,13 +29,25 @@ type ArbRetryableTx struct {
 	LifetimeExtendedGasCost func(bytes32, huge) (uint64, error)
 	RedeemScheduledGasCost  func(bytes32, bytes32, uint64, uint64, addr, huge, huge) (uint64, error)
 	CanceledGasCost         func(bytes32) (uint64, error)
+	Expired                 func(ctx, mech, bytes32) error
+	ExpiredGasCost          func(bytes32) (uint64, error)
+
+	RetryableCreationRateLimited        func(ctx, mech, bytes32, uint64) error
+	RetryableCreationRateLimitedGasCost func(bytes32, uint64) (uint64, error)
+	BeneficiaryTransferred              func(ctx, mech, bytes32, addr, addr) error
+	BeneficiaryTransferredGasCost       func(bytes32, addr, addr) (uint64, error)
+	RedeemerApproved                    func(ctx, mech, bytes32, addr) error
+	RedeemerApprovedGasCost             func(bytes32, addr) (uint64, error)
 
 	// deprecated event
 	Redeemed        func(ctx, mech, bytes32) error
 	RedeemedGasCost func(bytes32) (uint64, error)
 
-	NoTicketWithIDError func() error
-	NotCallableError    func() error
+	NoTicketWithIDError          func() error
+	NotCallableError             func() error
+	RetryableFeeCapExceededError func(bytes32, huge, huge) error
+
+	arbSys *ArbSys // withdraws the escrow of tickets canceled to L1
 }
 
 var ErrSelfModifyingRetryable = errors.New("retryable cannot modify itself")
@@ -47,29 +61,140 @@ func (con ArbRetryableTx) oldNotFoundError(c ctx) error {
 
 // Redeem schedules an attempt to redeem the retryable, donating all of the call's gas to the redeem attempt
 func (con ArbRetryableTx) Redeem(c ctx, evm mech, ticketId bytes32) (bytes32, error) {
+	retryTxInner, futureGasCosts, err := con.prepareRedeem(c, evm, ticketId)
+	if retryTxInner == nil || err != nil {
+		return hash{}, err
+	}
+	gasToDonate, err := donateRemainingGas(c, futureGasCosts)
+	if err != nil {
+		return hash{}, err
+	}
+	return con.scheduleRetry(c, evm, ticketId, retryTxInner, gasToDonate, c.caller)
+}
+
+// RedeemAndForward schedules a redeem like Redeem, then calls forwardTo with forwardData as the caller.
+// Half of the gas left after the call's own costs is donated to the retry and the rest is reserved for the
+// forward call. The two aren't atomic: the retry only runs once this tx completes, so the forward call can't
+// depend on its outcome, and the retry can still fail after the forward call succeeds. If the forward call fails
+// the whole call reverts, and the redeem isn't scheduled.
+func (con ArbRetryableTx) RedeemAndForward(c ctx, evm mech, ticketId bytes32, forwardTo addr, forwardData []byte) (bytes32, error) {
+	retryTxInner, futureGasCosts, err := con.prepareRedeem(c, evm, ticketId)
+	if retryTxInner == nil || err != nil {
+		return hash{}, err
+	}
+	if c.gasLeft < futureGasCosts {
+		return hash{}, c.Burn(futureGasCosts) // this will error
+	}
+	gasToDonate := (c.gasLeft - futureGasCosts) / 2
+	if gasToDonate < params.TxGas {
+		return hash{}, errors.New("not enough gas to run redeem attempt")
+	}
+	retryTxHash, err := con.scheduleRetry(c, evm, ticketId, retryTxInner, gasToDonate, c.caller)
+	if err != nil {
+		return hash{}, err
+	}
+
+	// only the cost of returning the ticket id is left to cover
+	forwardGas := arbmath.SaturatingUSub(c.gasLeft, c.returnDataCost)
+	_, leftOver, err := evm.Call(vm.AccountRef(c.caller), forwardTo, forwardData, forwardGas, common.Big0)
+	if burnErr := c.Burn(forwardGas - leftOver); burnErr != nil {
+		return hash{}, burnErr
+	}
+	if err != nil {
+		return hash{}, fmt.Errorf("forward call to %v failed: %w", forwardTo, err)
+	}
+	return retryTxHash, nil
+}
+
+// donateRemainingGas is how much gas a redeem can donate while keeping reserve for the rest of the call.
+// If the reserve can't be covered, all remaining gas is burned and the call runs out of gas. If what would be
+// left to donate is too little to run the retry, the call fails without burning any more gas.
+func donateRemainingGas(c ctx, reserve uint64) (uint64, error) {
+	if c.gasLeft < reserve {
+		return 0, c.Burn(reserve) // this will error
+	}
+	gasToDonate := c.gasLeft - reserve
+	if gasToDonate < params.TxGas {
+		return 0, errors.New("not enough gas to run redeem attempt")
+	}
+	return gasToDonate, nil
+}
+
+// RedeemNoDonate schedules an attempt to redeem the retryable with exactly gasLimit gas, leaving the rest of the
+// call's gas to the caller
+func (con ArbRetryableTx) RedeemNoDonate(c ctx, evm mech, ticketId bytes32, gasLimit uint64) (bytes32, error) {
+	if gasLimit < params.TxGas {
+		return hash{}, errors.New("not enough gas to run redeem attempt")
+	}
+	retryTxInner, futureGasCosts, err := con.prepareRedeem(c, evm, ticketId)
+	if retryTxInner == nil || err != nil {
+		return hash{}, err
+	}
+	if needed := arbmath.SaturatingUAdd(futureGasCosts, gasLimit); c.gasLeft < needed {
+		return hash{}, c.Burn(needed) // this will error
+	}
+	return con.scheduleRetry(c, evm, ticketId, retryTxInner, gasLimit, c.caller)
+}
+
+// prepareRedeem charges for reading the retryable and makes its next retry, returning the gas that must be left
+// after donating to cover scheduling the retry and returning its id. If the basefee is above the ticket's fee cap,
+// the redeem reverts and no retry is made.
+func (con ArbRetryableTx) prepareRedeem(c ctx, evm mech, ticketId bytes32) (*types.ArbitrumRetryTx, uint64, error) {
 	if c.txProcessor.CurrentRetryable != nil && ticketId == *c.txProcessor.CurrentRetryable {
-		return bytes32{}, ErrSelfModifyingRetryable
+		return nil, 0, ErrSelfModifyingRetryable
 	}
 	retryableState := c.State.RetryableState()
 	byteCount, err := retryableState.RetryableSizeBytes(ticketId, evm.Context.Time)
 	if err != nil {
-		return hash{}, err
+		return nil, 0, err
 	}
 	writeBytes := arbmath.WordsForBytes(byteCount)
 	if err := c.Burn(params.SloadGas * writeBytes); err != nil {
-		return hash{}, err
+		return nil, 0, err
 	}
 
 	retryable, err := retryableState.OpenRetryable(ticketId, evm.Context.Time)
 	if err != nil {
-		return hash{}, err
+		return nil, 0, err
 	}
 	if retryable == nil {
-		return hash{}, con.oldNotFoundError(c)
+		return nil, 0, con.oldNotFoundError(c)
+	}
+	if c.State.ArbOSVersion() >= 20 {
+		redeemer, err := retryableState.ApprovedRedeemer(ticketId)
+		if err != nil {
+			return nil, 0, err
+		}
+		if redeemer != (addr{}) && c.caller != redeemer {
+			beneficiary, err := retryable.Beneficiary()
+			if err != nil {
+				return nil, 0, err
+			}
+			if c.caller != beneficiary {
+				return nil, 0, errors.New("only the beneficiary or approved redeemer may redeem the retryable")
+			}
+		}
+
+		// retries run in the block that schedules them, so the current basefee is what the retry would pay
+		maxFeePerGas, err := retryableState.MaxFeePerGas(ticketId)
+		if err != nil {
+			return nil, 0, err
+		}
+		if maxFeePerGas.Sign() > 0 && arbmath.BigGreaterThan(evm.Context.BaseFee, maxFeePerGas) {
+			return nil, 0, con.RetryableFeeCapExceededError(ticketId, evm.Context.BaseFee, maxFeePerGas)
+		}
+
+		exhausted, err := retryableState.TriesExhausted(retryable)
+		if err != nil {
+			return nil, 0, err
+		}
+		if exhausted {
+			return nil, 0, retryables.ErrTriesExhausted
+		}
 	}
 	nextNonce, err := retryable.IncrementNumTries()
 	if err != nil {
-		return hash{}, err
+		return nil, 0, err
 	}
 	nonce := nextNonce - 1
 
@@ -86,41 +211,51 @@ func (con ArbRetryableTx) Redeem(c ctx, evm mech, ticketId bytes32) (bytes32, er
 		common.Big0,
 	)
 	if err != nil {
-		return hash{}, err
+		return nil, 0, err
 	}
 
 	// figure out how much gas the event issuance will cost, and reduce the donated gas amount in the event
 	//     by that much, so that we'll donate the correct amount of gas
 	eventCost, err := con.RedeemScheduledGasCost(hash{}, hash{}, 0, 0, addr{}, common.Big0, common.Big0)
 	if err != nil {
-		return hash{}, err
+		return nil, 0, err
 	}
-	// Result is 32 bytes long which is 1 word
-	gasCostToReturnResult := params.CopyGas
+	// the framework will charge this much to return the ticket id once we're done
+	gasCostToReturnResult := c.returnDataCost
 	gasPoolUpdateCost := storage.StorageReadCost + storage.StorageWriteCost
 	futureGasCosts := eventCost + gasCostToReturnResult + gasPoolUpdateCost
-	if c.gasLeft < futureGasCosts {
-		return hash{}, c.Burn(futureGasCosts) // this will error
-	}
-	gasToDonate := c.gasLeft - futureGasCosts
-	if gasToDonate < params.TxGas {
-		return hash{}, errors.New("not enough gas to run redeem attempt")
+	if c.State.ArbOSVersion() >= 20 {
+		// the retry is recorded when it's scheduled, before the donated gas is burned
+		futureGasCosts += retryables.RedeemTxRecordCost
 	}
+	return retryTxInner, futureGasCosts, nil
+}
 
+// scheduleRetry emits the RedeemScheduled event for the retry, then funds it with gas taken from this call
+func (con ArbRetryableTx) scheduleRetry(
+	c ctx, evm mech, ticketId bytes32, retryTxInner *types.ArbitrumRetryTx, gasToDonate uint64, donor addr,
+) (bytes32, error) {
 	// fix up the gas in the retry
 	retryTxInner.Gas = gasToDonate
 
 	retryTx := types.NewTx(retryTxInner)
 	retryTxHash := retryTx.Hash()
+	if c.State.ArbOSVersion() >= 20 {
+		if err := c.State.RetryableState().RecordRedeemTx(retryTxHash, ticketId, retryTxInner.Nonce); err != nil {
+			return hash{}, err
+		}
+	}
 
-	err = con.RedeemScheduled(c, evm, ticketId, retryTxHash, nonce, gasToDonate, c.caller, maxRefund, common.Big0)
+	err := con.RedeemScheduled(
+		c, evm, ticketId, retryTxHash, retryTxInner.Nonce, gasToDonate, donor, retryTxInner.MaxRefund, common.Big0,
+	)
 	if err != nil {
 		return hash{}, err
 	}
 
 	// To prepare for the enqueued retry event, we burn gas here, adding it back to the pool right before retrying.
 	// The gas payer for this tx will get a credit for the wei they paid for this gas when retrying.
-	// We burn as much gas as we can, leaving only enough to pay for copying out the return data.
+	// Redeem burns as much gas as it can here, leaving only enough to pay for copying out the return data.
 	if err := c.Burn(gasToDonate); err != nil {
 		return hash{}, err
 	}
@@ -130,11 +265,29 @@ func (con ArbRetryableTx) Redeem(c ctx, evm mech, ticketId bytes32) (bytes32, er
 	return retryTxHash, c.State.L2PricingState().AddToGasPool(arbmath.SaturatingCast(gasToDonate))
 }
 
+// GetTicketForRedeem gets the retryable whose sequenceNum'th redeem attempt is the tx with the given id,
+// reverting if no scheduled redeem matches both. Redeems are only recorded while their retryable exists.
+func (con ArbRetryableTx) GetTicketForRedeem(c ctx, evm mech, redeemTxId bytes32, sequenceNum uint64) (bytes32, error) {
+	ticketId, recordedSequenceNum, err := c.State.RetryableState().RedeemTxTicket(redeemTxId)
+	if err != nil {
+		return bytes32{}, err
+	}
+	if ticketId == (common.Hash{}) || recordedSequenceNum != sequenceNum {
+		return bytes32{}, con.NoTicketWithIDError()
+	}
+	return ticketId, nil
+}
+
 // GetLifetime gets the default lifetime period a retryable has at creation
 func (con ArbRetryableTx) GetLifetime(c ctx, evm mech) (huge, error) {
 	return big.NewInt(retryables.RetryableLifetimeSeconds), nil
 }
 
+// GetMaxLifetimeWindow gets the furthest past now that keepalives can ever push a ticket's timeout
+func (con ArbRetryableTx) GetMaxLifetimeWindow(c ctx, evm mech) (huge, error) {
+	return big.NewInt(retryables.RetryableMaxLifetimes * retryables.RetryableLifetimeSeconds), nil
+}
+
 // GetTimeout gets the timestamp for when ticket will expire
 func (con ArbRetryableTx) GetTimeout(c ctx, evm mech, ticketId bytes32) (huge, error) {
 	retryableState := c.State.RetryableState()
@@ -152,25 +305,118 @@ func (con ArbRetryableTx) GetTimeout(c ctx, evm mech, ticketId bytes32) (huge, e
 	return big.NewInt(int64(timeout)), nil
 }
 
+// GetSecondsUntilExpiry gets how long until the ticket expires, which is negative if it has expired but not yet been reaped
+func (con ArbRetryableTx) GetSecondsUntilExpiry(c ctx, evm mech, ticketId bytes32) (int64, error) {
+	timeout, err := c.State.RetryableState().TimeoutIncludingExpired(ticketId)
+	if err != nil {
+		return 0, err
+	}
+	if timeout == 0 {
+		return 0, con.NoTicketWithIDError()
+	}
+	return arbmath.SaturatingSub(arbmath.SaturatingCast(timeout), arbmath.SaturatingCast(evm.Context.Time)), nil
+}
+
+// CalculateTicketId gets the ticket id of a retryable the sender submits through the inbox as the given delayed message.
+// The parameters are those of the inbox's createRetryableTicket, along with the L1 basefee the message is delivered with.
+// Refund addresses the inbox aliases must be given as aliased.
+func (con ArbRetryableTx) CalculateTicketId(
+	c ctx,
+	evm mech,
+	sender addr,
+	messageNum uint64,
+	l1BaseFee huge,
+	deposit huge,
+	to addr,
+	l2CallValue huge,
+	maxSubmissionFee huge,
+	excessFeeRefundAddress addr,
+	callValueRefundAddress addr,
+	gasLimit uint64,
+	maxFeePerGas huge,
+	data []byte,
+) (bytes32, error) {
+	var retryTo *addr
+	if to != (addr{}) {
+		retryTo = &to
+	}
+	return retryables.InboxTicketId(
+		evm.ChainConfig().ChainID, messageNum, util.RemapL1Address(sender), l1BaseFee, deposit, retryTo, l2CallValue,
+		maxSubmissionFee, excessFeeRefundAddress, callValueRefundAddress, gasLimit, maxFeePerGas, data,
+	), nil
+}
+
+// GetL2SubmissionCount gets the number of retryables submitted from L2, which is the next submission's nonce
+func (con ArbRetryableTx) GetL2SubmissionCount(c ctx, evm mech) (uint64, error) {
+	return c.State.RetryableState().L2SubmissionCount()
+}
+
+// SweepExpired deletes expired tickets from the front of the timeout queue, processing up to maxEntries entries.
+// The caller is paid the expiry deletion reward for each word of storage reclaimed, as far as the network fee
+// account can afford it. Returns the reward paid.
+func (con ArbRetryableTx) SweepExpired(c ctx, evm mech, maxEntries uint64) (huge, error) {
+	words, err := c.State.RetryableState().SweepExpired(
+		evm.Context.Time, maxEntries, evm, util.TracingDuringEVM, c.State.ArbOSVersion(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	weiPerWord, err := c.State.RetryableState().ExpiryDeletionReward()
+	if err != nil {
+		return nil, err
+	}
+	networkFeeAccount, err := c.State.NetworkFeeAccount()
+	if err != nil {
+		return nil, err
+	}
+	reward := arbmath.BigMulByUint(weiPerWord, words)
+	reward = arbmath.BigMin(reward, evm.StateDB.GetBalance(networkFeeAccount))
+	if err := util.TransferBalance(&networkFeeAccount, &c.caller, reward, evm, util.TracingDuringEVM, "sweepReward"); err != nil {
+		return nil, err
+	}
+	return reward, nil
+}
+
 // Keepalive adds one lifetime period to the ticket's expiry
 func (con ArbRetryableTx) Keepalive(c ctx, evm mech, ticketId bytes32) (huge, error) {
+	retryableState := c.State.RetryableState()
+	currentTime := evm.Context.Time
+	if c.State.ArbOSVersion() >= 20 {
+		// a ticket in its grace period is revived rather than extended
+		expired, err := retryableState.OpenExpiredInGracePeriod(ticketId, currentTime)
+		if err != nil {
+			return nil, err
+		}
+		if expired != nil {
+			nbytes, err := expired.SizeBytes()
+			if err != nil {
+				return nil, err
+			}
+			if err := con.burnKeepaliveCost(c, nbytes); err != nil {
+				return big.NewInt(0), err
+			}
+			newTimeout, err := retryableState.Revive(expired)
+			if err != nil {
+				return big.NewInt(0), err
+			}
+			err = con.LifetimeExtended(c, evm, ticketId, new(big.Int).SetUint64(newTimeout))
+			return new(big.Int).SetUint64(newTimeout), err
+		}
+	}
 
 	// charge for the expiry update
-	retryableState := c.State.RetryableState()
-	nbytes, err := retryableState.RetryableSizeBytes(ticketId, evm.Context.Time)
+	nbytes, err := retryableState.RetryableSizeBytes(ticketId, currentTime)
 	if err != nil {
 		return nil, err
 	}
 	if nbytes == 0 {
 		return nil, con.oldNotFoundError(c)
 	}
-	updateCost := arbmath.WordsForBytes(nbytes) * params.SstoreSetGas / 100
-	if err := c.Burn(updateCost); err != nil {
+	if err := con.burnKeepaliveCost(c, nbytes); err != nil {
 		return big.NewInt(0), err
 	}
 
-	currentTime := evm.Context.Time
-	window := currentTime + retryables.RetryableLifetimeSeconds
+	window := currentTime + (retryables.RetryableMaxLifetimes-1)*retryables.RetryableLifetimeSeconds
 	newTimeout, err := retryableState.Keepalive(ticketId, currentTime, window, retryables.RetryableLifetimeSeconds)
 	if err != nil {
 		return big.NewInt(0), err
@@ -180,6 +426,34 @@ func (con ArbRetryableTx) Keepalive(c ctx, evm mech, ticketId bytes32) (huge, er
 	return big.NewInt(int64(newTimeout)), err
 }
 
+// burnKeepaliveCost charges for updating the expiry of a ticket of the given size
+func (con ArbRetryableTx) burnKeepaliveCost(c ctx, nbytes uint64) error {
+	updateCost := arbmath.WordsForBytes(nbytes) * params.SstoreSetGas / 100
+	if c.State.ArbOSVersion() >= 20 {
+		minCost, err := c.State.RetryableState().MinKeepaliveCost()
+		if err != nil {
+			return err
+		}
+		updateCost = arbmath.MaxInt(updateCost, minCost)
+	}
+	return c.Burn(updateCost)
+}
+
+// GetMinRetryableDeposit gets the least a retryable's submission must deposit
+func (con ArbRetryableTx) GetMinRetryableDeposit(c ctx, evm mech) (huge, error) {
+	return c.State.RetryableState().MinDeposit()
+}
+
+// GetMaxTries gets how many redeem attempts a retryable gets before it's deleted, where 0 means unlimited
+func (con ArbRetryableTx) GetMaxTries(c ctx, evm mech) (uint64, error) {
+	return c.State.RetryableState().MaxTries()
+}
+
+// GetExpiryGracePeriod gets how long after expiring a ticket can still be revived with Keepalive
+func (con ArbRetryableTx) GetExpiryGracePeriod(c ctx, evm mech) (uint64, error) {
+	return c.State.RetryableState().ExpiryGracePeriod()
+}
+
 // GetBeneficiary gets the beneficiary of the ticket
 func (con ArbRetryableTx) GetBeneficiary(c ctx, evm mech, ticketId bytes32) (addr, error) {
 	retryableState := c.State.RetryableState()
@@ -193,6 +467,54 @@ func (con ArbRetryableTx) GetBeneficiary(c ctx, evm mech, ticketId bytes32) (add
 	return retryable.Beneficiary()
 }
 
+// GetEscrowedCallValue gets the callvalue held in escrow for the ticket. A successful redeem delivers it to the
+// ticket's target, while canceling or expiring the ticket sends it to the beneficiary, after which it isn't found.
+func (con ArbRetryableTx) GetEscrowedCallValue(c ctx, evm mech, ticketId bytes32) (huge, error) {
+	retryable, err := c.State.RetryableState().OpenRetryable(ticketId, evm.Context.Time)
+	if err != nil {
+		return nil, err
+	}
+	if retryable == nil {
+		return nil, con.oldNotFoundError(c)
+	}
+	if err := c.Burn(params.BalanceGasEIP1884); err != nil {
+		return nil, err
+	}
+	return evm.StateDB.GetBalance(retryables.RetryableEscrowAddress(ticketId)), nil
+}
+
+// GetAutoRedeemResult gets whether the retryable's submission scheduled a redeem, whether that redeem succeeded,
+// and the id of its retry tx. The outcome is deleted along with the retryable, so a ticket that's been redeemed,
+// cancelled, or reaped reports that no auto-redeem was attempted.
+func (con ArbRetryableTx) GetAutoRedeemResult(c ctx, evm mech, ticketId bytes32) (bool, bool, bytes32, error) {
+	status, retryTxId, err := c.State.RetryableState().AutoRedeemResult(ticketId)
+	if err != nil {
+		return false, false, bytes32{}, err
+	}
+	attempted := status != retryables.AutoRedeemNotAttempted
+	return attempted, status == retryables.AutoRedeemSucceeded, retryTxId, nil
+}
+
+// GetPendingSubmissionRefund gets the submission fee the ticket's excess fee refund address is still owed, which is
+// refunded if the pending auto-redeem succeeds. This is 0 if there's none or the auto-redeem has already finished.
+func (con ArbRetryableTx) GetPendingSubmissionRefund(c ctx, evm mech, ticketId bytes32) (huge, error) {
+	return c.State.RetryableState().PendingSubmissionFeeRefund(ticketId)
+}
+
+// GetRetryablesByBeneficiary lists up to count of the tickets created for the beneficiary since ArbOS 20, starting
+// at the given position. Cancelling, redeeming, or reaping a ticket moves the beneficiary's last ticket into its place.
+func (con ArbRetryableTx) GetRetryablesByBeneficiary(c ctx, evm mech, beneficiary addr, start uint64, count uint64) ([][32]byte, error) {
+	tickets, err := c.State.RetryableState().RetryablesByBeneficiary(beneficiary, start, count)
+	if err != nil {
+		return nil, err
+	}
+	ticketIds := make([][32]byte, len(tickets))
+	for i, ticket := range tickets {
+		ticketIds[i] = ticket
+	}
+	return ticketIds, nil
+}
+
 // Cancel the ticket and refund its callvalue to its beneficiary
 func (con ArbRetryableTx) Cancel(c ctx, evm mech, ticketId bytes32) error {
 	if c.txProcessor.CurrentRetryable != nil && ticketId == *c.txProcessor.CurrentRetryable {
@@ -215,13 +537,83 @@ func (con ArbRetryableTx) Cancel(c ctx, evm mech, ticketId bytes32) error {
 	}
 
 	// no refunds are given for deleting retryables because they use rented space
-	_, err = retryableState.DeleteRetryable(ticketId, evm, util.TracingDuringEVM)
+	_, err = retryableState.DeleteRetryable(ticketId, evm, util.TracingDuringEVM, c.State.ArbOSVersion())
 	if err != nil {
 		return err
 	}
 	return con.Canceled(c, evm, ticketId)
 }
 
+// CancelAndWithdraw cancels the ticket like Cancel, then withdraws its escrowed call value to l1Destination
+// through the outbox, returning the send's leaf index. The send is made even if nothing was escrowed.
+func (con ArbRetryableTx) CancelAndWithdraw(c ctx, evm mech, ticketId bytes32, l1Destination addr) (huge, error) {
+	escrowAddress := retryables.RetryableEscrowAddress(ticketId)
+	escrowed := new(big.Int).Set(evm.StateDB.GetBalance(escrowAddress))
+	if err := con.Cancel(c, evm, ticketId); err != nil {
+		return nil, err
+	}
+
+	// canceling returned the escrow to the caller, who deposits it with ArbSys to be sent to L1 as their own withdrawal
+	if err := util.TransferBalance(&c.caller, &con.arbSys.Address, escrowed, evm, util.TracingDuringEVM, "withdraw"); err != nil {
+		return nil, err
+	}
+	return con.arbSys.SendTxToL1(c, evm, escrowed, l1Destination, nil)
+}
+
+// ApproveRedeemer lets the ticket's beneficiary restrict redeeming it to themselves and the redeemer.
+// Approving the zero address lets anyone redeem it again.
+func (con ArbRetryableTx) ApproveRedeemer(c ctx, evm mech, ticketId bytes32, redeemer addr) error {
+	if c.txProcessor.CurrentRetryable != nil && ticketId == *c.txProcessor.CurrentRetryable {
+		return ErrSelfModifyingRetryable
+	}
+	retryableState := c.State.RetryableState()
+	retryable, err := retryableState.OpenRetryable(ticketId, evm.Context.Time)
+	if err != nil {
+		return err
+	}
+	if retryable == nil {
+		return con.NoTicketWithIDError()
+	}
+	beneficiary, err := retryable.Beneficiary()
+	if err != nil {
+		return err
+	}
+	if c.caller != beneficiary {
+		return errors.New("only the beneficiary may approve a redeemer")
+	}
+	if err := retryableState.SetApprovedRedeemer(ticketId, redeemer); err != nil {
+		return err
+	}
+	return con.RedeemerApproved(c, evm, ticketId, redeemer)
+}
+
+// TransferBeneficiary lets the ticket's beneficiary make another address its beneficiary, moving any refunds
+// and the right to cancel it to that address
+func (con ArbRetryableTx) TransferBeneficiary(c ctx, evm mech, ticketId bytes32, newBeneficiary addr) error {
+	if c.txProcessor.CurrentRetryable != nil && ticketId == *c.txProcessor.CurrentRetryable {
+		return ErrSelfModifyingRetryable
+	}
+	retryableState := c.State.RetryableState()
+	retryable, err := retryableState.OpenRetryable(ticketId, evm.Context.Time)
+	if err != nil {
+		return err
+	}
+	if retryable == nil {
+		return con.NoTicketWithIDError()
+	}
+	beneficiary, err := retryable.Beneficiary()
+	if err != nil {
+		return err
+	}
+	if c.caller != beneficiary {
+		return errors.New("only the beneficiary may transfer a retryable")
+	}
+	if err := retryableState.TransferBeneficiary(ticketId, retryable, newBeneficiary); err != nil {
+		return err
+	}
+	return con.BeneficiaryTransferred(c, evm, ticketId, beneficiary, newBeneficiary)
+}
+
 func (con ArbRetryableTx) GetCurrentRedeemer(c ctx, evm mech) (common.Address, error) {
 	if c.txProcessor.CurrentRefundTo != nil {
 		return *c.txProcessor.CurrentRefundTo, nil
@@ -237,3 +629,130 @@ func (con ArbRetryableTx) SubmitRetryable(
 ) error {
 	return con.NotCallableError()
 }
+
+// SubmitRetryableFromL2 creates a retryable from within L2, applying the same checks as the L1 inbox.
+// The caller pays the submission fee and escrows the callvalue from its own balance, so no excess fee is taken
+// that would need refunding. If the caller supplies gasLimit on top of the gas the call needs, and maxFeePerGas
+// covers the current basefee, a redeem is scheduled just as an inbox submission auto-redeems.
+func (con ArbRetryableTx) SubmitRetryableFromL2(
+	c ctx, evm mech, to addr, l2CallValue huge, maxSubmissionCost huge,
+	excessFeeRefundAddress addr, callValueRefundAddress addr,
+	gasLimit uint64, maxFeePerGas huge, data []byte,
+) (bytes32, error) {
+	if len(data) > retryables.MaxL2SubmissionDataSize {
+		return hash{}, fmt.Errorf("retryable data too large: %v bytes exceeds the max of %v", len(data), retryables.MaxL2SubmissionDataSize)
+	}
+	if l2CallValue.Sign() < 0 || maxSubmissionCost.Sign() < 0 || maxFeePerGas.Sign() < 0 {
+		return hash{}, errors.New("retryable values cannot be negative")
+	}
+	// the inbox reserves these values for making gas estimation revert with the retryable's data
+	if gasLimit == 1 || arbmath.BigEquals(maxFeePerGas, common.Big1) {
+		return hash{}, errors.New("retryable gas limit and max fee per gas must not be 1")
+	}
+	if err := retryables.CheckTarget(&to); err != nil {
+		return hash{}, err
+	}
+	if err := c.State.RetryableState().CheckCreator(c.caller); err != nil {
+		return hash{}, err
+	}
+	// the call reverts, so unlike inbox submissions there's no event
+	if err := c.State.RetryableState().CheckCreationRateLimit(evm.Context.BlockNumber.Uint64()); err != nil {
+		return hash{}, err
+	}
+
+	l1BaseFee, err := c.State.L1PricingState().PricePerUnit()
+	if err != nil {
+		return hash{}, err
+	}
+	submissionFee := retryables.RetryableSubmissionFee(len(data), l1BaseFee)
+	if arbmath.BigLessThan(maxSubmissionCost, submissionFee) {
+		return hash{}, fmt.Errorf("insufficient submission fee: max %v but need %v", maxSubmissionCost, submissionFee)
+	}
+	// the deposit is what the caller pays in, the same as an inbox submission's deposit before gas
+	if err := c.State.RetryableState().CheckDeposit(arbmath.BigAdd(submissionFee, l2CallValue)); err != nil {
+		return hash{}, err
+	}
+	balance := evm.StateDB.GetBalance(c.caller)
+	if arbmath.BigLessThan(balance, arbmath.BigAdd(submissionFee, l2CallValue)) {
+		return hash{}, fmt.Errorf("insufficient funds: have %v but submission fee and callvalue need %v", balance, arbmath.BigAdd(submissionFee, l2CallValue))
+	}
+	networkFeeAccount, err := c.State.NetworkFeeAccount()
+	if err != nil {
+		return hash{}, err
+	}
+
+	retryableState := c.State.RetryableState()
+	ticketId, err := retryableState.NextL2SubmissionId(evm.ChainConfig().ChainID, c.caller)
+	if err != nil {
+		return hash{}, err
+	}
+	err = util.TransferBalance(&c.caller, &networkFeeAccount, submissionFee, evm, util.TracingDuringEVM, "submission fee")
+	if err != nil {
+		return hash{}, err
+	}
+	escrow := retryables.RetryableEscrowAddress(ticketId)
+	err = util.TransferBalance(&c.caller, &escrow, l2CallValue, evm, util.TracingDuringEVM, "escrow")
+	if err != nil {
+		return hash{}, err
+	}
+
+	var retryTo *addr
+	if to != (addr{}) {
+		retryTo = &to
+	}
+	timeout := evm.Context.Time + retryables.RetryableLifetimeSeconds
+	retryable, err := retryableState.CreateRetryable(
+		ticketId, timeout, c.caller, retryTo, l2CallValue, callValueRefundAddress, data,
+	)
+	if err != nil {
+		return hash{}, err
+	}
+	if err := retryableState.SetMaxFeePerGas(ticketId, maxFeePerGas); err != nil {
+		return hash{}, err
+	}
+	if err := retryableState.IndexBeneficiary(ticketId, callValueRefundAddress); err != nil {
+		return hash{}, err
+	}
+	if err := retryableState.RecordCreation(evm.Context.BlockNumber.Uint64()); err != nil {
+		return hash{}, err
+	}
+	if err := con.TicketCreated(c, evm, ticketId); err != nil {
+		return hash{}, err
+	}
+
+	if gasLimit < params.TxGas || arbmath.BigLessThan(maxFeePerGas, evm.Context.BaseFee) {
+		return ticketId, nil
+	}
+	eventCost, err := con.RedeemScheduledGasCost(hash{}, hash{}, 0, 0, addr{}, common.Big0, common.Big0)
+	if err != nil {
+		return hash{}, err
+	}
+	gasPoolUpdateCost := storage.StorageReadCost + storage.StorageWriteCost
+	autoRedeemRecordCost := 2 * storage.StorageWriteCost
+	futureGasCosts := eventCost + c.returnDataCost + gasPoolUpdateCost + retryables.RedeemTxRecordCost + autoRedeemRecordCost
+	if c.gasLeft < arbmath.SaturatingUAdd(futureGasCosts, gasLimit) {
+		return hash{}, c.Burn(arbmath.SaturatingUAdd(futureGasCosts, gasLimit)) // this will error
+	}
+	nextNonce, err := retryable.IncrementNumTries()
+	if err != nil {
+		return hash{}, err
+	}
+	retryTxInner, err := retryable.MakeTx(
+		evm.ChainConfig().ChainID,
+		nextNonce-1,
+		evm.Context.BaseFee,
+		0, // filled in when scheduling
+		ticketId,
+		excessFeeRefundAddress,
+		arbmath.BigMulByUint(maxFeePerGas, gasLimit),
+		common.Big0,
+	)
+	if err != nil {
+		return hash{}, err
+	}
+	retryTxHash, err := con.scheduleRetry(c, evm, ticketId, retryTxInner, gasLimit, c.caller)
+	if err != nil {
+		return hash{}, err
+	}
+	return ticketId, c.State.RetryableState().RecordAutoRedeem(ticketId, retryTxHash)
+}
@@ -4,12 +4,24 @@
 package precompiles
 
 import (
+	"bytes"
+	"errors"
 	"math/big"
 	"testing"
 
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+	"github.com/offchainlabs/nitro/arbos/retryables"
 	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	gethstate "github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
 	templates "github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 )
 
@@ -38,7 +50,7 @@ func TestRetryableRedeem(t *testing.T) {
 	)
 	Require(t, err)
 
-	retryABI, err := templates.ArbRetryableTxMetaData.GetAbi()
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
 	Require(t, err)
 	redeemCalldata, err := retryABI.Pack("redeem", id)
 	Require(t, err)
@@ -63,3 +75,1581 @@ func TestRetryableRedeem(t *testing.T) {
 		Fail(t, "didn't consume all the expected gas")
 	}
 }
+
+func TestSubmitRetryableFromL2(t *testing.T) {
+	evm := newMockEVMForTesting()
+	evm.Context.BaseFee = big.NewInt(l2pricing.InitialBaseFeeWei)
+	state := testContext(common.Address{}, evm).State
+	state.SetFormatVersion(20)
+
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	sender := common.HexToAddress("0x0708090a")
+	to := common.HexToAddress("0x06070809")
+	beneficiary := common.HexToAddress("0x0301040105090206")
+	callvalue := big.NewInt(1000)
+	calldata := []byte{1, 2, 3, 4}
+	evm.StateDB.AddBalance(sender, big.NewInt(params.Ether))
+
+	l1BaseFee, err := state.L1PricingState().PricePerUnit()
+	Require(t, err)
+	submissionFee := retryables.RetryableSubmissionFee(len(calldata), l1BaseFee)
+	networkFeeAccount, err := state.NetworkFeeAccount()
+	Require(t, err)
+	networkFeesBefore := evm.StateDB.GetBalance(networkFeeAccount)
+
+	call := func(caller addr, method string, args ...interface{}) ([]byte, error) {
+		t.Helper()
+		input, err := retryABI.Pack(method, args...)
+		Require(t, err)
+		output, _, err := Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, caller, big.NewInt(0), false, 1000000, evm,
+		)
+		return output, err
+	}
+	submit := func(maxSubmissionCost huge, gasLimit uint64, data []byte) (bytes32, error) {
+		t.Helper()
+		output, err := call(
+			sender, "submitRetryableFromL2", to, callvalue, maxSubmissionCost, sender, beneficiary, gasLimit,
+			evm.Context.BaseFee, data,
+		)
+		if err != nil {
+			return bytes32{}, err
+		}
+		result, err := retryABI.Unpack("submitRetryableFromL2", output)
+		Require(t, err)
+		return result[0].([32]byte), nil
+	}
+
+	// submissions must pay the fee and fit within the inbox's size limit
+	if _, err := submit(arbmath.BigSubByUint(submissionFee, 1), 0, calldata); err == nil {
+		Fail(t, "submitted a retryable without paying the submission fee")
+	}
+	if _, err := submit(submissionFee, 0, make([]byte, retryables.MaxL2SubmissionDataSize+1)); err == nil {
+		Fail(t, "submitted a retryable with too much data")
+	}
+
+	ticketId, err := submit(arbmath.BigMulByUint(submissionFee, 2), 0, calldata)
+	Require(t, err)
+	retryable, err := state.RetryableState().OpenRetryable(ticketId, evm.Context.Time)
+	Require(t, err)
+	if retryable == nil {
+		Fail(t, "retryable wasn't created")
+	}
+	from, err := retryable.From()
+	Require(t, err)
+	ticketBeneficiary, err := retryable.Beneficiary()
+	Require(t, err)
+	if from != sender || ticketBeneficiary != beneficiary {
+		Fail(t, "wrong retryable sender or beneficiary", from, ticketBeneficiary)
+	}
+	escrowed := evm.StateDB.GetBalance(retryables.RetryableEscrowAddress(ticketId))
+	if !arbmath.BigEquals(escrowed, callvalue) {
+		Fail(t, "wrong callvalue escrowed", escrowed)
+	}
+	// only the actual submission fee is taken, not the max
+	networkFees := arbmath.BigSub(evm.StateDB.GetBalance(networkFeeAccount), networkFeesBefore)
+	if !arbmath.BigEquals(networkFees, submissionFee) {
+		Fail(t, "wrong submission fee charged", networkFees, submissionFee)
+	}
+
+	// each submission gets its own ticket
+	otherTicketId, err := submit(submissionFee, 0, calldata)
+	Require(t, err)
+	if otherTicketId == ticketId {
+		Fail(t, "submissions share a ticket id")
+	}
+
+	_, err = call(sender, "redeem", ticketId)
+	Require(t, err)
+	tries, err := retryable.NumTries()
+	Require(t, err)
+	if tries != 1 {
+		Fail(t, "redeem wasn't scheduled", tries)
+	}
+
+	// supplying a gas limit schedules a redeem right away
+	autoRedeemedId, err := submit(submissionFee, 100000, calldata)
+	Require(t, err)
+	autoRedeemed, err := state.RetryableState().OpenRetryable(autoRedeemedId, evm.Context.Time)
+	Require(t, err)
+	tries, err = autoRedeemed.NumTries()
+	Require(t, err)
+	if tries != 1 {
+		Fail(t, "auto-redeem wasn't scheduled", tries)
+	}
+	attempted, succeeded, _, err := ArbRetryableTx{}.GetAutoRedeemResult(testContext(sender, evm), evm, autoRedeemedId)
+	Require(t, err)
+	if !attempted || succeeded {
+		Fail(t, "auto-redeem should be pending", attempted, succeeded)
+	}
+	attempted, _, _, err = ArbRetryableTx{}.GetAutoRedeemResult(testContext(sender, evm), evm, ticketId)
+	Require(t, err)
+	if attempted {
+		Fail(t, "manually redeemed ticket reports an auto-redeem")
+	}
+
+	// with the creator allowlist enabled, only allowed senders may submit
+	Require(t, state.RetryableState().SetCreatorAllowlistEnabled(true))
+	if _, err := submit(submissionFee, 0, calldata); err == nil {
+		Fail(t, "sender that isn't allowed submitted a retryable")
+	}
+	Require(t, state.RetryableState().Creators().Add(sender))
+	_, err = submit(submissionFee, 0, calldata)
+	Require(t, err)
+	Require(t, state.RetryableState().SetCreatorAllowlistEnabled(false))
+	Require(t, state.RetryableState().Creators().Remove(sender, state.ArbOSVersion()))
+	_, err = submit(submissionFee, 0, calldata)
+	Require(t, err)
+}
+
+func TestKeepaliveMinimumCost(t *testing.T) {
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	id := common.BigToHash(big.NewInt(978645611142))
+	const gasLimit = 10000000
+
+	// keepaliveGas returns the gas used keeping alive a fresh ticket, along with the ticket's size-based cost
+	keepaliveGas := func(calldataSize int, minCost uint64) (uint64, uint64) {
+		t.Helper()
+		evm := newMockEVMForTesting()
+		context := testContext(common.Address{}, evm)
+		context.State.SetFormatVersion(20)
+		Require(t, ArbOwner{}.SetMinimumKeepaliveCost(context, evm, minCost))
+		to := common.HexToAddress("0x06070809")
+		_, err := context.State.RetryableState().CreateRetryable(
+			id, evm.Context.Time+10000, common.HexToAddress("0x030405"), &to, big.NewInt(0), to, make([]byte, calldataSize),
+		)
+		Require(t, err)
+		nbytes, err := context.State.RetryableState().RetryableSizeBytes(id, evm.Context.Time)
+		Require(t, err)
+
+		input, err := retryABI.Pack("keepalive", id)
+		Require(t, err)
+		_, gasLeft, err := Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, common.Address{}, big.NewInt(0), false, gasLimit, evm,
+		)
+		Require(t, err)
+		return gasLimit - gasLeft, arbmath.WordsForBytes(nbytes) * params.SstoreSetGas / 100
+	}
+
+	// the floor applies to tiny tickets
+	const minCost = 100000
+	unfloored, sizeCost := keepaliveGas(0, 0)
+	floored, _ := keepaliveGas(0, minCost)
+	if sizeCost >= minCost {
+		Fail(t, "ticket isn't small enough to test the floor", sizeCost)
+	}
+	if floored-unfloored != minCost-sizeCost {
+		Fail(t, "floor wasn't applied", unfloored, floored)
+	}
+
+	// while the per-word cost still dominates for large ones
+	unfloored, sizeCost = keepaliveGas(100000, 0)
+	floored, _ = keepaliveGas(100000, minCost)
+	if sizeCost <= minCost {
+		Fail(t, "ticket isn't large enough to outweigh the floor", sizeCost)
+	}
+	if floored != unfloored {
+		Fail(t, "floor changed the cost of a large ticket", unfloored, floored)
+	}
+}
+
+func TestRetryableExpiredEvent(t *testing.T) {
+	evm := newMockEVMForTesting()
+	state := testContext(common.Address{}, evm).State
+	state.SetFormatVersion(20)
+	Precompiles() // installs the event hook used by the state transition
+
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	expiredTopic := retryABI.Events["Expired"].ID
+	statedb, ok := evm.StateDB.(*gethstate.StateDB)
+	if !ok {
+		Fail(t, "mock EVM doesn't use a geth statedb")
+	}
+
+	id := common.BigToHash(big.NewInt(978645611142))
+	timeout := evm.Context.Time + 1000
+	to := common.HexToAddress("0x06070809")
+	_, err = state.RetryableState().CreateRetryable(
+		id, timeout, common.HexToAddress("0x030405"), &to, big.NewInt(0), to, []byte{},
+	)
+	Require(t, err)
+
+	startBlock := func(time uint64) []*types.Log {
+		t.Helper()
+		evm.Context.Time = time
+		header := &types.Header{Number: big.NewInt(1), Time: time}
+		lastHeader := &types.Header{Number: big.NewInt(0), Time: time - 1}
+		tx := arbos.InternalTxStartBlock(evm.ChainConfig().ChainID, big.NewInt(0), 0, header, lastHeader)
+		logCount := len(statedb.Logs())
+		Require(t, arbos.ApplyInternalTxUpdate(tx, state, evm))
+		var expired []*types.Log
+		for _, log := range statedb.Logs()[logCount:] {
+			if log.Topics[0] == expiredTopic {
+				expired = append(expired, log)
+			}
+		}
+		return expired
+	}
+
+	// nothing expires before the timeout
+	if logs := startBlock(timeout); len(logs) != 0 {
+		Fail(t, "retryable expired early")
+	}
+
+	logs := startBlock(timeout + 1)
+	if len(logs) != 1 || logs[0].Topics[1] != id {
+		Fail(t, "expected one Expired event for the ticket, got", logs)
+	}
+	retryable, err := state.RetryableState().OpenRetryable(id, 0)
+	Require(t, err)
+	if retryable != nil {
+		Fail(t, "expired retryable wasn't deleted")
+	}
+
+	// the ticket is only reported once
+	if logs := startBlock(timeout + 2); len(logs) != 0 {
+		Fail(t, "expired retryable was reported again")
+	}
+}
+
+func TestKeepaliveGracePeriod(t *testing.T) {
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	id := common.BigToHash(big.NewInt(978645611142))
+	const grace = 1000
+
+	// setup creates a ticket that expired the given number of seconds ago
+	setup := func(expiredFor uint64) (*vm.EVM, *retryables.RetryableState) {
+		t.Helper()
+		evm := newMockEVMForTesting()
+		context := testContext(common.Address{}, evm)
+		context.State.SetFormatVersion(20)
+		Require(t, ArbOwner{}.SetRetryableExpiryGracePeriod(context, evm, grace))
+		evm.Context.Time = 1000000
+		to := common.HexToAddress("0x06070809")
+		_, err := context.State.RetryableState().CreateRetryable(
+			id, evm.Context.Time-expiredFor, common.HexToAddress("0x030405"), &to, big.NewInt(0), to, []byte{},
+		)
+		Require(t, err)
+		return evm, context.State.RetryableState()
+	}
+	keepalive := func(evm *vm.EVM) error {
+		input, err := retryABI.Pack("keepalive", id)
+		Require(t, err)
+		_, _, err = Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, common.Address{}, big.NewInt(0), false, 10000000, evm,
+		)
+		return err
+	}
+
+	// tickets in their grace period can't be redeemed and aren't reaped
+	evm, retryableState := setup(grace / 2)
+	retryable, err := retryableState.OpenRetryable(id, evm.Context.Time)
+	Require(t, err)
+	if retryable != nil {
+		Fail(t, "ticket in its grace period can be redeemed")
+	}
+	reaped, err := retryableState.ReapOneRetryable(evm.Context.Time, evm, util.TracingDuringEVM, 20)
+	Require(t, err)
+	if reaped != nil {
+		Fail(t, "ticket was reaped during its grace period")
+	}
+
+	// but can be revived, which makes them redeemable right away
+	Require(t, keepalive(evm))
+	retryable, err = retryableState.OpenRetryable(id, evm.Context.Time)
+	Require(t, err)
+	if retryable == nil {
+		Fail(t, "revived ticket can't be redeemed")
+	}
+	reaped, err = retryableState.ReapOneRetryable(evm.Context.Time, evm, util.TracingDuringEVM, 20)
+	Require(t, err)
+	if reaped != nil {
+		Fail(t, "revived ticket was reaped")
+	}
+	timeout, err := retryable.CalculateTimeout()
+	Require(t, err)
+	if timeout != evm.Context.Time-grace/2+retryables.RetryableLifetimeSeconds {
+		Fail(t, "revived ticket has the wrong timeout", timeout)
+	}
+
+	// once the grace period is over the ticket is gone for good
+	evm, retryableState = setup(grace + 1)
+	if err := keepalive(evm); err == nil {
+		Fail(t, "revived a ticket after its grace period")
+	}
+	reaped, err = retryableState.ReapOneRetryable(evm.Context.Time, evm, util.TracingDuringEVM, 20)
+	Require(t, err)
+	if reaped == nil || *reaped != id {
+		Fail(t, "ticket wasn't reaped after its grace period")
+	}
+
+	// tickets in their grace period don't hold up reaping the tickets behind them
+	evm, retryableState = setup(grace / 2)
+	expired := common.BigToHash(big.NewInt(978645611143))
+	_, err = retryableState.CreateRetryable(
+		expired, evm.Context.Time-grace-1, common.HexToAddress("0x030405"), nil, big.NewInt(0), common.Address{}, []byte{},
+	)
+	Require(t, err)
+	reaped, err = retryableState.ReapOneRetryable(evm.Context.Time, evm, util.TracingDuringEVM, 20)
+	Require(t, err)
+	if reaped != nil {
+		Fail(t, "ticket was reaped during its grace period")
+	}
+	reaped, err = retryableState.ReapOneRetryable(evm.Context.Time, evm, util.TracingDuringEVM, 20)
+	Require(t, err)
+	if reaped == nil || *reaped != expired {
+		Fail(t, "ticket behind one in its grace period wasn't reaped")
+	}
+	Require(t, keepalive(evm))
+
+	// the grace period must be shorter than a lifetime
+	context := testContext(common.Address{}, evm)
+	if err := (ArbOwner{}).SetRetryableExpiryGracePeriod(context, evm, retryables.RetryableLifetimeSeconds); err == nil {
+		Fail(t, "set a grace period as long as a lifetime")
+	}
+}
+
+func TestGetEscrowedCallValue(t *testing.T) {
+	evm := newMockEVMForTesting()
+	beneficiary := common.HexToAddress("0x030405")
+	context := testContext(beneficiary, evm)
+	context.State.SetFormatVersion(20)
+	retryableTx := ArbRetryableTx{}
+
+	id := common.BigToHash(big.NewInt(978645611142))
+	to := common.HexToAddress("0x06070809")
+	callvalue := big.NewInt(1000000)
+	_, err := context.State.RetryableState().CreateRetryable(
+		id, evm.Context.Time+10000, common.HexToAddress("0x0102"), &to, callvalue, beneficiary, []byte{},
+	)
+	Require(t, err)
+	evm.StateDB.AddBalance(retryables.RetryableEscrowAddress(id), callvalue)
+
+	escrowed, err := retryableTx.GetEscrowedCallValue(context, evm, id)
+	Require(t, err)
+	if !arbmath.BigEquals(escrowed, callvalue) {
+		Fail(t, "escrowed", escrowed, "instead of", callvalue)
+	}
+
+	// unknown and expired tickets aren't found
+	if _, err := retryableTx.GetEscrowedCallValue(context, evm, common.Hash{}); err == nil {
+		Fail(t, "got the escrow of a ticket that doesn't exist")
+	}
+	evm.Context.Time += 10001
+	if _, err := retryableTx.GetEscrowedCallValue(context, evm, id); err == nil {
+		Fail(t, "got the escrow of an expired ticket")
+	}
+	evm.Context.Time -= 10001
+
+	// canceling returns the escrow to the beneficiary
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	input, err := retryABI.Pack("cancel", id)
+	Require(t, err)
+	_, _, err = Precompiles()[types.ArbRetryableTxAddress].Call(
+		input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, beneficiary, big.NewInt(0), false, 1000000, evm,
+	)
+	Require(t, err)
+	if _, err := retryableTx.GetEscrowedCallValue(context, evm, id); err == nil {
+		Fail(t, "got the escrow of a canceled ticket")
+	}
+	if balance := evm.StateDB.GetBalance(retryables.RetryableEscrowAddress(id)); balance.Sign() != 0 {
+		Fail(t, "escrow wasn't emptied by canceling", balance)
+	}
+	if balance := evm.StateDB.GetBalance(beneficiary); !arbmath.BigEquals(balance, callvalue) {
+		Fail(t, "beneficiary has", balance, "instead of the escrowed", callvalue)
+	}
+}
+
+func TestRedeemNoDonate(t *testing.T) {
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	id := common.BigToHash(big.NewInt(978645611142))
+	const gasSupplied = 1000000
+	const retryGas = 100000
+
+	// redeemGas calls the method on a fresh ticket, returning the gas used and the gas given to the retry
+	redeemGas := func(method string, args ...interface{}) (uint64, uint64, error) {
+		t.Helper()
+		evm := newMockEVMForTesting()
+		evm.Context.BaseFee = big.NewInt(l2pricing.InitialBaseFeeWei)
+		context := testContext(common.Address{}, evm)
+		context.State.SetFormatVersion(20)
+		to := common.HexToAddress("0x06070809")
+		_, err := context.State.RetryableState().CreateRetryable(
+			id, evm.Context.Time+10000, common.HexToAddress("0x030405"), &to, big.NewInt(0), to, make([]byte, 42),
+		)
+		Require(t, err)
+
+		input, err := retryABI.Pack(method, append([]interface{}{id}, args...)...)
+		Require(t, err)
+		_, gasLeft, err := Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, common.Address{}, big.NewInt(0), false,
+			gasSupplied, evm,
+		)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		statedb, ok := evm.StateDB.(*gethstate.StateDB)
+		if !ok {
+			Fail(t, "evm doesn't use a geth statedb")
+		}
+		event := retryABI.Events["RedeemScheduled"]
+		var donated uint64
+		for _, log := range statedb.Logs() {
+			if log.Topics[0] == event.ID {
+				values, err := event.Inputs.NonIndexed().Unpack(log.Data)
+				Require(t, err)
+				donated, _ = values[0].(uint64)
+			}
+		}
+		return gasSupplied - gasLeft, donated, nil
+	}
+
+	redeemUsed, redeemDonated, err := redeemGas("redeem")
+	Require(t, err)
+	noDonateUsed, noDonateDonated, err := redeemGas("redeemNoDonate", uint64(retryGas))
+	Require(t, err)
+
+	if noDonateDonated != retryGas {
+		Fail(t, "redeemNoDonate gave the retry", noDonateDonated, "gas instead of", retryGas)
+	}
+	if noDonateUsed >= redeemUsed {
+		Fail(t, "redeemNoDonate didn't leave the caller any gas", noDonateUsed, redeemUsed)
+	}
+	if gasSupplied-redeemUsed > storage.StorageWriteCost {
+		Fail(t, "redeem left the caller gas", gasSupplied-redeemUsed)
+	}
+	// whatever wasn't donated went to the same overhead in both cases
+	if redeemUsed-redeemDonated != noDonateUsed-noDonateDonated {
+		Fail(t, "redeem's overhead", redeemUsed-redeemDonated, "differs from redeemNoDonate's", noDonateUsed-noDonateDonated)
+	}
+
+	if _, _, err := redeemGas("redeemNoDonate", params.TxGas-1); err == nil {
+		Fail(t, "scheduled a retry with too little gas")
+	}
+	if _, _, err := redeemGas("redeemNoDonate", uint64(gasSupplied)); err == nil {
+		Fail(t, "scheduled a retry with more gas than the call has")
+	}
+}
+
+func TestRedeemRecordsWithFiniteGas(t *testing.T) {
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	id := common.BigToHash(big.NewInt(978645611143))
+	const gasSupplied = 1000000
+
+	// recording the retry is paid for out of what redeem reserves, rather than the gas it donates
+	evm := newMockEVMForTesting()
+	evm.Context.BaseFee = big.NewInt(l2pricing.InitialBaseFeeWei)
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	to := common.HexToAddress("0x06070809")
+	_, err = context.State.RetryableState().CreateRetryable(
+		id, evm.Context.Time+10000, common.HexToAddress("0x030405"), &to, big.NewInt(0), to, make([]byte, 42),
+	)
+	Require(t, err)
+	input, err := retryABI.Pack("redeem", id)
+	Require(t, err)
+	output, gasLeft, err := Precompiles()[types.ArbRetryableTxAddress].Call(
+		input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, common.Address{}, big.NewInt(0), false,
+		gasSupplied, evm,
+	)
+	Require(t, err)
+	if gasLeft > storage.StorageWriteCost {
+		Fail(t, "redeem left the caller gas", gasLeft)
+	}
+	ticketId, sequenceNum, err := context.State.RetryableState().RedeemTxTicket(common.BytesToHash(output))
+	Require(t, err)
+	if ticketId != id || sequenceNum != 0 {
+		Fail(t, "redeem was recorded as attempt", sequenceNum, "of", ticketId)
+	}
+}
+
+func TestGetAutoRedeemResult(t *testing.T) {
+	evm := newMockEVMForTesting()
+	evm.Context.BaseFee = big.NewInt(l2pricing.InitialBaseFeeWei)
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	retryableState := context.State.RetryableState()
+	con := ArbRetryableTx{}
+
+	expect := func(ticketId common.Hash, attempted, succeeded bool, retryTxId common.Hash) {
+		t.Helper()
+		gotAttempted, gotSucceeded, gotRetryTxId, err := con.GetAutoRedeemResult(context, evm, ticketId)
+		Require(t, err)
+		if gotAttempted != attempted || gotSucceeded != succeeded || gotRetryTxId != retryTxId {
+			Fail(t, "unexpected auto-redeem result", gotAttempted, gotSucceeded, gotRetryTxId)
+		}
+	}
+
+	// tickets never submitted or submitted without an auto-redeem report that none was attempted
+	unknown := common.BigToHash(big.NewInt(31337))
+	expect(unknown, false, false, common.Hash{})
+	Require(t, retryableState.FinishAutoRedeem(unknown, common.Hash{}, true))
+	expect(unknown, false, false, common.Hash{})
+
+	// an auto-redeem is pending until its retry finishes, and other retries of the ticket don't count
+	succeeds := common.BigToHash(big.NewInt(1))
+	succeedsRetry := common.BigToHash(big.NewInt(2))
+	Require(t, retryableState.RecordAutoRedeem(succeeds, succeedsRetry))
+	expect(succeeds, true, false, succeedsRetry)
+	Require(t, retryableState.FinishAutoRedeem(succeeds, common.BigToHash(big.NewInt(3)), true))
+	expect(succeeds, true, false, succeedsRetry)
+	Require(t, retryableState.FinishAutoRedeem(succeeds, succeedsRetry, true))
+	expect(succeeds, true, true, succeedsRetry)
+
+	// a failed auto-redeem stays failed even if a later redeem succeeds
+	fails := common.BigToHash(big.NewInt(4))
+	failsRetry := common.BigToHash(big.NewInt(5))
+	Require(t, retryableState.RecordAutoRedeem(fails, failsRetry))
+	Require(t, retryableState.FinishAutoRedeem(fails, failsRetry, false))
+	expect(fails, true, false, failsRetry)
+	Require(t, retryableState.FinishAutoRedeem(fails, failsRetry, true))
+	expect(fails, true, false, failsRetry)
+}
+
+func TestGetPendingSubmissionRefund(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	retryableState := context.State.RetryableState()
+	con := ArbRetryableTx{}
+
+	expect := func(ticketId common.Hash, refund *big.Int) {
+		t.Helper()
+		got, err := con.GetPendingSubmissionRefund(context, evm, ticketId)
+		Require(t, err)
+		if !arbmath.BigEquals(got, refund) {
+			Fail(t, "pending submission refund", got, "instead of", refund)
+		}
+	}
+
+	// tickets submitted without an auto-redeem have nothing left to refund
+	none := common.BigToHash(big.NewInt(1))
+	expect(none, common.Big0)
+	Require(t, retryableState.RecordAutoRedeem(none, common.BigToHash(big.NewInt(2))))
+	expect(none, common.Big0)
+
+	// the fee is owed until the auto-redeem finishes, whether or not it succeeds
+	for i, success := range []bool{true, false} {
+		ticketId := common.BigToHash(big.NewInt(int64(10 + i)))
+		retryTxId := common.BigToHash(big.NewInt(int64(20 + i)))
+		submissionFee := big.NewInt(int64(1400000 + i))
+		Require(t, retryableState.RecordAutoRedeem(ticketId, retryTxId))
+		Require(t, retryableState.RecordSubmissionFeeRefund(ticketId, submissionFee))
+		expect(ticketId, submissionFee)
+		Require(t, retryableState.FinishAutoRedeem(ticketId, common.BigToHash(big.NewInt(3)), success))
+		expect(ticketId, submissionFee)
+		Require(t, retryableState.FinishAutoRedeem(ticketId, retryTxId, success))
+		expect(ticketId, common.Big0)
+	}
+}
+
+func TestGetSecondsUntilExpiry(t *testing.T) {
+	evm := newMockEVMForTesting()
+	state := testContext(common.Address{}, evm).State
+	state.SetFormatVersion(20)
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+
+	id := common.BigToHash(big.NewInt(978645611142))
+	evm.Context.Time = 1000
+	timeout := evm.Context.Time + 500
+	to := common.HexToAddress("0x06070809")
+	_, err = state.RetryableState().CreateRetryable(
+		id, timeout, common.HexToAddress("0x030405"), &to, big.NewInt(0), to, []byte{},
+	)
+	Require(t, err)
+
+	secondsUntilExpiry := func(ticketId common.Hash) (int64, error) {
+		t.Helper()
+		input, err := retryABI.Pack("getSecondsUntilExpiry", ticketId)
+		Require(t, err)
+		output, _, err := Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, common.Address{}, big.NewInt(0), false,
+			1000000, evm,
+		)
+		if err != nil {
+			return 0, err
+		}
+		result, err := retryABI.Unpack("getSecondsUntilExpiry", output)
+		Require(t, err)
+		return result[0].(int64), nil
+	}
+
+	for _, now := range []uint64{1000, 1499, 1500, 1501, 2000} {
+		evm.Context.Time = now
+		seconds, err := secondsUntilExpiry(id)
+		Require(t, err)
+		if expected := int64(timeout) - int64(now); seconds != expected {
+			Fail(t, "at time", now, "expected", expected, "seconds until expiry but got", seconds)
+		}
+	}
+
+	if _, err := secondsUntilExpiry(common.BigToHash(big.NewInt(31337))); err == nil {
+		Fail(t, "found a ticket that never existed")
+	}
+	_, err = state.RetryableState().DeleteRetryable(id, evm, util.TracingDuringEVM, state.ArbOSVersion())
+	Require(t, err)
+	if _, err := secondsUntilExpiry(id); err == nil {
+		Fail(t, "found a deleted ticket")
+	}
+}
+
+func TestL2SubmissionTicketId(t *testing.T) {
+	evm := newMockEVMForTesting()
+	evm.Context.BaseFee = big.NewInt(l2pricing.InitialBaseFeeWei)
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	con := ArbRetryableTx{}
+
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	ticketCreated := retryABI.Events["TicketCreated"].ID
+	senders := []common.Address{common.HexToAddress("0x0708090a"), common.HexToAddress("0x0b0c0d0e")}
+	to := common.HexToAddress("0x06070809")
+	for _, sender := range senders {
+		evm.StateDB.AddBalance(sender, big.NewInt(params.Ether))
+	}
+
+	// submissions from different senders share the nonce sequence, and each id is derived from its nonce
+	for i := 0; i < 4; i++ {
+		sender := senders[i%len(senders)]
+		nonce, err := con.GetL2SubmissionCount(context, evm)
+		Require(t, err)
+		if nonce != uint64(i) {
+			Fail(t, "submission", i, "has nonce", nonce)
+		}
+		expected := retryables.L2SubmissionTicketId(evm.ChainConfig().ChainID, sender, nonce)
+
+		input, err := retryABI.Pack(
+			"submitRetryableFromL2", to, big.NewInt(0), big.NewInt(1e16), sender, sender, uint64(0),
+			evm.Context.BaseFee, []byte{},
+		)
+		Require(t, err)
+		logsBefore := len(evm.StateDB.(*gethstate.StateDB).Logs())
+		_, _, err = Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, sender, big.NewInt(0), false, 1000000, evm,
+		)
+		Require(t, err)
+
+		var created []common.Hash
+		for _, log := range evm.StateDB.(*gethstate.StateDB).Logs()[logsBefore:] {
+			if log.Topics[0] == ticketCreated {
+				created = append(created, log.Topics[1])
+			}
+		}
+		if len(created) != 1 || created[0] != expected {
+			Fail(t, "computed ticket id", expected, "but TicketCreated had", created)
+		}
+	}
+}
+
+func TestMigrateRetryableStorage(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	retryableState := context.State.RetryableState()
+
+	// tickets created today have the legacy layout
+	to := common.HexToAddress("0x06070809")
+	var ticketIds [][32]byte
+	var calldatas [][]byte
+	for i := int64(1); i <= 3; i++ {
+		id := common.BigToHash(big.NewInt(i))
+		calldata := make([]byte, 40*i)
+		for j := range calldata {
+			calldata[j] = byte(j) + byte(i)
+		}
+		_, err := retryableState.CreateRetryable(
+			id, evm.Context.Time+1000, common.HexToAddress("0x030405"), &to, big.NewInt(i), to, calldata,
+		)
+		Require(t, err)
+		version, err := retryableState.LayoutVersion(id)
+		Require(t, err)
+		if version != 0 {
+			Fail(t, "new ticket has layout version", version)
+		}
+		ticketIds = append(ticketIds, id)
+		calldatas = append(calldatas, calldata)
+	}
+	missing := common.BigToHash(big.NewInt(31337))
+
+	// tickets that don't exist are skipped
+	migrated, err := ArbOwner{}.MigrateRetryableStorage(context, evm, append(ticketIds[:1:1], missing))
+	Require(t, err)
+	if migrated != 1 {
+		Fail(t, "migrated", migrated, "tickets instead of 1")
+	}
+	version, err := retryableState.LayoutVersion(missing)
+	Require(t, err)
+	if version != 0 {
+		Fail(t, "migration marked a missing ticket")
+	}
+
+	// the rest are migrated once, after which migrating them again does nothing
+	migrated, err = ArbOwner{}.MigrateRetryableStorage(context, evm, ticketIds)
+	Require(t, err)
+	if migrated != uint64(len(ticketIds)-1) {
+		Fail(t, "migrated", migrated, "tickets instead of", len(ticketIds)-1)
+	}
+	migrated, err = ArbOwner{}.MigrateRetryableStorage(context, evm, ticketIds)
+	Require(t, err)
+	if migrated != 0 {
+		Fail(t, "migrated", migrated, "tickets that were already current")
+	}
+
+	// migrated tickets read the same as they were created
+	for i, id := range ticketIds {
+		version, err := retryableState.LayoutVersion(id)
+		Require(t, err)
+		if version != retryables.RetryableLayoutVersion {
+			Fail(t, "ticket", i, "has layout version", version)
+		}
+		ticket, err := retryableState.OpenRetryable(id, evm.Context.Time)
+		Require(t, err)
+		calldata, err := ticket.Calldata()
+		Require(t, err)
+		callvalue, err := ticket.Callvalue()
+		Require(t, err)
+		timeout, err := ticket.CalculateTimeout()
+		Require(t, err)
+		ticketTo, err := ticket.To()
+		Require(t, err)
+		if !bytes.Equal(calldata, calldatas[i]) || callvalue.Int64() != int64(i+1) || timeout != evm.Context.Time+1000 || *ticketTo != to {
+			Fail(t, "ticket", i, "changed during migration")
+		}
+	}
+}
+
+func TestRedeemFeeCap(t *testing.T) {
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	id := common.BigToHash(big.NewInt(978645611142))
+	maxFeePerGas := big.NewInt(l2pricing.InitialBaseFeeWei * 2)
+
+	// redeemAt tries to redeem a fresh ticket with the fee cap at the given basefee
+	redeemAt := func(baseFee *big.Int) (*retryables.Retryable, []*types.Log, []byte, error) {
+		t.Helper()
+		evm := newMockEVMForTesting()
+		evm.Context.BaseFee = baseFee
+		context := testContext(common.Address{}, evm)
+		context.State.SetFormatVersion(20)
+		retryableState := context.State.RetryableState()
+		to := common.HexToAddress("0x06070809")
+		retryable, err := retryableState.CreateRetryable(
+			id, evm.Context.Time+10000, common.HexToAddress("0x030405"), &to, big.NewInt(0), to, make([]byte, 42),
+		)
+		Require(t, err)
+		Require(t, retryableState.SetMaxFeePerGas(id, maxFeePerGas))
+
+		input, err := retryABI.Pack("redeem", id)
+		Require(t, err)
+		output, _, err := Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, common.Address{}, big.NewInt(0), false,
+			1000000, evm,
+		)
+		return retryable, evm.StateDB.(*gethstate.StateDB).Logs(), output, err
+	}
+	countEvents := func(logs []*types.Log, event string) int {
+		count := 0
+		for _, log := range logs {
+			if log.Topics[0] == retryABI.Events[event].ID {
+				count++
+			}
+		}
+		return count
+	}
+
+	// at or under the cap the retry is scheduled as usual
+	for _, baseFee := range []*big.Int{big.NewInt(l2pricing.InitialBaseFeeWei), maxFeePerGas} {
+		retryable, logs, output, err := redeemAt(baseFee)
+		Require(t, err)
+		if countEvents(logs, "RedeemScheduled") != 1 {
+			Fail(t, "redeem at basefee", baseFee, "wasn't scheduled")
+		}
+		tries, err := retryable.NumTries()
+		Require(t, err)
+		if retryTxHash := common.BytesToHash(output); tries != 1 || retryTxHash == (common.Hash{}) {
+			Fail(t, "redeem at basefee", baseFee, "left", tries, "tries and returned", retryTxHash)
+		}
+	}
+
+	// above the cap the redeem reverts rather than overspending
+	baseFee := arbmath.BigAddByUint(maxFeePerGas, 1)
+	retryable, logs, output, err := redeemAt(baseFee)
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "redeem above the fee cap didn't revert", err)
+	}
+	if countEvents(logs, "RedeemScheduled") != 0 {
+		Fail(t, "redeem above the fee cap was scheduled")
+	}
+	values, err := retryABI.Errors["RetryableFeeCapExceeded"].Unpack(output)
+	Require(t, err)
+	args := values.([]interface{})
+	if args[0].([32]byte) != id || !arbmath.BigEquals(args[1].(*big.Int), baseFee) || !arbmath.BigEquals(args[2].(*big.Int), maxFeePerGas) {
+		Fail(t, "RetryableFeeCapExceeded reported", args)
+	}
+	tries, err := retryable.NumTries()
+	Require(t, err)
+	if tries != 0 {
+		Fail(t, "failed redeem left", tries, "tries")
+	}
+}
+
+func TestGetRetryablesByBeneficiary(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	retryableState := context.State.RetryableState()
+	con := ArbRetryableTx{}
+	alice := common.HexToAddress("0xa11ce")
+	bob := common.HexToAddress("0xb0b")
+	to := common.HexToAddress("0x06070809")
+
+	create := func(id int64, beneficiary common.Address, lifetime uint64) common.Hash {
+		t.Helper()
+		ticketId := common.BigToHash(big.NewInt(id))
+		_, err := retryableState.CreateRetryable(
+			ticketId, evm.Context.Time+lifetime, common.HexToAddress("0x030405"), &to, big.NewInt(0), beneficiary, []byte{},
+		)
+		Require(t, err)
+		Require(t, retryableState.IndexBeneficiary(ticketId, beneficiary))
+		return ticketId
+	}
+	list := func(beneficiary common.Address, start, count uint64) []common.Hash {
+		t.Helper()
+		ticketIds, err := con.GetRetryablesByBeneficiary(context, evm, beneficiary, start, count)
+		Require(t, err)
+		tickets := make([]common.Hash, len(ticketIds))
+		for i, ticketId := range ticketIds {
+			tickets[i] = ticketId
+		}
+		return tickets
+	}
+	expect := func(beneficiary common.Address, expected ...common.Hash) {
+		t.Helper()
+		tickets := list(beneficiary, 0, 100)
+		if len(tickets) != len(expected) {
+			Fail(t, "listed", tickets, "instead of", expected)
+		}
+		for i := range expected {
+			if tickets[i] != expected[i] {
+				Fail(t, "listed", tickets, "instead of", expected)
+			}
+		}
+	}
+
+	// bob's ticket is first in the timeout queue, so it can be reaped once it expires
+	bobs := create(100, bob, 10)
+	var alices []common.Hash
+	for i := int64(1); i <= 5; i++ {
+		alices = append(alices, create(i, alice, 10000))
+	}
+	expect(alice, alices...)
+	expect(bob, bobs)
+	expect(to)
+
+	// pages cover the list without gaps, and reading past the end is empty
+	var paged []common.Hash
+	for start := uint64(0); start < 6; start += 2 {
+		page := list(alice, start, 2)
+		if len(page) > 2 {
+			Fail(t, "page of 2 had", len(page), "tickets")
+		}
+		paged = append(paged, page...)
+	}
+	expect(alice, paged...)
+	if page := list(alice, 5, 10); len(page) != 0 {
+		Fail(t, "listed", page, "past the end")
+	}
+	if page := list(alice, 1, 0); len(page) != 0 {
+		Fail(t, "listed", page, "when asked for none")
+	}
+
+	// cancelling moves the last ticket into the cancelled one's place
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	input, err := retryABI.Pack("cancel", alices[1])
+	Require(t, err)
+	_, _, err = Precompiles()[types.ArbRetryableTxAddress].Call(
+		input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, alice, big.NewInt(0), false, 1000000, evm,
+	)
+	Require(t, err)
+	expect(alice, alices[0], alices[4], alices[2], alices[3])
+
+	// deleting the last ticket leaves the rest in place
+	_, err = retryableState.DeleteRetryable(alices[3], evm, util.TracingDuringEVM, context.State.ArbOSVersion())
+	Require(t, err)
+	expect(alice, alices[0], alices[4], alices[2])
+
+	// expired tickets stay listed until they're reaped
+	evm.Context.Time += 100
+	expect(bob, bobs)
+	reaped, err := retryableState.ReapOneRetryable(evm.Context.Time, evm, util.TracingDuringEVM, context.State.ArbOSVersion())
+	Require(t, err)
+	if reaped == nil || *reaped != bobs {
+		Fail(t, "reaped", reaped, "instead of bob's ticket")
+	}
+	expect(bob)
+	expect(alice, alices[0], alices[4], alices[2])
+
+	// transferring moves the ticket to the new beneficiary's list, and only the beneficiary may transfer it
+	transfer := func(caller common.Address, ticketId common.Hash, beneficiary common.Address) error {
+		t.Helper()
+		input, err := retryABI.Pack("transferBeneficiary", ticketId, beneficiary)
+		Require(t, err)
+		_, _, err = Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, caller, big.NewInt(0), false, 1000000, evm,
+		)
+		return err
+	}
+	if err := transfer(bob, alices[0], bob); err == nil {
+		Fail(t, "bob transferred alice's ticket")
+	}
+	Require(t, transfer(alice, alices[0], bob))
+	expect(alice, alices[2], alices[4])
+	expect(bob, alices[0])
+	ticket, err := retryableState.OpenRetryable(alices[0], evm.Context.Time)
+	Require(t, err)
+	beneficiary, err := ticket.Beneficiary()
+	Require(t, err)
+	if beneficiary != bob {
+		Fail(t, "transferred ticket has beneficiary", beneficiary)
+	}
+
+	// the new beneficiary can cancel it, which removes it from their list
+	if err := transfer(alice, alices[0], alice); err == nil {
+		Fail(t, "alice transferred a ticket after giving it away")
+	}
+	input, err = retryABI.Pack("cancel", alices[0])
+	Require(t, err)
+	_, _, err = Precompiles()[types.ArbRetryableTxAddress].Call(
+		input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, bob, big.NewInt(0), false, 1000000, evm,
+	)
+	Require(t, err)
+	expect(bob)
+	expect(alice, alices[2], alices[4])
+}
+
+func TestDonateRemainingGas(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	const reserve = uint64(30000)
+
+	// without enough gas for the reserve, the call runs out of gas rather than underflowing
+	context.gasLeft = reserve - 1
+	if _, err := donateRemainingGas(context, reserve); !errors.Is(err, vm.ErrOutOfGas) {
+		Fail(t, "expected running out of gas below the reserve, got", err)
+	}
+	if context.gasLeft != 0 {
+		Fail(t, "running out of gas left", context.gasLeft)
+	}
+
+	// with exactly the reserve, or too little on top of it to run a retry, nothing more is burned
+	for _, gasLeft := range []uint64{reserve, reserve + params.TxGas - 1} {
+		context.gasLeft = gasLeft
+		gasToDonate, err := donateRemainingGas(context, reserve)
+		if err == nil || errors.Is(err, vm.ErrOutOfGas) || gasToDonate != 0 {
+			Fail(t, "donated", gasToDonate, "with only", gasLeft, "gas left", err)
+		}
+		if context.gasLeft != gasLeft {
+			Fail(t, "burned gas without donating it", gasLeft-context.gasLeft)
+		}
+	}
+
+	// everything above the reserve is donated
+	for _, extra := range []uint64{params.TxGas, 1000000} {
+		context.gasLeft = reserve + extra
+		gasToDonate, err := donateRemainingGas(context, reserve)
+		Require(t, err)
+		if gasToDonate != extra {
+			Fail(t, "donated", gasToDonate, "instead of", extra)
+		}
+		if context.gasLeft != reserve+extra {
+			Fail(t, "computing the donation burned gas")
+		}
+	}
+}
+
+func TestRetryableCreationRateLimit(t *testing.T) {
+	evm := newMockEVMForTesting()
+	evm.Context.BaseFee = big.NewInt(l2pricing.InitialBaseFeeWei)
+	state := testContext(common.Address{}, evm).State
+	state.SetFormatVersion(20)
+	retryableState := state.RetryableState()
+
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	sender := common.HexToAddress("0x0708090a")
+	evm.StateDB.AddBalance(sender, big.NewInt(params.Ether))
+	l1BaseFee, err := state.L1PricingState().PricePerUnit()
+	Require(t, err)
+	submissionFee := retryables.RetryableSubmissionFee(0, l1BaseFee)
+	submit := func() error {
+		t.Helper()
+		input, err := retryABI.Pack(
+			"submitRetryableFromL2", sender, common.Big0, submissionFee, sender, sender, uint64(0), evm.Context.BaseFee, []byte{},
+		)
+		Require(t, err)
+		_, _, err = Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, sender, big.NewInt(0), false, 1000000, evm,
+		)
+		return err
+	}
+
+	// without a limit, any number of retryables can be created
+	for i := 0; i < 3; i++ {
+		Require(t, submit())
+	}
+
+	const limit = 2
+	Require(t, ArbOwner{}.SetMaxRetryablesPerBlock(testContext(common.Address{}, evm), evm, limit))
+	for block := int64(1); block <= 2; block++ {
+		evm.Context.BlockNumber = big.NewInt(block)
+		for i := 0; i < limit; i++ {
+			Require(t, submit(), "submission", i, "in block", block)
+		}
+		if err := submit(); err == nil {
+			Fail(t, "created more than", limit, "retryables in block", block)
+		}
+		if err := retryableState.CheckCreationRateLimit(uint64(block)); !errors.Is(err, retryables.ErrCreationRateLimited) {
+			Fail(t, "block", block, "isn't rate limited", err)
+		}
+	}
+
+	// removing the limit lets the block create more
+	Require(t, retryableState.SetMaxRetryablesPerBlock(0))
+	Require(t, submit())
+
+	// inbox submissions that are rate limited are reported with an event
+	ticketId := common.BytesToHash([]byte("rate limited"))
+	Require(t, arbos.EmitRetryableCreationRateLimitedEvent(evm, ticketId, limit))
+	logs := evm.StateDB.(*gethstate.StateDB).Logs()
+	rateLimited := logs[len(logs)-1]
+	if rateLimited.Topics[0] != retryABI.Events["RetryableCreationRateLimited"].ID || rateLimited.Topics[1] != ticketId {
+		Fail(t, "wrong RetryableCreationRateLimited event", rateLimited.Topics)
+	}
+	values, err := retryABI.Events["RetryableCreationRateLimited"].Inputs.NonIndexed().Unpack(rateLimited.Data)
+	Require(t, err)
+	if values[0].(uint64) != limit {
+		Fail(t, "event reported a limit of", values[0])
+	}
+}
+
+func TestApproveRedeemer(t *testing.T) {
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	id := common.BigToHash(big.NewInt(978645611143))
+	beneficiary := common.HexToAddress("0xbeef")
+	redeemer := common.HexToAddress("0xa11ce")
+	stranger := common.HexToAddress("0xb0b")
+
+	call := func(evm *vm.EVM, caller common.Address, method string, args ...interface{}) error {
+		t.Helper()
+		input, err := retryABI.Pack(method, args...)
+		Require(t, err)
+		_, _, err = Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, caller, big.NewInt(0), false, 1000000, evm,
+		)
+		return err
+	}
+	// redeemBy tries to redeem a fresh ticket after its beneficiary approves the given redeemer
+	redeemBy := func(approved, caller common.Address) error {
+		t.Helper()
+		evm := newMockEVMForTesting()
+		context := testContext(common.Address{}, evm)
+		context.State.SetFormatVersion(20)
+		to := common.HexToAddress("0x06070809")
+		_, err := context.State.RetryableState().CreateRetryable(
+			id, evm.Context.Time+10000, common.HexToAddress("0x030405"), &to, big.NewInt(0), beneficiary, []byte{},
+		)
+		Require(t, err)
+		if err := call(evm, stranger, "approveRedeemer", id, stranger); err == nil {
+			Fail(t, "approved a redeemer without being the beneficiary")
+		}
+		if approved != (common.Address{}) {
+			Require(t, call(evm, beneficiary, "approveRedeemer", id, approved))
+		}
+		return call(evm, caller, "redeem", id)
+	}
+
+	// without an approved redeemer anyone may redeem
+	Require(t, redeemBy(common.Address{}, stranger))
+
+	// once restricted only the approved redeemer and the beneficiary may
+	Require(t, redeemBy(redeemer, redeemer))
+	Require(t, redeemBy(redeemer, beneficiary))
+	if err := redeemBy(redeemer, stranger); !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "unapproved redeemer wasn't rejected", err)
+	}
+}
+
+func TestSweepExpired(t *testing.T) {
+	evm := newMockEVMForTesting()
+	sweeper := common.HexToAddress("0x5eeb")
+	context := testContext(sweeper, evm)
+	context.State.SetFormatVersion(20)
+	Precompiles() // installs the event hook used when retryables are deleted
+	retryableState := context.State.RetryableState()
+	con := ArbRetryableTx{}
+	networkFeeAccount, err := context.State.NetworkFeeAccount()
+	Require(t, err)
+	funds := big.NewInt(params.Ether)
+	evm.StateDB.AddBalance(networkFeeAccount, funds)
+
+	to := common.HexToAddress("0x06070809")
+	create := func(id int64, timeout uint64, calldata []byte) common.Hash {
+		t.Helper()
+		ticketId := common.BigToHash(big.NewInt(id))
+		_, err := retryableState.CreateRetryable(
+			ticketId, timeout, common.HexToAddress("0x030405"), &to, big.NewInt(0), to, calldata,
+		)
+		Require(t, err)
+		return ticketId
+	}
+	timeout := evm.Context.Time + 1000
+	expired := []common.Hash{create(1, timeout, []byte{}), create(2, timeout, make([]byte, 40))}
+	live := create(3, timeout+10000, []byte{})
+	evm.Context.Time = timeout + 1
+
+	// with no reward configured sweeping is free but unpaid
+	reward, err := con.SweepExpired(context, evm, 1)
+	Require(t, err)
+	if reward.Sign() != 0 || evm.StateDB.GetBalance(sweeper).Sign() != 0 {
+		Fail(t, "sweeping without a reward paid", reward)
+	}
+
+	// the sweeper is paid for every word reclaimed, and the sweep stops at the first live ticket
+	weiPerWord := big.NewInt(params.GWei)
+	Require(t, retryableState.SetExpiryDeletionReward(weiPerWord))
+	reward, err = con.SweepExpired(context, evm, 10)
+	Require(t, err)
+	expected := arbmath.BigMulByUint(weiPerWord, 7+2)
+	if !arbmath.BigEquals(reward, expected) {
+		Fail(t, "sweep paid", reward, "instead of", expected)
+	}
+	if !arbmath.BigEquals(evm.StateDB.GetBalance(sweeper), expected) {
+		Fail(t, "sweeper wasn't credited", evm.StateDB.GetBalance(sweeper))
+	}
+	if remaining := evm.StateDB.GetBalance(networkFeeAccount); !arbmath.BigEquals(remaining, arbmath.BigSub(funds, expected)) {
+		Fail(t, "network fee account has", remaining, "after paying", expected)
+	}
+	for _, ticketId := range expired {
+		retryable, err := retryableState.OpenRetryable(ticketId, 0)
+		Require(t, err)
+		if retryable != nil {
+			Fail(t, "expired ticket", ticketId, "wasn't swept")
+		}
+	}
+	retryable, err := retryableState.OpenRetryable(live, evm.Context.Time)
+	Require(t, err)
+	if retryable == nil {
+		Fail(t, "live ticket was swept")
+	}
+
+	// the reward is limited to what the network fee account holds
+	evm.Context.Time = timeout + 10001
+	evm.StateDB.SubBalance(networkFeeAccount, evm.StateDB.GetBalance(networkFeeAccount))
+	evm.StateDB.AddBalance(networkFeeAccount, big.NewInt(1))
+	reward, err = con.SweepExpired(context, evm, 10)
+	Require(t, err)
+	if !arbmath.BigEquals(reward, big.NewInt(1)) {
+		Fail(t, "sweep paid", reward, "from an account holding 1 wei")
+	}
+}
+
+func TestMinRetryableDeposit(t *testing.T) {
+	evm := newMockEVMForTesting()
+	evm.Context.BaseFee = big.NewInt(l2pricing.InitialBaseFeeWei)
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	retryableState := context.State.RetryableState()
+
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	sender := common.HexToAddress("0x0708090a")
+	evm.StateDB.AddBalance(sender, big.NewInt(params.Ether))
+	l1BaseFee, err := context.State.L1PricingState().PricePerUnit()
+	Require(t, err)
+	submissionFee := retryables.RetryableSubmissionFee(0, l1BaseFee)
+	callvalue := big.NewInt(1000)
+	deposit := arbmath.BigAdd(submissionFee, callvalue)
+	submit := func() error {
+		t.Helper()
+		input, err := retryABI.Pack(
+			"submitRetryableFromL2", sender, callvalue, submissionFee, sender, sender, uint64(0), evm.Context.BaseFee, []byte{},
+		)
+		Require(t, err)
+		_, _, err = Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, sender, big.NewInt(0), false, 1000000, evm,
+		)
+		return err
+	}
+
+	minimum, err := ArbRetryableTx{}.GetMinRetryableDeposit(context, evm)
+	Require(t, err)
+	if minimum.Sign() != 0 {
+		Fail(t, "default minimum deposit is", minimum)
+	}
+	Require(t, submit())
+
+	// deposits at or above the minimum are accepted
+	for _, minimum := range []*big.Int{deposit, arbmath.BigSubByUint(deposit, 1)} {
+		Require(t, ArbOwner{}.SetMinRetryableDeposit(context, evm, minimum))
+		Require(t, submit(), "deposit of", deposit, "with a minimum of", minimum)
+	}
+
+	// and ones below it are rejected
+	minimum = arbmath.BigAddByUint(deposit, 1)
+	Require(t, ArbOwner{}.SetMinRetryableDeposit(context, evm, minimum))
+	if err := submit(); err == nil {
+		Fail(t, "submitted a deposit of", deposit, "with a minimum of", minimum)
+	}
+	if err := retryableState.CheckDeposit(deposit); !errors.Is(err, retryables.ErrDepositTooSmall) {
+		Fail(t, "deposit below the minimum wasn't too small", err)
+	}
+}
+
+func TestRedeemAndForward(t *testing.T) {
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	id := common.BigToHash(big.NewInt(978645611144))
+	forwardTo := common.HexToAddress("0xf0f0")
+	caller := common.HexToAddress("0xca11e4")
+	storeCaller := []byte{0x33, 0x60, 0x00, 0x55, 0x00} // sstore(0, caller())
+	spin := []byte{0x5b, 0x60, 0x00, 0x56}              // loop until out of gas
+
+	// redeemAndForward redeems a fresh ticket and forwards to a contract with the given code
+	redeemAndForward := func(code []byte, gas uint64) (*retryables.Retryable, *vm.EVM, []byte, error) {
+		t.Helper()
+		evm := newMockEVMForTesting()
+		evm.Context.CanTransfer, evm.Context.Transfer = core.CanTransfer, core.Transfer // the forward is a real call
+		context := testContext(common.Address{}, evm)
+		context.State.SetFormatVersion(20)
+		evm.StateDB.SetCode(forwardTo, code)
+		to := common.HexToAddress("0x06070809")
+		retryable, err := context.State.RetryableState().CreateRetryable(
+			id, evm.Context.Time+10000, common.HexToAddress("0x030405"), &to, big.NewInt(0), to, []byte{},
+		)
+		Require(t, err)
+		input, err := retryABI.Pack("redeemAndForward", id, forwardTo, []byte{})
+		Require(t, err)
+		// like the EVM, undo everything the call did if it reverts
+		snapshot := evm.StateDB.Snapshot()
+		output, _, err := Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, caller, big.NewInt(0), false,
+			gas, evm,
+		)
+		if err != nil {
+			evm.StateDB.RevertToSnapshot(snapshot)
+		}
+		return retryable, evm, output, err
+	}
+	scheduled := func(evm *vm.EVM) []*types.Log {
+		var logs []*types.Log
+		for _, log := range evm.StateDB.(*gethstate.StateDB).Logs() {
+			if log.Topics[0] == retryABI.Events["RedeemScheduled"].ID {
+				logs = append(logs, log)
+			}
+		}
+		return logs
+	}
+
+	// the retry is scheduled with half the remaining gas, and the forward call runs with the rest as the caller
+	retryable, evm, output, err := redeemAndForward(storeCaller, 1000000)
+	Require(t, err)
+	if forwardedFrom := evm.StateDB.GetState(forwardTo, common.Hash{}); forwardedFrom != common.BytesToHash(caller.Bytes()) {
+		Fail(t, "forward call didn't run as the caller", forwardedFrom)
+	}
+	logs := scheduled(evm)
+	if len(logs) != 1 || common.BytesToHash(output) == (common.Hash{}) {
+		Fail(t, "redeem wasn't scheduled", logs)
+	}
+	values, err := retryABI.Events["RedeemScheduled"].Inputs.NonIndexed().Unpack(logs[0].Data)
+	Require(t, err)
+	if donated := values[0].(uint64); donated < 400000 || donated > 500000 {
+		Fail(t, "donated", donated, "gas of 1000000")
+	}
+	tries, err := retryable.NumTries()
+	Require(t, err)
+	if tries != 1 {
+		Fail(t, "redeem left", tries, "tries")
+	}
+
+	// if the forward call runs out of the gas left for it the redeem is undone
+	retryable, evm, _, err = redeemAndForward(spin, 1000000)
+	if !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "forward call that ran out of gas didn't revert", err)
+	}
+	if len(scheduled(evm)) != 0 {
+		Fail(t, "redeem was scheduled despite the forward call failing")
+	}
+	tries, err = retryable.NumTries()
+	Require(t, err)
+	if tries != 0 {
+		Fail(t, "failed redeem left", tries, "tries")
+	}
+
+	// and there must be enough gas to donate to the retry after reserving the forward call's share
+	if _, _, _, err := redeemAndForward(storeCaller, 50000); err == nil {
+		Fail(t, "redeemed without enough gas to split with the forward call")
+	}
+}
+
+func TestCancelAndWithdraw(t *testing.T) {
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	sysABI, err := PrecompileABI(templates.ArbSysMetaData, "ArbSys")
+	Require(t, err)
+	evm := newMockEVMForTestingWithVersionAndRunMode(nil, core.MessageCommitMode)
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	retryableState := context.State.RetryableState()
+	beneficiary := common.HexToAddress("0xbeef")
+	l1Destination := common.HexToAddress("0x11de57")
+
+	cancel := func(caller common.Address, ticketId common.Hash) (uint64, error) {
+		t.Helper()
+		input, err := retryABI.Pack("cancelAndWithdraw", ticketId, l1Destination)
+		Require(t, err)
+		output, _, err := Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, caller, big.NewInt(0), false, 1000000, evm,
+		)
+		if err != nil {
+			return 0, err
+		}
+		values, err := retryABI.Methods["cancelAndWithdraw"].Outputs.Unpack(output)
+		Require(t, err)
+		return values[0].(*big.Int).Uint64(), nil
+	}
+	create := func(id int64, escrowed *big.Int) common.Hash {
+		t.Helper()
+		ticketId := common.BigToHash(big.NewInt(id))
+		to := common.HexToAddress("0x06070809")
+		_, err := retryableState.CreateRetryable(
+			ticketId, evm.Context.Time+10000, common.HexToAddress("0x030405"), &to, escrowed, beneficiary, []byte{},
+		)
+		Require(t, err)
+		evm.StateDB.AddBalance(retryables.RetryableEscrowAddress(ticketId), escrowed)
+		return ticketId
+	}
+	// lastSend is the caller and value of the most recent withdrawal
+	lastSend := func() (common.Address, *big.Int) {
+		t.Helper()
+		logs := evm.StateDB.(*gethstate.StateDB).Logs()
+		for i := len(logs) - 1; i >= 0; i-- {
+			log := logs[i]
+			if log.Address != types.ArbSysAddress || log.Topics[0] != sysABI.Events["L2ToL1Tx"].ID {
+				continue
+			}
+			if log.Topics[1] != common.BytesToHash(l1Destination.Bytes()) {
+				Fail(t, "withdrew to", log.Topics[1], "instead of", l1Destination)
+			}
+			values := map[string]interface{}{}
+			Require(t, sysABI.Events["L2ToL1Tx"].Inputs.NonIndexed().UnpackIntoMap(values, log.Data))
+			return values["caller"].(common.Address), values["callvalue"].(*big.Int)
+		}
+		Fail(t, "no withdrawal was sent")
+		return common.Address{}, nil
+	}
+
+	escrowed := big.NewInt(123456789)
+	ticketId := create(1, escrowed)
+	snapshot := evm.StateDB.Snapshot()
+	if _, err := cancel(common.HexToAddress("0xb0b"), ticketId); err == nil {
+		Fail(t, "canceled a retryable without being its beneficiary")
+	}
+	evm.StateDB.RevertToSnapshot(snapshot)
+
+	// the escrow is sent to L1 as the beneficiary's withdrawal rather than left with them on L2
+	leaf, err := cancel(beneficiary, ticketId)
+	Require(t, err)
+	if leaf != 0 {
+		Fail(t, "first withdrawal was sent as leaf", leaf)
+	}
+	sender, value := lastSend()
+	if sender != beneficiary || !arbmath.BigEquals(value, escrowed) {
+		Fail(t, "withdrew", value, "for", sender, "instead of", escrowed, "for", beneficiary)
+	}
+	for _, account := range []common.Address{retryables.RetryableEscrowAddress(ticketId), beneficiary, types.ArbSysAddress} {
+		if balance := evm.StateDB.GetBalance(account); balance.Sign() != 0 {
+			Fail(t, account, "kept", balance, "of the withdrawn escrow")
+		}
+	}
+	retryable, err := retryableState.OpenRetryable(ticketId, evm.Context.Time)
+	Require(t, err)
+	if retryable != nil {
+		Fail(t, "withdrawn retryable wasn't canceled")
+	}
+
+	// a ticket with nothing escrowed is still canceled
+	ticketId = create(2, big.NewInt(0))
+	leaf, err = cancel(beneficiary, ticketId)
+	Require(t, err)
+	if leaf != 1 {
+		Fail(t, "second withdrawal was sent as leaf", leaf)
+	}
+	if _, value := lastSend(); value.Sign() != 0 {
+		Fail(t, "withdrew", value, "from a ticket with no escrow")
+	}
+	retryable, err = retryableState.OpenRetryable(ticketId, evm.Context.Time)
+	Require(t, err)
+	if retryable != nil {
+		Fail(t, "retryable with no escrow wasn't canceled")
+	}
+}
+
+func TestGetTicketForRedeem(t *testing.T) {
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	ticketId := common.BigToHash(big.NewInt(978645611143))
+	to := common.HexToAddress("0x06070809")
+	_, err = context.State.RetryableState().CreateRetryable(
+		ticketId, evm.Context.Time+10000, common.HexToAddress("0x030405"), &to, big.NewInt(0), to, []byte{},
+	)
+	Require(t, err)
+
+	call := func(method string, args ...interface{}) ([]interface{}, error) {
+		t.Helper()
+		input, err := retryABI.Pack(method, args...)
+		Require(t, err)
+		output, _, err := Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, common.Address{}, big.NewInt(0), false, 1000000, evm,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return retryABI.Methods[method].Outputs.Unpack(output)
+	}
+
+	var redeemTxIds []common.Hash
+	for i := 0; i < 2; i++ {
+		values, err := call("redeem", ticketId)
+		Require(t, err)
+		redeemTxIds = append(redeemTxIds, values[0].([32]byte))
+	}
+	for sequenceNum, redeemTxId := range redeemTxIds {
+		values, err := call("getTicketForRedeem", redeemTxId, uint64(sequenceNum))
+		Require(t, err)
+		if values[0].([32]byte) != ticketId {
+			Fail(t, "redeem", sequenceNum, "was traced to", values[0], "instead of", ticketId)
+		}
+	}
+
+	// the redeem must have been scheduled, and as that attempt
+	if _, err := call("getTicketForRedeem", redeemTxIds[0], uint64(1)); !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "matched a redeem with the wrong sequence number", err)
+	}
+	if _, err := call("getTicketForRedeem", common.HexToHash("0xdead"), uint64(0)); !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "matched a tx that isn't a redeem", err)
+	}
+}
+
+func TestRetryableMaxTries(t *testing.T) {
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	retryableState := context.State.RetryableState()
+	ticketId := common.BigToHash(big.NewInt(978645611143))
+	to := common.HexToAddress("0x06070809")
+	_, err = retryableState.CreateRetryable(
+		ticketId, evm.Context.Time+10000, common.HexToAddress("0x030405"), &to, big.NewInt(0), to, []byte{},
+	)
+	Require(t, err)
+
+	redeem := func() error {
+		t.Helper()
+		input, err := retryABI.Pack("redeem", ticketId)
+		Require(t, err)
+		snapshot := evm.StateDB.Snapshot()
+		_, _, err = Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, common.Address{}, big.NewInt(0), false, 1000000, evm,
+		)
+		if err != nil {
+			evm.StateDB.RevertToSnapshot(snapshot)
+		}
+		return err
+	}
+
+	// by default a retryable may be redeemed any number of times
+	for i := 0; i < 3; i++ {
+		Require(t, redeem())
+	}
+
+	Require(t, retryableState.SetMaxTries(4))
+	Require(t, redeem())
+	if err := redeem(); !errors.Is(err, vm.ErrExecutionReverted) {
+		Fail(t, "redeemed a retryable that's used all its tries", err)
+	}
+	retryable, err := retryableState.OpenRetryable(ticketId, evm.Context.Time)
+	Require(t, err)
+	if numTries, err := retryable.NumTries(); err != nil || numTries != 4 {
+		Fail(t, "rejected redeem was counted as a try", numTries, err)
+	}
+
+	Require(t, retryableState.SetMaxTries(0))
+	Require(t, redeem())
+}
+
+func TestGetMaxLifetimeWindow(t *testing.T) {
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	evm.Context.Time = 1000000
+	retryableState := context.State.RetryableState()
+	ticketId := common.BigToHash(big.NewInt(978645611144))
+	to := common.HexToAddress("0x06070809")
+	_, err = retryableState.CreateRetryable(
+		ticketId, evm.Context.Time+retryables.RetryableLifetimeSeconds, common.HexToAddress("0x030405"), &to, big.NewInt(0), to, []byte{},
+	)
+	Require(t, err)
+
+	window, err := ArbRetryableTx{}.GetMaxLifetimeWindow(context, evm)
+	Require(t, err)
+	if window.Uint64() != retryables.RetryableMaxLifetimes*retryables.RetryableLifetimeSeconds {
+		Fail(t, "wrong max lifetime window", window)
+	}
+	lifetime, err := ArbRetryableTx{}.GetLifetime(context, evm)
+	Require(t, err)
+	if window.Cmp(lifetime) <= 0 {
+		Fail(t, "max lifetime window", window, "isn't longer than a lifetime", lifetime)
+	}
+
+	// keep the ticket alive until it can't be extended any further, checking it never passes the window
+	keepalives := 0
+	for ; keepalives < 10; keepalives++ {
+		input, err := retryABI.Pack("keepalive", ticketId)
+		Require(t, err)
+		snapshot := evm.StateDB.Snapshot()
+		_, _, err = Precompiles()[types.ArbRetryableTxAddress].Call(
+			input, types.ArbRetryableTxAddress, types.ArbRetryableTxAddress, common.Address{}, big.NewInt(0), false, 10000000, evm,
+		)
+		if err != nil {
+			evm.StateDB.RevertToSnapshot(snapshot)
+			break
+		}
+		retryable, err := retryableState.OpenRetryable(ticketId, evm.Context.Time)
+		Require(t, err)
+		timeout, err := retryable.CalculateTimeout()
+		Require(t, err)
+		if timeout > evm.Context.Time+window.Uint64() {
+			Fail(t, "keepalive extended the timeout to", timeout, "past the max window", window)
+		}
+	}
+	if keepalives != 1 {
+		Fail(t, "a ticket a lifetime from timing out was kept alive", keepalives, "times")
+	}
+}
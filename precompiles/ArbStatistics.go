@@ -24,3 +24,14 @@ func (con ArbStatistics) GetStats(c ctx, evm mech) (huge, huge, huge, huge, huge
 	classicNumContracts := big.NewInt(0) // TODO: hardcode the final value from Arbitrum Classic
 	return blockNum, classicNumAccounts, classicStorageSum, classicGasSum, classicNumTxes, classicNumContracts, nil
 }
+
+// GetContractCount gets how many contracts have been deployed by top-level txs since ArbOS 20
+func (con ArbStatistics) GetContractCount(c ctx, evm mech) (uint64, error) {
+	return c.State.ContractCount()
+}
+
+// GetAccountCount gets how many accounts have been created by top-level txs since ArbOS 20, either by funding an
+// empty account or deploying a contract. Accounts created by internal calls aren't counted.
+func (con ArbStatistics) GetAccountCount(c ctx, evm mech) (uint64, error) {
+	return c.State.AccountCount()
+}
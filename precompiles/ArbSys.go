@@ -9,7 +9,9 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/util/merkletree"
 )
@@ -21,11 +23,17 @@ type ArbSys struct {
 	L2ToL1TxGasCost         func(addr, addr, huge, huge, huge, huge, huge, huge, []byte) (uint64, error)
 	SendMerkleUpdate        func(ctx, mech, huge, bytes32, huge) error
 	SendMerkleUpdateGasCost func(huge, bytes32, huge) (uint64, error)
+	L2ToL1TxMetadata        func(ctx, mech, huge, []byte) error
+	L2ToL1TxMetadataGasCost func(huge, []byte) (uint64, error)
+	ArbOSUpgraded           func(ctx, mech, uint64, uint64) error
+	ArbOSUpgradedGasCost    func(uint64, uint64) (uint64, error)
 	InvalidBlockNumberError func(huge, huge) error
 
 	// deprecated event
 	L2ToL1Transaction        func(ctx, mech, addr, addr, huge, huge, huge, huge, huge, huge, huge, []byte) error
 	L2ToL1TransactionGasCost func(addr, addr, huge, huge, huge, huge, huge, huge, huge, []byte) (uint64, error)
+
+	precompiles map[addr]ArbosPrecompile // every precompile, so their methods' availability can be reported
 }
 
 // ArbBlockNumber gets the current L2 block number
@@ -54,6 +62,33 @@ func (con *ArbSys) ArbBlockHash(c ctx, evm mech, arbBlockNumber *big.Int) (bytes
 	return evm.Context.GetHash(requestedBlockNum), nil
 }
 
+// GetCurrentL1Context gets the L1 block number and timestamp of the inbox message the current block was made from.
+// The block's timestamp is the message's, unless that was before the parent block's and had to be raised to it.
+func (con *ArbSys) GetCurrentL1Context(c ctx, evm mech) (l1BlockNumber uint64, l1Timestamp uint64, err error) {
+	l1BlockNumber, err = c.State.Blockhashes().L1BlockNumber()
+	return l1BlockNumber, evm.Context.Time, err
+}
+
+// ArbBlockHashRange gets the hashes of the L2 blocks from through to, all of which must be sufficiently recent
+func (con *ArbSys) ArbBlockHashRange(c ctx, evm mech, from uint64, to uint64) ([][32]byte, error) {
+	currentNumber := evm.Context.BlockNumber.Uint64()
+	if to >= currentNumber {
+		return nil, con.InvalidBlockNumberError(new(big.Int).SetUint64(to), evm.Context.BlockNumber)
+	}
+	if from > to || from+256 < currentNumber {
+		return nil, con.InvalidBlockNumberError(new(big.Int).SetUint64(from), evm.Context.BlockNumber)
+	}
+	count := to - from + 1
+	if err := c.Burn(count * params.GasExtStep); err != nil {
+		return nil, err
+	}
+	hashes := make([][32]byte, count)
+	for i := range hashes {
+		hashes[i] = evm.Context.GetHash(from + uint64(i))
+	}
+	return hashes, nil
+}
+
 // ArbChainID gets the rollup's unique chain identifier
 func (con *ArbSys) ArbChainID(c ctx, evm mech) (huge, error) {
 	return evm.ChainConfig().ChainID, nil
@@ -65,6 +100,11 @@ func (con *ArbSys) ArbOSVersion(c ctx, evm mech) (huge, error) {
 	return version, nil
 }
 
+// GetChainConfig gets the serialized chain config stored in ArbOS state
+func (con *ArbSys) GetChainConfig(c ctx, evm mech) ([]byte, error) {
+	return c.State.ChainConfig()
+}
+
 // GetStorageGasAvailable returns 0 since Nitro has no concept of storage gas
 func (con *ArbSys) GetStorageGasAvailable(c ctx, evm mech) (huge, error) {
 	return big.NewInt(0), nil
@@ -186,6 +226,66 @@ func (con *ArbSys) SendTxToL1(c ctx, evm mech, value huge, destination addr, cal
 	return sendHash.Big(), err
 }
 
+// SendTxToL1WithMetadata sends a transaction to L1 like SendTxToL1, tagging it with metadata for the L1 relayer.
+// The metadata is only emitted alongside the send, keyed by its position in the outbox, and never enters the
+// send hash, so the outbox proof is the same as it would be without it.
+func (con *ArbSys) SendTxToL1WithMetadata(c ctx, evm mech, destination addr, calldataForL1 []byte, metadata []byte) (huge, error) {
+	position, err := con.SendTxToL1(c, evm, common.Big0, destination, calldataForL1)
+	if err != nil {
+		return nil, err
+	}
+	return position, con.L2ToL1TxMetadata(c, evm, position, metadata)
+}
+
+// GetL2ToL1TxCount gets the number of L2 to L1 transactions sent so far, which is the size of the outbox Merkle tree
+func (con *ArbSys) GetL2ToL1TxCount(c ctx, evm mech) (uint64, error) {
+	return c.State.SendMerkleAccumulator().Size()
+}
+
+// GetL1BaseFeeEstimate gets ArbOS's current estimate of the L1 basefee, which is what it prices L1 data at
+func (con *ArbSys) GetL1BaseFeeEstimate(c ctx, evm mech) (huge, error) {
+	return c.State.L1PricingState().PricePerUnit()
+}
+
+// GetL2BaseFeeComponents splits the L2 basefee into its floor and the congestion premium on top of it
+func (con *ArbSys) GetL2BaseFeeComponents(c ctx, evm mech) (huge, huge, error) {
+	return c.State.L2PricingState().BaseFeeComponents()
+}
+
+// GetInboxMessageCount gets the number of inbox messages processed so far, including the one that made this block.
+// Each message produces exactly one block, so this is the current message's index plus one.
+func (con *ArbSys) GetInboxMessageCount(c ctx, evm mech) (uint64, error) {
+	genesisBlockNum, err := c.State.GenesisBlockNum()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(arbutil.BlockNumberToMessageCount(evm.Context.BlockNumber.Uint64(), genesisBlockNum)), nil
+}
+
+// GetFeatureFlags gets the bitmask of optional ArbOS features enabled at the current version and configuration.
+// Each bit is documented alongside arbosState.FeatureL2Retryables.
+func (con *ArbSys) GetFeatureFlags(c ctx, evm mech) (uint64, error) {
+	return c.State.FeatureFlags()
+}
+
+// GetArbOSConfigHash gets a hash of the chain owner's configuration of ArbOS, which nodes can compare to detect drift.
+// See arbosState.ConfigHash for what it covers.
+func (con *ArbSys) GetArbOSConfigHash(c ctx, evm mech) (bytes32, error) {
+	return c.State.ConfigHash()
+}
+
+// IsPrecompileMethodAvailable checks whether a precompile's method can be called at the current ArbOS version
+func (con *ArbSys) IsPrecompileMethodAvailable(c ctx, evm mech, precompile addr, selector [4]byte) (bool, error) {
+	contract, ok := con.precompiles[precompile]
+	if !ok {
+		return false, nil
+	}
+	if _, debugOnly := contract.(*DebugPrecompile); debugOnly && !evm.ChainConfig().DebugMode() {
+		return false, nil
+	}
+	return contract.Precompile().IsMethodAvailable(selector, c.State.ArbOSVersion()), nil
+}
+
 // SendMerkleTreeState gets the root, size, and partials of the outbox Merkle tree state (caller must be the 0 address)
 func (con ArbSys) SendMerkleTreeState(c ctx, evm mech) (huge, bytes32, []bytes32, error) {
 	if c.caller != (addr{}) {
@@ -194,7 +294,11 @@ func (con ArbSys) SendMerkleTreeState(c ctx, evm mech) (huge, bytes32, []bytes32
 
 	// OK to not charge gas, because method is only callable by address zero
 
-	size, rootHash, rawPartials, _ := c.State.SendMerkleAccumulator().StateForExport()
+	// the size, root, and partials are all read from the same state, so they're always consistent with each other
+	size, rootHash, rawPartials, err := c.State.SendMerkleAccumulator().StateForExport()
+	if err != nil {
+		return nil, bytes32{}, nil, err
+	}
 	partials := make([]bytes32, len(rawPartials))
 	for i, par := range rawPartials {
 		partials[i] = par
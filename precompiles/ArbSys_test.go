@@ -0,0 +1,502 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	gethstate "github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/offchainlabs/nitro/arbos"
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+	"github.com/offchainlabs/nitro/arbos/merkleAccumulator"
+	"github.com/offchainlabs/nitro/arbos/util"
+	templates "github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+func sendTxToL1ForTesting(t *testing.T, evm mech, calldataForL1 []byte) {
+	t.Helper()
+	sysABI, err := PrecompileABI(templates.ArbSysMetaData, "ArbSys")
+	Require(t, err)
+	sendCalldata, err := sysABI.Pack("sendTxToL1", common.HexToAddress("0x0123"), calldataForL1)
+	Require(t, err)
+	_, _, err = Precompiles()[types.ArbSysAddress].Call(
+		sendCalldata,
+		types.ArbSysAddress,
+		types.ArbSysAddress,
+		common.Address{},
+		big.NewInt(0),
+		false,
+		1000000,
+		evm,
+	)
+	Require(t, err)
+}
+
+func TestGetL2ToL1TxCount(t *testing.T) {
+	evm := newMockEVMForTestingWithVersionAndRunMode(nil, core.MessageCommitMode)
+	context := testContext(common.Address{}, evm)
+	arbSys := &ArbSys{}
+
+	count, err := arbSys.GetL2ToL1TxCount(context, evm)
+	Require(t, err)
+	if count != 0 {
+		Fail(t, "outbox should start empty", count)
+	}
+
+	for i := uint64(1); i <= 5; i++ {
+		sendTxToL1ForTesting(t, evm, []byte{1, 2, 3})
+
+		count, err = arbSys.GetL2ToL1TxCount(context, evm)
+		Require(t, err)
+		if count != i {
+			Fail(t, "wrong count after sending", i, "txs:", count)
+		}
+	}
+}
+
+func TestGetInboxMessageCount(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	arbSys := &ArbSys{}
+	genesisBlockNum, err := context.State.GenesisBlockNum()
+	Require(t, err)
+
+	// the genesis block is made by the init message, and every later message makes one block
+	for i := uint64(0); i < 5; i++ {
+		evm.Context.BlockNumber = arbmath.UintToBig(genesisBlockNum + i)
+		count, err := arbSys.GetInboxMessageCount(context, evm)
+		Require(t, err)
+		if count != i+1 {
+			Fail(t, "block", genesisBlockNum+i, "reported", count, "messages instead of", i+1)
+		}
+	}
+}
+
+func TestSendMerkleTreeState(t *testing.T) {
+	evm := newMockEVMForTestingWithVersionAndRunMode(nil, core.MessageCommitMode)
+	context := testContext(common.Address{}, evm)
+	arbSys := &ArbSys{}
+
+	for i := 0; i < 13; i++ {
+		sendTxToL1ForTesting(t, evm, []byte{byte(i)})
+
+		size, root, partials, err := arbSys.SendMerkleTreeState(context, evm)
+		Require(t, err)
+		if size.Uint64() != uint64(i+1) {
+			Fail(t, "wrong size", size, "after", i+1, "sends")
+		}
+
+		// rebuilding the tree from its partials must reproduce both the size and the root
+		rawPartials := make([]*common.Hash, len(partials))
+		for j := range partials {
+			partial := common.Hash(partials[j])
+			rawPartials[j] = &partial
+		}
+		rebuilt, err := merkleAccumulator.NewNonpersistentMerkleAccumulatorFromPartials(rawPartials)
+		Require(t, err)
+		rebuiltSize, err := rebuilt.Size()
+		Require(t, err)
+		rebuiltRoot, err := rebuilt.Root()
+		Require(t, err)
+		if rebuiltSize != size.Uint64() || rebuiltRoot != root {
+			Fail(t, "partials are inconsistent with the size and root", rebuiltSize, size, rebuiltRoot, root)
+		}
+	}
+
+	// only address zero may read the tree state
+	other := testContext(common.HexToAddress("0x0123"), evm)
+	if _, _, _, err := arbSys.SendMerkleTreeState(other, evm); err == nil {
+		Fail(t, "non-zero caller read the tree state")
+	}
+}
+
+func TestWasMyCallersAddressAliased(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	arbSys := &ArbSys{}
+
+	// a call made directly by the tx is top level, so whether the caller was aliased depends only on how it got here
+	cases := []struct {
+		name    string
+		txType  byte
+		aliased bool
+	}{
+		{"L2 signed legacy", types.LegacyTxType, false},
+		{"L2 signed dynamic fee", types.DynamicFeeTxType, false},
+		{"L1 unsigned", types.ArbitrumUnsignedTxType, true},
+		{"L1 contract", types.ArbitrumContractTxType, true},
+		{"retry", types.ArbitrumRetryTxType, true},
+		{"deposit", types.ArbitrumDepositTxType, false},
+	}
+	for _, test := range cases {
+		txType := test.txType
+		context.txProcessor.TopTxType = &txType
+
+		aliased, err := arbSys.WasMyCallersAddressAliased(context, evm)
+		Require(t, err)
+		if aliased != test.aliased {
+			Fail(t, test.name, "tx reported aliased", aliased)
+		}
+
+		// there's no caller's caller at the top level, so it's the zero address that gets unaliased
+		address, err := arbSys.MyCallersAddressWithoutAliasing(context, evm)
+		Require(t, err)
+		expected := common.Address{}
+		if test.aliased {
+			expected = util.InverseRemapL1Address(expected)
+		}
+		if address != expected {
+			Fail(t, test.name, "tx's caller without aliasing was", address, "instead of", expected)
+		}
+		if test.aliased && util.RemapL1Address(address) != (common.Address{}) {
+			Fail(t, test.name, "tx's caller doesn't alias back to the original", address)
+		}
+	}
+}
+
+func TestGetL1BaseFeeEstimate(t *testing.T) {
+	evm := newMockEVMForTesting()
+	baseFee := big.NewInt(l2pricing.InitialBaseFeeWei)
+	evm.Context.BaseFee = baseFee
+	evm.Context.Coinbase = l1pricing.BatchPosterAddress
+	context := testContext(common.Address{}, evm)
+	arbSys := &ArbSys{}
+
+	price := big.NewInt(123456789)
+	Require(t, context.State.L1PricingState().SetPricePerUnit(price))
+	estimate, err := arbSys.GetL1BaseFeeEstimate(context, evm)
+	Require(t, err)
+	gasInfo, err := ArbGasInfo{}.GetL1BaseFeeEstimate(context, evm)
+	Require(t, err)
+	if !arbmath.BigEquals(estimate, price) || !arbmath.BigEquals(gasInfo, price) {
+		Fail(t, "L1 basefee estimate", estimate, "and ArbGasInfo's", gasInfo, "don't match the price", price)
+	}
+
+	// charge a tx in the same block, and check its L1 fee was priced at the estimate
+	to := common.HexToAddress("0x06070809")
+	tx := types.NewTx(&types.LegacyTx{
+		Nonce:    1,
+		GasPrice: baseFee,
+		Gas:      1000000,
+		To:       &to,
+		Value:    big.NewInt(0),
+		Data:     []byte{1, 2, 3, 4},
+	})
+	msg := &core.Message{
+		Tx:        tx,
+		To:        &to,
+		GasLimit:  tx.Gas(),
+		GasPrice:  baseFee,
+		GasFeeCap: baseFee,
+		Value:     tx.Value(),
+		Data:      tx.Data(),
+		TxRunMode: core.MessageCommitMode,
+	}
+	txProcessor := arbos.NewTxProcessor(evm, msg)
+	evm.ProcessingHook = txProcessor
+	gasRemaining := tx.Gas()
+	_, err = txProcessor.GasChargingHook(&gasRemaining)
+	Require(t, err)
+
+	compressionLevel, err := context.State.BrotliCompressionLevel()
+	Require(t, err)
+	posterCost, units := context.State.L1PricingState().GetPosterInfo(tx, l1pricing.BatchPosterAddress, compressionLevel)
+	if units == 0 || !arbmath.BigEquals(posterCost, arbmath.BigMulByUint(estimate, units)) {
+		Fail(t, "tx was charged", posterCost, "for", units, "units, which isn't priced at the estimate", estimate)
+	}
+	posterGas := arbmath.BigDiv(posterCost, baseFee)
+	if !arbmath.BigEquals(txProcessor.PosterFee, arbmath.BigMul(posterGas, baseFee)) {
+		Fail(t, "poster fee", txProcessor.PosterFee, "doesn't cover", posterGas, "gas at the basefee")
+	}
+}
+
+func TestGetL2BaseFeeComponents(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	arbSys := &ArbSys{}
+	l2pricingState := context.State.L2PricingState()
+	minBaseFee, err := l2pricingState.MinBaseFeeWei()
+	Require(t, err)
+
+	checkComponents := func() (huge, huge) {
+		t.Helper()
+		floor, congestion, err := arbSys.GetL2BaseFeeComponents(context, evm)
+		Require(t, err)
+		baseFee, err := l2pricingState.BaseFeeWei()
+		Require(t, err)
+		if !arbmath.BigEquals(arbmath.BigAdd(floor, congestion), baseFee) {
+			Fail(t, "floor", floor, "and congestion", congestion, "don't add up to the basefee", baseFee)
+		}
+		return floor, congestion
+	}
+
+	// a backlog within the tolerance leaves the basefee at its floor
+	limit, err := l2pricingState.SpeedLimitPerSecond()
+	Require(t, err)
+	Require(t, l2pricingState.AddToGasPool(-int64(limit)))
+	l2pricingState.UpdatePricingModel(nil, 0, false)
+	floor, congestion := checkComponents()
+	if !arbmath.BigEquals(floor, minBaseFee) || congestion.Sign() != 0 {
+		Fail(t, "uncongested chain has floor", floor, "and congestion", congestion)
+	}
+
+	// a large backlog raises the basefee above its floor
+	Require(t, l2pricingState.AddToGasPool(-int64(limit*1000)))
+	l2pricingState.UpdatePricingModel(nil, 0, false)
+	floor, congestion = checkComponents()
+	if !arbmath.BigEquals(floor, minBaseFee) || congestion.Sign() <= 0 {
+		Fail(t, "congested chain has floor", floor, "and congestion", congestion)
+	}
+
+	// raising the minimum above the current basefee caps the floor at the basefee until it's recomputed
+	baseFee, err := l2pricingState.BaseFeeWei()
+	Require(t, err)
+	Require(t, l2pricingState.SetMinBaseFeeWei(arbmath.BigMulByUint(baseFee, 2)))
+	floor, congestion = checkComponents()
+	if !arbmath.BigEquals(floor, baseFee) || congestion.Sign() != 0 {
+		Fail(t, "floor", floor, "and congestion", congestion, "after raising the minimum")
+	}
+}
+
+func TestSendTxToL1WithMetadata(t *testing.T) {
+	sysABI, err := PrecompileABI(templates.ArbSysMetaData, "ArbSys")
+	Require(t, err)
+	destination := common.HexToAddress("0x0123")
+	calldataForL1 := []byte{1, 2, 3}
+
+	send := func(evm mech, method string, args ...interface{}) []*types.Log {
+		t.Helper()
+		input, err := sysABI.Pack(method, args...)
+		Require(t, err)
+		_, _, err = Precompiles()[types.ArbSysAddress].Call(
+			input, types.ArbSysAddress, types.ArbSysAddress, common.Address{}, big.NewInt(0), false, 1000000, evm,
+		)
+		Require(t, err)
+		return evm.StateDB.(*gethstate.StateDB).Logs()
+	}
+	findLog := func(logs []*types.Log, event string) *types.Log {
+		t.Helper()
+		for _, log := range logs {
+			if log.Topics[0] == sysABI.Events[event].ID {
+				return log
+			}
+		}
+		Fail(t, "no", event, "event was emitted")
+		return nil
+	}
+
+	plainEVM := newMockEVMForTestingWithVersionAndRunMode(nil, core.MessageCommitMode)
+	plainSend := findLog(send(plainEVM, "sendTxToL1", destination, calldataForL1), "L2ToL1Tx")
+
+	evm := newMockEVMForTestingWithVersionAndRunMode(nil, core.MessageCommitMode)
+	for i, metadata := range [][]byte{{0xde, 0xad, 0xbe, 0xef}, {}} {
+		logs := send(evm, "sendTxToL1WithMetadata", destination, calldataForL1, metadata)
+
+		// the send itself, and so its outbox proof, is the same as one without metadata
+		sent := findLog(logs[len(logs)-2:], "L2ToL1Tx")
+		if sent.Topics[2] != plainSend.Topics[2] {
+			Fail(t, "metadata changed the send hash", sent.Topics[2], "instead of", plainSend.Topics[2])
+		}
+
+		tagged := findLog(logs[len(logs)-1:], "L2ToL1TxMetadata")
+		if tagged.Topics[1] != common.BigToHash(big.NewInt(int64(i))) || tagged.Topics[1] != sent.Topics[3] {
+			Fail(t, "metadata was emitted for position", tagged.Topics[1], "rather than the send's", sent.Topics[3])
+		}
+		values, err := sysABI.Events["L2ToL1TxMetadata"].Inputs.NonIndexed().Unpack(tagged.Data)
+		Require(t, err)
+		if got, ok := values[0].([]byte); !ok || !bytes.Equal(got, metadata) {
+			Fail(t, "metadata", values[0], "didn't round-trip as", metadata)
+		}
+	}
+}
+
+func TestIsPrecompileMethodAvailable(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	contracts := Precompiles()
+	arbSys := &ArbSys{precompiles: contracts}
+	sys := contracts[types.ArbSysAddress].Precompile()
+	ownerAddress := common.HexToAddress("70")
+	owner := contracts[ownerAddress].Precompile()
+
+	check := func(precompile addr, selector bytes4, expected bool) {
+		t.Helper()
+		available, err := arbSys.IsPrecompileMethodAvailable(context, evm, precompile, selector)
+		Require(t, err)
+		if available != expected {
+			Fail(t, "method", selector, "of", precompile, "available:", available, "at version", context.State.ArbOSVersion())
+		}
+	}
+
+	// methods gated behind a later version aren't available until it's reached, even behind the owner wrapper
+	context.State.SetFormatVersion(11)
+	check(types.ArbSysAddress, sys.GetMethodID("ArbBlockNumber"), true)
+	check(types.ArbSysAddress, sys.GetMethodID("GetL2ToL1TxCount"), false)
+	check(ownerAddress, owner.GetMethodID("SetChainConfig"), true)
+	check(ownerAddress, owner.GetMethodID("SetChainId"), false)
+
+	context.State.SetFormatVersion(20)
+	check(types.ArbSysAddress, sys.GetMethodID("GetL2ToL1TxCount"), true)
+	check(ownerAddress, owner.GetMethodID("SetChainId"), true)
+
+	// unknown selectors and addresses that aren't precompiles are never available
+	check(types.ArbSysAddress, bytes4{0xde, 0xad, 0xbe, 0xef}, false)
+	check(common.HexToAddress("0x0123"), sys.GetMethodID("ArbBlockNumber"), false)
+}
+
+func TestGetFeatureFlags(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	arbSys := &ArbSys{}
+	flags := func() uint64 {
+		t.Helper()
+		flags, err := arbSys.GetFeatureFlags(context, evm)
+		Require(t, err)
+		return flags
+	}
+
+	// configured features aren't reported before the upgrade that introduces them
+	context.State.SetFormatVersion(19)
+	Require(t, context.State.L2PricingState().SetCollectTips(true))
+	if reported := flags(); reported != 0 {
+		Fail(t, "reported features", reported, "before ArbOS 20")
+	}
+
+	Require(t, context.State.UpgradeArbosVersion(20, false, evm.StateDB, evm.ChainConfig()))
+	expected := arbosState.FeatureL2Retryables | arbosState.FeatureCollectTips
+	if reported := flags(); reported != expected {
+		Fail(t, "reported features", reported, "instead of", expected, "after upgrading")
+	}
+
+	retryableState := context.State.RetryableState()
+	Require(t, context.State.L2PricingState().SetCollectTips(false))
+	Require(t, retryableState.SetCreatorAllowlistEnabled(true))
+	Require(t, retryableState.SetMaxRetryablesPerBlock(10))
+	Require(t, retryableState.SetExpiryGracePeriod(100))
+	expected = arbosState.FeatureL2Retryables | arbosState.FeatureRetryableCreatorAllowlist |
+		arbosState.FeatureRetryableRateLimit | arbosState.FeatureRetryableGracePeriod
+	if reported := flags(); reported != expected {
+		Fail(t, "reported features", reported, "instead of", expected)
+	}
+}
+
+func TestArbBlockHashRange(t *testing.T) {
+	evm := newMockEVMForTesting()
+	testContext(common.Address{}, evm).State.SetFormatVersion(20)
+	sysABI, err := PrecompileABI(templates.ArbSysMetaData, "ArbSys")
+	Require(t, err)
+	evm.Context.BlockNumber = big.NewInt(1000)
+	evm.Context.GetHash = func(number uint64) common.Hash {
+		return common.BigToHash(arbmath.UintToBig(number + 1))
+	}
+	call := func(method string, args ...interface{}) ([]interface{}, error) {
+		t.Helper()
+		input, err := sysABI.Pack(method, args...)
+		Require(t, err)
+		output, _, err := Precompiles()[types.ArbSysAddress].Call(
+			input, types.ArbSysAddress, types.ArbSysAddress, common.Address{}, big.NewInt(0), false, 1000000, evm,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return sysABI.Unpack(method, output)
+	}
+	blockHash := func(number *big.Int) ([32]byte, error) {
+		t.Helper()
+		output, err := call("arbBlockHash", number)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		return output[0].([32]byte), nil
+	}
+
+	// the previous block's hash is the latest one available
+	output, err := call("arbBlockNumber")
+	Require(t, err)
+	current := output[0].(*big.Int)
+	latest, err := blockHash(arbmath.BigSubByUint(current, 1))
+	Require(t, err)
+	if latest != evm.Context.GetHash(current.Uint64()-1) {
+		Fail(t, "previous block has hash", latest)
+	}
+	if _, err := blockHash(current); err == nil {
+		Fail(t, "got the hash of the current block")
+	}
+
+	// a range covering the whole window matches the hashes of its blocks
+	from, to := current.Uint64()-256, current.Uint64()-1
+	output, err = call("arbBlockHashRange", from, to)
+	Require(t, err)
+	hashes := output[0].([][32]byte)
+	if len(hashes) != 256 {
+		Fail(t, "range has", len(hashes), "hashes")
+	}
+	for i, hash := range hashes {
+		expected, err := blockHash(arbmath.UintToBig(from + uint64(i)))
+		Require(t, err)
+		if hash != expected {
+			Fail(t, "range has hash", hash, "for block", from+uint64(i), "instead of", expected)
+		}
+	}
+
+	// ranges that straddle either end of the window are rejected whole
+	for _, bounds := range [][2]uint64{{from - 1, from + 10}, {to - 10, to + 1}, {to, to - 1}} {
+		if _, err := call("arbBlockHashRange", bounds[0], bounds[1]); err == nil {
+			Fail(t, "got hashes for blocks", bounds[0], "through", bounds[1])
+		}
+	}
+}
+
+func TestGetArbOSConfigHash(t *testing.T) {
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	context.State.SetFormatVersion(20)
+	arbSys := ArbSys{}
+	configHash := func() bytes32 {
+		t.Helper()
+		hash, err := arbSys.GetArbOSConfigHash(context, evm)
+		Require(t, err)
+		return hash
+	}
+	initial := configHash()
+
+	// state that isn't configuration doesn't change the hash
+	evm.StateDB.AddBalance(common.HexToAddress("0x0102"), big.NewInt(1e18))
+	_, err := context.State.AddressTable().Register(common.HexToAddress("0x0304"))
+	Require(t, err)
+	Require(t, context.State.L2PricingState().SetGasBacklog(1000000))
+	Require(t, context.State.L1PricingState().SetPricePerUnit(big.NewInt(12345)))
+	if configHash() != initial {
+		Fail(t, "config hash changed with state that isn't configuration")
+	}
+
+	// but each parameter does
+	l2p := context.State.L2PricingState()
+	speedLimit, err := l2p.SpeedLimitPerSecond()
+	Require(t, err)
+	Require(t, l2p.SetSpeedLimitPerSecond(speedLimit+1))
+	changed := configHash()
+	if changed == initial {
+		Fail(t, "config hash didn't change with the speed limit")
+	}
+	Require(t, context.State.RetryableState().SetMinDeposit(big.NewInt(1)))
+	if configHash() == changed || configHash() == initial {
+		Fail(t, "config hash didn't change with the minimum retryable deposit")
+	}
+
+	// and is only a function of their values
+	Require(t, context.State.RetryableState().SetMinDeposit(common.Big0))
+	Require(t, l2p.SetSpeedLimitPerSecond(speedLimit))
+	if configHash() != initial {
+		Fail(t, "config hash differs after restoring the configuration")
+	}
+}
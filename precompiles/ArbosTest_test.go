@@ -0,0 +1,73 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
+
+	templates "github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+func TestBurnArbGas(t *testing.T) {
+	evm := newMockEVMForTesting()
+	con := ArbosTest{}
+
+	for _, amount := range []uint64{0, 1, params.TxGas, 7000000} {
+		context := testContext(common.Address{}, evm)
+		Require(t, con.BurnArbGas(context, arbmath.UintToBig(amount)))
+		if context.Burned() != amount {
+			Fail(t, "asked to burn", amount, "but burned", context.Burned())
+		}
+	}
+
+	overflow := arbmath.BigAdd(arbmath.UintToBig(math.MaxUint64), big.NewInt(1))
+	for _, amount := range []*big.Int{overflow, big.NewInt(-1)} {
+		context := testContext(common.Address{}, evm)
+		if err := con.BurnArbGas(context, amount); err == nil {
+			Fail(t, "burned an amount that doesn't fit in a uint64", amount)
+		}
+		if context.Burned() != 0 {
+			Fail(t, "burned gas for an invalid amount", context.Burned())
+		}
+	}
+}
+
+func TestBurnArbGasCall(t *testing.T) {
+	evm := newMockEVMForTesting()
+	testABI, err := templates.ArbosTestMetaData.GetAbi()
+	Require(t, err)
+	address := common.HexToAddress("69")
+
+	call := func(amount *big.Int, gas uint64) (uint64, error) {
+		t.Helper()
+		input, err := testABI.Pack("burnArbGas", amount)
+		Require(t, err)
+		_, gasLeft, err := Precompiles()[address].Call(input, address, address, common.Address{}, big.NewInt(0), false, gas, evm)
+		return gas - gasLeft, err
+	}
+
+	// the call should use the requested amount on top of copying in the argument
+	argsCost := params.CopyGas
+	supplied := uint64(1000000)
+	for _, amount := range []uint64{0, 12345, 500000} {
+		used, err := call(arbmath.UintToBig(amount), supplied)
+		Require(t, err)
+		if used != amount+argsCost {
+			Fail(t, "burning", amount, "used", used)
+		}
+	}
+
+	// burning more than the call was given consumes all of it
+	used, err := call(arbmath.UintToBig(2*supplied), supplied)
+	Require(t, err)
+	if used != supplied {
+		Fail(t, "burning more than supplied should use everything, but used", used)
+	}
+}
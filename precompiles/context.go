@@ -10,6 +10,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
 
 	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
@@ -33,6 +34,9 @@ type Context struct {
 	State       *arbosState.ArbosState
 	tracingInfo *util.TracingInfo
 	readOnly    bool
+
+	// the gas the framework will charge to return the method's result, for methods that must reserve it
+	returnDataCost uint64
 }
 
 func (c *Context) Burn(amount uint64) error {
@@ -73,6 +77,8 @@ func testContext(caller addr, evm mech) *Context {
 		gasLeft:     ^uint64(0),
 		tracingInfo: tracingInfo,
 		readOnly:    false,
+
+		returnDataCost: params.CopyGas,
 	}
 	state, err := arbosState.OpenArbosState(evm.StateDB, burn.NewSystemBurner(tracingInfo, false))
 	if err != nil {
@@ -0,0 +1,100 @@
+// Copyright 2023, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	templates "github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+)
+
+// gasParityCase is a single precompile call that should use the same gas whether it's run while
+// estimating gas or while executing. Differences between the two mean a cost computed up front
+// disagrees with what the method actually burns, so the estimate can't be trusted.
+type gasParityCase struct {
+	name   string
+	caller addr
+	method string // the solidity name of the method
+	args   []interface{}
+	// setup prepares the state before the call, and is run for each mode against a fresh state
+	setup func(t *testing.T, evm mech)
+	// tolerance is how much gas the two modes may differ by, which should be zero unless a
+	// method deliberately pads its estimate
+	tolerance uint64
+}
+
+const gasParityGasLimit = 1000000
+
+func gasUsedInRunMode(
+	t *testing.T, address addr, contract *abi.ABI, test gasParityCase, runMode core.MessageRunMode,
+) (uint64, error) {
+	t.Helper()
+	evm := newMockEVMForTestingWithVersionAndRunMode(nil, runMode)
+	if test.setup != nil {
+		test.setup(t, evm)
+	}
+	input, err := contract.Pack(test.method, test.args...)
+	Require(t, err, test.name)
+	_, gasLeft, err := Precompiles()[address].Call(
+		input, address, address, test.caller, big.NewInt(0), false, gasParityGasLimit, evm,
+	)
+	return gasParityGasLimit - gasLeft, err
+}
+
+// testGasParity runs each case in gas estimation and execution modes, and checks that both
+// succeed or fail together while using the same gas, within the case's tolerance.
+func testGasParity(t *testing.T, address addr, contract *abi.ABI, cases []gasParityCase) {
+	t.Helper()
+	for _, test := range cases {
+		estimated, estimateErr := gasUsedInRunMode(t, address, contract, test, core.MessageGasEstimationMode)
+		executed, executeErr := gasUsedInRunMode(t, address, contract, test, core.MessageCommitMode)
+		if (estimateErr == nil) != (executeErr == nil) {
+			Fail(t, test.name, "succeeded in only one mode:", estimateErr, executeErr)
+		}
+		diff := estimated - executed
+		if executed > estimated {
+			diff = executed - estimated
+		}
+		if diff > test.tolerance {
+			Fail(t, test.name, "estimated", estimated, "gas but executing used", executed)
+		}
+	}
+}
+
+func TestArbRetryableTxGasParity(t *testing.T) {
+	retryABI, err := PrecompileABI(templates.ArbRetryableTxMetaData, "ArbRetryableTx")
+	Require(t, err)
+
+	id := common.BigToHash(big.NewInt(978645611142))
+	missing := common.BigToHash(big.NewInt(31337))
+	beneficiary := common.HexToAddress("0x0301040105090206")
+	createRetryable := func(t *testing.T, evm mech) {
+		t.Helper()
+		state := testContext(common.Address{}, evm).State
+		to := common.HexToAddress("0x06070809")
+		_, err := state.RetryableState().CreateRetryable(
+			id, evm.Context.Time+10000, common.HexToAddress("0x030405"), &to, big.NewInt(0), beneficiary, make([]byte, 100),
+		)
+		Require(t, err)
+	}
+
+	testGasParity(t, types.ArbRetryableTxAddress, retryABI, []gasParityCase{
+		{name: "getLifetime", method: "getLifetime"},
+		{name: "getTimeout", method: "getTimeout", args: []interface{}{id}, setup: createRetryable},
+		{name: "getTimeout missing", method: "getTimeout", args: []interface{}{missing}},
+		{name: "keepalive", method: "keepalive", args: []interface{}{id}, setup: createRetryable},
+		{name: "keepalive missing", method: "keepalive", args: []interface{}{missing}},
+		{name: "getBeneficiary", method: "getBeneficiary", args: []interface{}{id}, setup: createRetryable},
+		{name: "getCurrentRedeemer", method: "getCurrentRedeemer"},
+		{name: "redeem", method: "redeem", args: []interface{}{id}, setup: createRetryable},
+		{name: "cancel", method: "cancel", args: []interface{}{id}, caller: beneficiary, setup: createRetryable},
+		{name: "cancel not beneficiary", method: "cancel", args: []interface{}{id}, setup: createRetryable},
+	})
+}
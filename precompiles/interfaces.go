@@ -0,0 +1,149 @@
+// Copyright 2021-2024, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+// pendingInterfaces declares the methods, events, and errors of each precompile that are newer than the solidity
+// interfaces its bindings were generated from. Only the entries the bindings lack are used, so each one can be
+// deleted once the interfaces that declare it are pinned.
+var pendingInterfaces = map[string]string{
+	"ArbAddressTable": `[
+		{"type": "function", "name": "lookupAddresses", "stateMutability": "view", "inputs": [{"name": "indices", "type": "uint64[]"}], "outputs": [{"name": "", "type": "address[]"}]}
+	]`,
+	"ArbAggregator": `[
+		{"type": "function", "name": "isBatchPoster", "stateMutability": "view", "inputs": [{"name": "account", "type": "address"}], "outputs": [{"name": "", "type": "bool"}]},
+		{"type": "function", "name": "getLastBatchL1Block", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint64"}]}
+	]`,
+	"ArbGasInfo": `[
+		{"type": "function", "name": "getGasPricesInArbGas", "stateMutability": "view", "inputs": [], "outputs": [{"name": "base", "type": "uint256"}, {"name": "congestion", "type": "uint256"}, {"name": "total", "type": "uint256"}]},
+		{"type": "function", "name": "getPricingParams", "stateMutability": "view", "inputs": [], "outputs": [{"name": "l2BaseFee", "type": "uint256"}, {"name": "minL2BaseFee", "type": "uint256"}, {"name": "l1BaseFeeEstimate", "type": "uint256"}, {"name": "l1PricePerByte", "type": "uint256"}]},
+		{"type": "function", "name": "getCurrentL1DataFeePerByte", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint256"}]},
+		{"type": "function", "name": "getBrotliCompressionLevel", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint64"}]},
+		{"type": "function", "name": "getCollectTips", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "bool"}]},
+		{"type": "function", "name": "getSpeedLimitUsage", "stateMutability": "view", "inputs": [], "outputs": [{"name": "limit", "type": "uint64"}, {"name": "currentBacklog", "type": "uint64"}]},
+		{"type": "function", "name": "getBlockGasUsedSoFar", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint64"}]},
+		{"type": "function", "name": "getL1PricingUpdateTime", "stateMutability": "view", "inputs": [], "outputs": [{"name": "l2BlockTime", "type": "uint64"}, {"name": "l1BaseFeeUpdateTime", "type": "uint64"}]},
+		{"type": "function", "name": "getPosterFundsDueByEpoch", "stateMutability": "view", "inputs": [{"name": "poster", "type": "address"}, {"name": "numEpochs", "type": "uint64"}], "outputs": [{"name": "", "type": "uint256[]"}]},
+		{"type": "function", "name": "getGasEstimateForData", "stateMutability": "nonpayable", "inputs": [{"name": "to", "type": "address"}, {"name": "data", "type": "bytes"}], "outputs": [{"name": "", "type": "uint64"}]}
+	]`,
+	"ArbInfo": `[
+		{"type": "function", "name": "getBalances", "stateMutability": "view", "inputs": [{"name": "accounts", "type": "address[]"}], "outputs": [{"name": "", "type": "uint256[]"}]}
+	]`,
+	"ArbOwner": `[
+		{"type": "function", "name": "updateL1BaseFeeEstimate", "stateMutability": "nonpayable", "inputs": [{"name": "observedL1BaseFee", "type": "uint256"}], "outputs": []},
+		{"type": "function", "name": "setSpeedLimitPerSecond", "stateMutability": "nonpayable", "inputs": [{"name": "limit", "type": "uint64"}], "outputs": []},
+		{"type": "function", "name": "setMinimumKeepaliveCost", "stateMutability": "nonpayable", "inputs": [{"name": "cost", "type": "uint64"}], "outputs": []},
+		{"type": "function", "name": "setCollectTips", "stateMutability": "nonpayable", "inputs": [{"name": "collect", "type": "bool"}], "outputs": []},
+		{"type": "function", "name": "setPrecompileMethodGas", "stateMutability": "nonpayable", "inputs": [{"name": "precompile", "type": "address"}, {"name": "method", "type": "bytes4"}, {"name": "gas", "type": "uint64"}], "outputs": []},
+		{"type": "function", "name": "setRetryableCreationAllowlist", "stateMutability": "nonpayable", "inputs": [{"name": "enabled", "type": "bool"}], "outputs": []},
+		{"type": "function", "name": "addRetryableCreator", "stateMutability": "nonpayable", "inputs": [{"name": "creator", "type": "address"}], "outputs": []},
+		{"type": "function", "name": "removeRetryableCreator", "stateMutability": "nonpayable", "inputs": [{"name": "creator", "type": "address"}], "outputs": []},
+		{"type": "function", "name": "migrateRetryableStorage", "stateMutability": "nonpayable", "inputs": [{"name": "ticketIds", "type": "bytes32[]"}], "outputs": [{"name": "", "type": "uint64"}]},
+		{"type": "function", "name": "setSimulationGasLimit", "stateMutability": "nonpayable", "inputs": [{"name": "limit", "type": "uint64"}], "outputs": []},
+		{"type": "function", "name": "setExpiryDeletionReward", "stateMutability": "nonpayable", "inputs": [{"name": "weiPerWord", "type": "uint256"}], "outputs": []},
+		{"type": "function", "name": "setRetryableExpiryGracePeriod", "stateMutability": "nonpayable", "inputs": [{"name": "seconds_", "type": "uint64"}], "outputs": []},
+		{"type": "function", "name": "setMinRetryableDeposit", "stateMutability": "nonpayable", "inputs": [{"name": "minimum", "type": "uint256"}], "outputs": []},
+		{"type": "function", "name": "setRetryableMaxTries", "stateMutability": "nonpayable", "inputs": [{"name": "maxTries", "type": "uint64"}], "outputs": []},
+		{"type": "function", "name": "setMaxRetryablesPerBlock", "stateMutability": "nonpayable", "inputs": [{"name": "max", "type": "uint64"}], "outputs": []},
+		{"type": "function", "name": "setIsBatchPoster", "stateMutability": "nonpayable", "inputs": [{"name": "batchPoster", "type": "address"}, {"name": "isBatchPoster", "type": "bool"}], "outputs": []},
+		{"type": "function", "name": "setChainId", "stateMutability": "nonpayable", "inputs": [{"name": "chainId", "type": "uint256"}], "outputs": []},
+		{"type": "event", "name": "L2BaseFeeSet", "inputs": [{"indexed": false, "name": "baseFee", "type": "uint256"}], "anonymous": false},
+		{"type": "event", "name": "L1PricingRewardRecipientChanged", "inputs": [{"indexed": true, "name": "previous", "type": "address"}, {"indexed": true, "name": "recipient", "type": "address"}], "anonymous": false}
+	]`,
+	"ArbOwnerPublic": `[
+		{"type": "function", "name": "getMinimumKeepaliveCost", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint64"}]}
+	]`,
+	"ArbRetryableTx": `[
+		{"type": "function", "name": "redeemAndForward", "stateMutability": "nonpayable", "inputs": [{"name": "ticketId", "type": "bytes32"}, {"name": "forwardTo", "type": "address"}, {"name": "forwardData", "type": "bytes"}], "outputs": [{"name": "", "type": "bytes32"}]},
+		{"type": "function", "name": "redeemNoDonate", "stateMutability": "nonpayable", "inputs": [{"name": "ticketId", "type": "bytes32"}, {"name": "gasLimit", "type": "uint64"}], "outputs": [{"name": "", "type": "bytes32"}]},
+		{"type": "function", "name": "getTicketForRedeem", "stateMutability": "view", "inputs": [{"name": "redeemTxId", "type": "bytes32"}, {"name": "sequenceNum", "type": "uint64"}], "outputs": [{"name": "", "type": "bytes32"}]},
+		{"type": "function", "name": "getMaxLifetimeWindow", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint256"}]},
+		{"type": "function", "name": "getSecondsUntilExpiry", "stateMutability": "view", "inputs": [{"name": "ticketId", "type": "bytes32"}], "outputs": [{"name": "", "type": "int64"}]},
+		{"type": "function", "name": "calculateTicketId", "stateMutability": "view", "inputs": [{"name": "sender", "type": "address"}, {"name": "messageNum", "type": "uint64"}, {"name": "l1BaseFee", "type": "uint256"}, {"name": "deposit", "type": "uint256"}, {"name": "to", "type": "address"}, {"name": "l2CallValue", "type": "uint256"}, {"name": "maxSubmissionFee", "type": "uint256"}, {"name": "excessFeeRefundAddress", "type": "address"}, {"name": "callValueRefundAddress", "type": "address"}, {"name": "gasLimit", "type": "uint64"}, {"name": "maxFeePerGas", "type": "uint256"}, {"name": "data", "type": "bytes"}], "outputs": [{"name": "", "type": "bytes32"}]},
+		{"type": "function", "name": "getL2SubmissionCount", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint64"}]},
+		{"type": "function", "name": "sweepExpired", "stateMutability": "nonpayable", "inputs": [{"name": "maxEntries", "type": "uint64"}], "outputs": [{"name": "", "type": "uint256"}]},
+		{"type": "function", "name": "getMinRetryableDeposit", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint256"}]},
+		{"type": "function", "name": "getMaxTries", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint64"}]},
+		{"type": "function", "name": "getExpiryGracePeriod", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint64"}]},
+		{"type": "function", "name": "getEscrowedCallValue", "stateMutability": "view", "inputs": [{"name": "ticketId", "type": "bytes32"}], "outputs": [{"name": "", "type": "uint256"}]},
+		{"type": "function", "name": "getAutoRedeemResult", "stateMutability": "view", "inputs": [{"name": "ticketId", "type": "bytes32"}], "outputs": [{"name": "attempted", "type": "bool"}, {"name": "succeeded", "type": "bool"}, {"name": "redeemTxId", "type": "bytes32"}]},
+		{"type": "function", "name": "getPendingSubmissionRefund", "stateMutability": "view", "inputs": [{"name": "ticketId", "type": "bytes32"}], "outputs": [{"name": "", "type": "uint256"}]},
+		{"type": "function", "name": "getRetryablesByBeneficiary", "stateMutability": "view", "inputs": [{"name": "beneficiary", "type": "address"}, {"name": "start", "type": "uint64"}, {"name": "count", "type": "uint64"}], "outputs": [{"name": "", "type": "bytes32[]"}]},
+		{"type": "function", "name": "cancelAndWithdraw", "stateMutability": "nonpayable", "inputs": [{"name": "ticketId", "type": "bytes32"}, {"name": "l1Destination", "type": "address"}], "outputs": [{"name": "", "type": "uint256"}]},
+		{"type": "function", "name": "approveRedeemer", "stateMutability": "nonpayable", "inputs": [{"name": "ticketId", "type": "bytes32"}, {"name": "redeemer", "type": "address"}], "outputs": []},
+		{"type": "function", "name": "transferBeneficiary", "stateMutability": "nonpayable", "inputs": [{"name": "ticketId", "type": "bytes32"}, {"name": "newBeneficiary", "type": "address"}], "outputs": []},
+		{"type": "function", "name": "submitRetryableFromL2", "stateMutability": "nonpayable", "inputs": [{"name": "to", "type": "address"}, {"name": "l2CallValue", "type": "uint256"}, {"name": "maxSubmissionCost", "type": "uint256"}, {"name": "excessFeeRefundAddress", "type": "address"}, {"name": "callValueRefundAddress", "type": "address"}, {"name": "gasLimit", "type": "uint64"}, {"name": "maxFeePerGas", "type": "uint256"}, {"name": "data", "type": "bytes"}], "outputs": [{"name": "", "type": "bytes32"}]},
+		{"type": "event", "name": "Expired", "inputs": [{"indexed": true, "name": "ticketId", "type": "bytes32"}], "anonymous": false},
+		{"type": "event", "name": "RetryableCreationRateLimited", "inputs": [{"indexed": true, "name": "ticketId", "type": "bytes32"}, {"indexed": false, "name": "maxPerBlock", "type": "uint64"}], "anonymous": false},
+		{"type": "event", "name": "BeneficiaryTransferred", "inputs": [{"indexed": true, "name": "ticketId", "type": "bytes32"}, {"indexed": true, "name": "previous", "type": "address"}, {"indexed": true, "name": "beneficiary", "type": "address"}], "anonymous": false},
+		{"type": "event", "name": "RedeemerApproved", "inputs": [{"indexed": true, "name": "ticketId", "type": "bytes32"}, {"indexed": true, "name": "redeemer", "type": "address"}], "anonymous": false},
+		{"type": "error", "name": "RetryableFeeCapExceeded", "inputs": [{"name": "ticketId", "type": "bytes32"}, {"name": "basefee", "type": "uint256"}, {"name": "maxFeePerGas", "type": "uint256"}]}
+	]`,
+	"ArbStatistics": `[
+		{"type": "function", "name": "getContractCount", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint64"}]},
+		{"type": "function", "name": "getAccountCount", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint64"}]}
+	]`,
+	"ArbSys": `[
+		{"type": "function", "name": "getCurrentL1Context", "stateMutability": "view", "inputs": [], "outputs": [{"name": "l1BlockNumber", "type": "uint64"}, {"name": "l1Timestamp", "type": "uint64"}]},
+		{"type": "function", "name": "arbBlockHashRange", "stateMutability": "view", "inputs": [{"name": "from", "type": "uint64"}, {"name": "to", "type": "uint64"}], "outputs": [{"name": "", "type": "bytes32[]"}]},
+		{"type": "function", "name": "getChainConfig", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "bytes"}]},
+		{"type": "function", "name": "sendTxToL1WithMetadata", "stateMutability": "nonpayable", "inputs": [{"name": "destination", "type": "address"}, {"name": "calldataForL1", "type": "bytes"}, {"name": "metadata", "type": "bytes"}], "outputs": [{"name": "", "type": "uint256"}]},
+		{"type": "function", "name": "getL2ToL1TxCount", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint64"}]},
+		{"type": "function", "name": "getL1BaseFeeEstimate", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint256"}]},
+		{"type": "function", "name": "getL2BaseFeeComponents", "stateMutability": "view", "inputs": [], "outputs": [{"name": "floor", "type": "uint256"}, {"name": "congestion", "type": "uint256"}]},
+		{"type": "function", "name": "getInboxMessageCount", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint64"}]},
+		{"type": "function", "name": "getFeatureFlags", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint64"}]},
+		{"type": "function", "name": "getArbOSConfigHash", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "bytes32"}]},
+		{"type": "function", "name": "isPrecompileMethodAvailable", "stateMutability": "view", "inputs": [{"name": "precompile", "type": "address"}, {"name": "selector", "type": "bytes4"}], "outputs": [{"name": "", "type": "bool"}]},
+		{"type": "event", "name": "L2ToL1TxMetadata", "inputs": [{"indexed": true, "name": "position", "type": "uint256"}, {"indexed": false, "name": "metadata", "type": "bytes"}], "anonymous": false},
+		{"type": "event", "name": "ArbOSUpgraded", "inputs": [{"indexed": false, "name": "previousVersion", "type": "uint64"}, {"indexed": false, "name": "newVersion", "type": "uint64"}], "anonymous": false}
+	]`,
+	"NodeInterface": `[
+		{"type": "function", "name": "getBatchCount", "stateMutability": "view", "inputs": [], "outputs": [{"name": "", "type": "uint64"}]},
+		{"type": "function", "name": "getBatchMetadata", "stateMutability": "view", "inputs": [{"name": "batchNum", "type": "uint64"}], "outputs": [{"name": "l1Block", "type": "uint64"}, {"name": "firstL2Block", "type": "uint64"}, {"name": "lastL2Block", "type": "uint64"}]},
+		{"type": "function", "name": "simulateSubmitRetryable", "stateMutability": "nonpayable", "inputs": [{"name": "sender", "type": "address"}, {"name": "deposit", "type": "uint256"}, {"name": "to", "type": "address"}, {"name": "l2CallValue", "type": "uint256"}, {"name": "maxSubmissionFee", "type": "uint256"}, {"name": "excessFeeRefundAddress", "type": "address"}, {"name": "callValueRefundAddress", "type": "address"}, {"name": "gasLimit", "type": "uint64"}, {"name": "maxFeePerGas", "type": "uint256"}, {"name": "data", "type": "bytes"}, {"name": "l1BaseFee", "type": "uint256"}, {"name": "messageNum", "type": "uint64"}], "outputs": [{"name": "ticketId", "type": "bytes32"}, {"name": "submissionFee", "type": "uint256"}, {"name": "autoRedeemSucceeds", "type": "bool"}]},
+		{"type": "function", "name": "lookupRedeemTx", "stateMutability": "view", "inputs": [{"name": "redeemTxHash", "type": "bytes32"}], "outputs": [{"name": "ticketId", "type": "bytes32"}, {"name": "sequenceNum", "type": "uint64"}]},
+		{"type": "function", "name": "getRetryableInfoAtBlock", "stateMutability": "view", "inputs": [{"name": "ticketId", "type": "bytes32"}, {"name": "l2Block", "type": "uint64"}], "outputs": [{"name": "beneficiary", "type": "address"}, {"name": "timeout", "type": "uint64"}, {"name": "numTries", "type": "uint64"}]},
+		{"type": "function", "name": "getStatsAtBlock", "stateMutability": "view", "inputs": [{"name": "l2Block", "type": "uint64"}], "outputs": [{"name": "blockNum", "type": "uint256"}, {"name": "classicNumAccounts", "type": "uint256"}, {"name": "classicStorageSum", "type": "uint256"}, {"name": "classicGasSum", "type": "uint256"}, {"name": "classicNumTxes", "type": "uint256"}, {"name": "classicNumContracts", "type": "uint256"}, {"name": "contracts", "type": "uint64"}, {"name": "accounts", "type": "uint64"}]},
+		{"type": "function", "name": "estimateOutboxExecution", "stateMutability": "view", "inputs": [{"name": "leafIndex", "type": "uint64"}], "outputs": [{"name": "", "type": "uint64"}]}
+	]`,
+}
+
+// PrecompileABI is the ABI of a precompile's bindings along with the parts of its interface that they don't have yet
+func PrecompileABI(metadata *bind.MetaData, contract string) (*abi.ABI, error) {
+	source, err := abi.JSON(strings.NewReader(metadata.ABI))
+	if err != nil {
+		return nil, err
+	}
+	pendingJSON, ok := pendingInterfaces[contract]
+	if !ok {
+		return &source, nil
+	}
+	pending, err := abi.JSON(strings.NewReader(pendingJSON))
+	if err != nil {
+		return nil, err
+	}
+	for name, method := range pending.Methods {
+		if _, ok := source.Methods[name]; !ok {
+			source.Methods[name] = method
+		}
+	}
+	for name, event := range pending.Events {
+		if _, ok := source.Events[name]; !ok {
+			source.Events[name] = event
+		}
+	}
+	for name, solErr := range pending.Errors {
+		if _, ok := source.Errors[name]; !ok {
+			source.Errors[name] = solErr
+		}
+	}
+	return &source, nil
+}
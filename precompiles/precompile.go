@@ -74,6 +74,10 @@ type PrecompileMethod struct {
 	purity       purity
 	handler      reflect.Method
 	arbosVersion uint64
+	// the gas to copy out the method's return data, which is exact for fixed-size outputs
+	returnDataCost uint64
+	// whether the method chooses how much of the supplied gas it uses, so its cost can't be overridden
+	donatesGas bool
 }
 
 type PrecompileEvent struct {
@@ -118,14 +122,14 @@ func (e *SolError) Error() string {
 // MakePrecompile makes a precompile for the given hardhat-to-geth bindings, ensuring that the implementer
 // supports each method.
 func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Precompile) {
-	source, err := abi.JSON(strings.NewReader(metadata.ABI))
+	implementerType := reflect.TypeOf(implementer)
+	contract := implementerType.Elem().Name()
+
+	source, err := PrecompileABI(metadata, contract)
 	if err != nil {
 		log.Crit("Bad ABI")
 	}
 
-	implementerType := reflect.TypeOf(implementer)
-	contract := implementerType.Elem().Name()
-
 	_, ok := implementerType.Elem().FieldByName("Address")
 	if !ok {
 		log.Crit("Implementer for precompile ", contract, " is missing an Address field")
@@ -227,6 +231,8 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 			purity,
 			handler,
 			0,
+			returnDataCost(method.Outputs),
+			false,
 		}
 		methods[id] = &method
 		methodsByName[name] = &method
@@ -515,6 +521,41 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, *Pr
 	}
 }
 
+// returnDataCost computes what Call will charge to copy out a method's results by encoding zero values of the
+// method's outputs. This is exact for fixed-size types, and a lower bound for dynamic ones.
+func returnDataCost(outputs abi.Arguments) uint64 {
+	values := make([]interface{}, len(outputs))
+	for i, output := range outputs {
+		values[i] = zeroValue(output.Type.GetType()).Interface()
+	}
+	encoded, err := outputs.PackValues(values)
+	if err != nil {
+		log.Crit("failed to encode precompile outputs", "err", err)
+	}
+	return params.CopyGas * arbmath.WordsForBytes(uint64(len(encoded)))
+}
+
+// zeroValue makes an encodable zero of the given type, allocating pointers like *big.Int that abi can't pack as nil
+func zeroValue(ty reflect.Type) reflect.Value {
+	value := reflect.New(ty).Elem()
+	switch ty.Kind() {
+	case reflect.Ptr:
+		value = reflect.New(ty.Elem())
+		value.Elem().Set(zeroValue(ty.Elem()))
+	case reflect.Struct:
+		for i := 0; i < ty.NumField(); i++ {
+			if ty.Field(i).IsExported() {
+				value.Field(i).Set(zeroValue(ty.Field(i).Type))
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < ty.Len(); i++ {
+			value.Index(i).Set(zeroValue(ty.Elem()))
+		}
+	}
+	return value
+}
+
 func Precompiles() map[addr]ArbosPrecompile {
 
 	//nolint:gocritic
@@ -529,17 +570,34 @@ func Precompiles() map[addr]ArbosPrecompile {
 		return impl.Precompile()
 	}
 
-	insert(MakePrecompile(templates.ArbInfoMetaData, &ArbInfo{Address: hex("65")}))
-	insert(MakePrecompile(templates.ArbAddressTableMetaData, &ArbAddressTable{Address: hex("66")}))
+	ArbInfo := insert(MakePrecompile(templates.ArbInfoMetaData, &ArbInfo{Address: hex("65")}))
+	ArbInfo.methodsByName["GetBalances"].arbosVersion = 20
+	ArbAddressTable := insert(MakePrecompile(templates.ArbAddressTableMetaData, &ArbAddressTable{Address: hex("66")}))
+	ArbAddressTable.methodsByName["LookupAddresses"].arbosVersion = 20
 	insert(MakePrecompile(templates.ArbBLSMetaData, &ArbBLS{Address: hex("67")}))
 	insert(MakePrecompile(templates.ArbFunctionTableMetaData, &ArbFunctionTable{Address: hex("68")}))
-	insert(MakePrecompile(templates.ArbosTestMetaData, &ArbosTest{Address: hex("69")}))
+	ArbosTest := insert(MakePrecompile(templates.ArbosTestMetaData, &ArbosTest{Address: hex("69")}))
+	ArbosTest.methodsByName["BurnArbGas"].donatesGas = true
 	ArbGasInfo := insert(MakePrecompile(templates.ArbGasInfoMetaData, &ArbGasInfo{Address: hex("6c")}))
 	ArbGasInfo.methodsByName["GetL1FeesAvailable"].arbosVersion = 10
 	ArbGasInfo.methodsByName["GetL1RewardRate"].arbosVersion = 11
 	ArbGasInfo.methodsByName["GetL1RewardRecipient"].arbosVersion = 11
-	insert(MakePrecompile(templates.ArbAggregatorMetaData, &ArbAggregator{Address: hex("6d")}))
-	insert(MakePrecompile(templates.ArbStatisticsMetaData, &ArbStatistics{Address: hex("6f")}))
+	ArbGasInfo.methodsByName["GetSpeedLimitUsage"].arbosVersion = 20
+	ArbGasInfo.methodsByName["GetBrotliCompressionLevel"].arbosVersion = 20
+	ArbGasInfo.methodsByName["GetCollectTips"].arbosVersion = 20
+	ArbGasInfo.methodsByName["GetPricingParams"].arbosVersion = 20
+	ArbGasInfo.methodsByName["GetGasPricesInArbGas"].arbosVersion = 20
+	ArbGasInfo.methodsByName["GetL1PricingUpdateTime"].arbosVersion = 20
+	ArbGasInfo.methodsByName["GetBlockGasUsedSoFar"].arbosVersion = 20
+	ArbGasInfo.methodsByName["GetPosterFundsDueByEpoch"].arbosVersion = 20
+	ArbGasInfo.methodsByName["GetGasEstimateForData"].arbosVersion = 20
+	ArbGasInfo.methodsByName["GetCurrentL1DataFeePerByte"].arbosVersion = 20
+	ArbAggregator := insert(MakePrecompile(templates.ArbAggregatorMetaData, &ArbAggregator{Address: hex("6d")}))
+	ArbAggregator.methodsByName["IsBatchPoster"].arbosVersion = 20
+	ArbAggregator.methodsByName["GetLastBatchL1Block"].arbosVersion = 20
+	ArbStatistics := insert(MakePrecompile(templates.ArbStatisticsMetaData, &ArbStatistics{Address: hex("6f")}))
+	ArbStatistics.methodsByName["GetContractCount"].arbosVersion = 20
+	ArbStatistics.methodsByName["GetAccountCount"].arbosVersion = 20
 
 	eventCtx := func(gasLimit uint64, err error) *Context {
 		if err != nil {
@@ -555,10 +613,33 @@ func Precompiles() map[addr]ArbosPrecompile {
 	ArbOwnerPublic.methodsByName["GetInfraFeeAccount"].arbosVersion = 5
 	ArbOwnerPublic.methodsByName["RectifyChainOwner"].arbosVersion = 11
 	ArbOwnerPublic.methodsByName["GetBrotliCompressionLevel"].arbosVersion = 20
+	ArbOwnerPublic.methodsByName["GetMinimumKeepaliveCost"].arbosVersion = 20
 
 	ArbRetryableImpl := &ArbRetryableTx{Address: types.ArbRetryableTxAddress}
 	ArbRetryable := insert(MakePrecompile(templates.ArbRetryableTxMetaData, ArbRetryableImpl))
 	arbos.ArbRetryableTxAddress = ArbRetryable.address
+	ArbRetryable.methodsByName["SubmitRetryableFromL2"].arbosVersion = 20
+	ArbRetryable.methodsByName["SubmitRetryableFromL2"].donatesGas = true
+	ArbRetryable.methodsByName["Redeem"].donatesGas = true
+	ArbRetryable.methodsByName["RedeemAndForward"].donatesGas = true
+	ArbRetryable.methodsByName["RedeemNoDonate"].arbosVersion = 20
+	ArbRetryable.methodsByName["GetAutoRedeemResult"].arbosVersion = 20
+	ArbRetryable.methodsByName["GetSecondsUntilExpiry"].arbosVersion = 20
+	ArbRetryable.methodsByName["CalculateTicketId"].arbosVersion = 20
+	ArbRetryable.methodsByName["GetL2SubmissionCount"].arbosVersion = 20
+	ArbRetryable.methodsByName["GetPendingSubmissionRefund"].arbosVersion = 20
+	ArbRetryable.methodsByName["GetRetryablesByBeneficiary"].arbosVersion = 20
+	ArbRetryable.methodsByName["TransferBeneficiary"].arbosVersion = 20
+	ArbRetryable.methodsByName["ApproveRedeemer"].arbosVersion = 20
+	ArbRetryable.methodsByName["GetExpiryGracePeriod"].arbosVersion = 20
+	ArbRetryable.methodsByName["GetEscrowedCallValue"].arbosVersion = 20
+	ArbRetryable.methodsByName["SweepExpired"].arbosVersion = 20
+	ArbRetryable.methodsByName["GetMinRetryableDeposit"].arbosVersion = 20
+	ArbRetryable.methodsByName["RedeemAndForward"].arbosVersion = 20
+	ArbRetryable.methodsByName["CancelAndWithdraw"].arbosVersion = 20
+	ArbRetryable.methodsByName["GetTicketForRedeem"].arbosVersion = 20
+	ArbRetryable.methodsByName["GetMaxTries"].arbosVersion = 20
+	ArbRetryable.methodsByName["GetMaxLifetimeWindow"].arbosVersion = 20
 	arbos.RedeemScheduledEventID = ArbRetryable.events["RedeemScheduled"].template.ID
 	arbos.EmitReedeemScheduledEvent = func(
 		evm mech, gas, nonce uint64, ticketId, retryTxHash bytes32,
@@ -574,11 +655,35 @@ func Precompiles() map[addr]ArbosPrecompile {
 		context := eventCtx(ArbRetryableImpl.TicketCreatedGasCost(hash{}))
 		return ArbRetryableImpl.TicketCreated(context, evm, ticketId)
 	}
-
-	ArbSys := insert(MakePrecompile(templates.ArbSysMetaData, &ArbSys{Address: types.ArbSysAddress}))
+	arbos.EmitExpiredEvent = func(evm mech, ticketId bytes32) error {
+		context := eventCtx(ArbRetryableImpl.ExpiredGasCost(hash{}))
+		return ArbRetryableImpl.Expired(context, evm, ticketId)
+	}
+	arbos.EmitRetryableCreationRateLimitedEvent = func(evm mech, ticketId bytes32, maxPerBlock uint64) error {
+		context := eventCtx(ArbRetryableImpl.RetryableCreationRateLimitedGasCost(hash{}, 0))
+		return ArbRetryableImpl.RetryableCreationRateLimited(context, evm, ticketId, maxPerBlock)
+	}
+
+	ArbSysImpl := &ArbSys{Address: types.ArbSysAddress}
+	ArbSys := insert(MakePrecompile(templates.ArbSysMetaData, ArbSysImpl))
+	ArbSys.methodsByName["GetL2ToL1TxCount"].arbosVersion = 20
+	ArbSys.methodsByName["GetChainConfig"].arbosVersion = 20
+	ArbSys.methodsByName["GetL1BaseFeeEstimate"].arbosVersion = 20
+	ArbSys.methodsByName["GetL2BaseFeeComponents"].arbosVersion = 20
+	ArbSys.methodsByName["SendTxToL1WithMetadata"].arbosVersion = 20
+	ArbSys.methodsByName["IsPrecompileMethodAvailable"].arbosVersion = 20
+	ArbSys.methodsByName["GetInboxMessageCount"].arbosVersion = 20
+	ArbSys.methodsByName["GetFeatureFlags"].arbosVersion = 20
+	ArbSys.methodsByName["ArbBlockHashRange"].arbosVersion = 20
+	ArbSys.methodsByName["GetCurrentL1Context"].arbosVersion = 20
+	ArbSys.methodsByName["GetArbOSConfigHash"].arbosVersion = 20
 	arbos.ArbSysAddress = ArbSys.address
 	arbos.L2ToL1TransactionEventID = ArbSys.events["L2ToL1Transaction"].template.ID
 	arbos.L2ToL1TxEventID = ArbSys.events["L2ToL1Tx"].template.ID
+	arbos.EmitArbOSUpgradedEvent = func(evm mech, previousVersion, newVersion uint64) error {
+		context := eventCtx(ArbSysImpl.ArbOSUpgradedGasCost(0, 0))
+		return ArbSysImpl.ArbOSUpgraded(context, evm, previousVersion, newVersion)
+	}
 
 	ArbOwnerImpl := &ArbOwner{Address: hex("70")}
 	emitOwnerActs := func(evm mech, method bytes4, owner addr, data []byte) error {
@@ -591,6 +696,23 @@ func Precompiles() map[addr]ArbosPrecompile {
 	ArbOwner.methodsByName["ReleaseL1PricerSurplusFunds"].arbosVersion = 10
 	ArbOwner.methodsByName["SetChainConfig"].arbosVersion = 11
 	ArbOwner.methodsByName["SetBrotliCompressionLevel"].arbosVersion = 20
+	ArbOwner.methodsByName["SetIsBatchPoster"].arbosVersion = 20
+	ArbOwner.methodsByName["SetMinimumKeepaliveCost"].arbosVersion = 20
+	ArbOwner.methodsByName["SetCollectTips"].arbosVersion = 20
+	ArbOwner.methodsByName["SetPrecompileMethodGas"].arbosVersion = 20
+	ArbOwner.methodsByName["SetRetryableCreationAllowlist"].arbosVersion = 20
+	ArbOwner.methodsByName["AddRetryableCreator"].arbosVersion = 20
+	ArbOwner.methodsByName["RemoveRetryableCreator"].arbosVersion = 20
+	ArbOwner.methodsByName["MigrateRetryableStorage"].arbosVersion = 20
+	ArbOwner.methodsByName["SetChainId"].arbosVersion = 20
+	ArbOwner.methodsByName["SetMaxRetryablesPerBlock"].arbosVersion = 20
+	ArbOwner.methodsByName["SetRetryableExpiryGracePeriod"].arbosVersion = 20
+	ArbOwner.methodsByName["SetExpiryDeletionReward"].arbosVersion = 20
+	ArbOwner.methodsByName["SetMinRetryableDeposit"].arbosVersion = 20
+	ArbOwner.methodsByName["SetRetryableMaxTries"].arbosVersion = 20
+	ArbOwner.methodsByName["UpdateL1BaseFeeEstimate"].arbosVersion = 20
+	ArbOwner.methodsByName["SetSpeedLimitPerSecond"].arbosVersion = 20
+	ArbOwner.methodsByName["SetSimulationGasLimit"].arbosVersion = 20
 
 	insert(ownerOnly(ArbOwnerImpl.Address, ArbOwner, emitOwnerActs))
 	insert(debugOnly(MakePrecompile(templates.ArbDebugMetaData, &ArbDebug{Address: hex("ff")})))
@@ -599,6 +721,8 @@ func Precompiles() map[addr]ArbosPrecompile {
 	arbos.InternalTxStartBlockMethodID = ArbosActs.GetMethodID("StartBlock")
 	arbos.InternalTxBatchPostingReportMethodID = ArbosActs.GetMethodID("BatchPostingReport")
 
+	ArbSysImpl.precompiles = contracts
+	ArbRetryableImpl.arbSys = ArbSysImpl
 	return contracts
 }
 
@@ -608,6 +732,15 @@ func (p *Precompile) CloneWithImpl(impl interface{}) *Precompile {
 	return &clone
 }
 
+// IsMethodAvailable reports whether calls to the method would be dispatched at the given ArbOS version
+func (p *Precompile) IsMethodAvailable(method bytes4, arbosVersion uint64) bool {
+	if arbosVersion < p.arbosVersion {
+		return false
+	}
+	handler, ok := p.methods[method]
+	return ok && arbosVersion >= handler.arbosVersion
+}
+
 func (p *Precompile) GetMethodID(name string) bytes4 {
 	method, ok := p.methodsByName[name]
 	if !ok {
@@ -666,6 +799,8 @@ func (p *Precompile) Call(
 		gasLeft:     gasSupplied,
 		readOnly:    method.purity <= view,
 		tracingInfo: util.NewTracingInfo(evm, caller, precompileAddress, util.TracingDuringEVM),
+
+		returnDataCost: method.returnDataCost,
 	}
 
 	argsCost := params.CopyGas * arbmath.WordsForBytes(uint64(len(input)-4))
@@ -674,6 +809,26 @@ func (p *Precompile) Call(
 		return nil, 0, vm.ErrExecutionReverted
 	}
 
+	if arbosVersion >= 20 && !method.donatesGas && arbosState.PrecompileMethodGasOverridden(evm.StateDB) {
+		// the chain owner may have raised the method's cost, which is charged if the call would've used less.
+		// Once any method is overridden, every call pays to look up its own override.
+		gasOverride, err := arbosState.PrecompileMethodGas(evm.StateDB, callerCtx, precompileAddress, id)
+		if err != nil {
+			return nil, callerCtx.gasLeft, vm.ErrExecutionReverted
+		}
+		if gasOverride != 0 {
+			if gasSupplied < gasOverride {
+				// user cannot afford the overridden cost
+				return nil, 0, vm.ErrExecutionReverted
+			}
+			defer func() {
+				if gasLeft != 0 && gasSupplied-gasLeft < gasOverride {
+					gasLeft = gasSupplied - gasOverride
+				}
+			}()
+		}
+	}
+
 	if method.purity != pure {
 		// impure methods may need the ArbOS state, so open & update the call context now
 		state, err := arbosState.OpenArbosState(evm.StateDB, callerCtx)
@@ -6,9 +6,12 @@ package precompiles
 import (
 	"bytes"
 	"math/big"
+	"strings"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -198,3 +201,165 @@ func (burner FatalBurner) Burn(amount uint64) error {
 	burner.gasLeft -= amount
 	return nil
 }
+
+func TestReturnDataCost(t *testing.T) {
+	arguments := func(types ...string) abi.Arguments {
+		t.Helper()
+		args := abi.Arguments{}
+		for _, ty := range types {
+			abiType, err := abi.NewType(ty, "", nil)
+			Require(t, err)
+			args = append(args, abi.Argument{Type: abiType})
+		}
+		return args
+	}
+
+	cases := []struct {
+		outputs []string
+		words   uint64
+	}{
+		{nil, 0},
+		{[]string{"bytes32"}, 1},
+		{[]string{"uint256", "uint256"}, 2},
+		{[]string{"address", "bool", "uint64"}, 3},
+		{[]string{"uint256[6]"}, 6},
+		{[]string{"bytes"}, 2}, // an offset & length when empty
+		{[]string{"bytes32", "uint256[]"}, 3},
+	}
+	for _, test := range cases {
+		cost := returnDataCost(arguments(test.outputs...))
+		if cost != test.words*params.CopyGas {
+			Fail(t, "outputs", test.outputs, "should cost", test.words, "words but cost", cost)
+		}
+	}
+
+	// methods that reserve gas for their results should see what the framework will charge
+	retryable := Precompiles()[types.ArbRetryableTxAddress].Precompile()
+	for _, name := range []string{"Redeem", "SubmitRetryableFromL2"} {
+		method := retryable.methodsByName[name]
+		if method.returnDataCost != returnDataCost(method.template.Outputs) || method.returnDataCost != params.CopyGas {
+			Fail(t, name, "reserves the wrong amount for its result", method.returnDataCost)
+		}
+	}
+	info := Precompiles()[common.HexToAddress("6c")].Precompile()
+	if cost := info.methodsByName["GetPricesInWei"].returnDataCost; cost != 6*params.CopyGas {
+		Fail(t, "GetPricesInWei's six results should cost six words, not", cost)
+	}
+}
+
+func TestPrecompileMethodGas(t *testing.T) {
+	sysABI, err := PrecompileABI(templates.ArbSysMetaData, "ArbSys")
+	Require(t, err)
+	input, err := sysABI.Pack("arbOSVersion")
+	Require(t, err)
+	other, err := sysABI.Pack("arbChainID")
+	Require(t, err)
+	method := *(*[4]byte)(input)
+	sys := types.ArbSysAddress
+
+	call := func(evm mech, input []byte) uint64 {
+		t.Helper()
+		gas := uint64(100000)
+		_, gasLeft, err := Precompiles()[sys].Call(input, sys, sys, common.Address{}, big.NewInt(0), false, gas, evm)
+		Require(t, err)
+		return gas - gasLeft
+	}
+
+	// overrides aren't consulted before ArbOS 20
+	evm := newMockEVMForTesting()
+	context := testContext(common.Address{}, evm)
+	defaultGas := call(evm, input)
+	defaultOther := call(evm, other)
+	Require(t, context.State.SetPrecompileMethodGas(sys, method, defaultGas+1))
+	if used := call(evm, input); used != defaultGas {
+		Fail(t, "override applied before ArbOS 20, using", used, "instead of", defaultGas)
+	}
+
+	// from ArbOS 20 calls pay nothing extra until a method is overridden
+	context.State.SetFormatVersion(20)
+	Require(t, context.State.SetPrecompileMethodGas(sys, method, 0))
+	if used := call(evm, input); used != defaultGas {
+		Fail(t, "method used", used, "gas without overrides instead of", defaultGas)
+	}
+	if used := call(evm, other); used != defaultOther {
+		Fail(t, "method used", used, "gas without overrides instead of", defaultOther)
+	}
+
+	// after which each call pays to read its override, which is a floor on the gas used
+	for _, override := range []uint64{defaultGas + 1234, 1} {
+		Require(t, ArbOwner{}.SetPrecompileMethodGas(context, evm, sys, method, override))
+		expected := arbmath.MaxInt(override, defaultGas+storage.StorageReadCost)
+		if used := call(evm, input); used != expected {
+			Fail(t, "overridden method used", used, "gas instead of", expected)
+		}
+		if used := call(evm, other); used != defaultOther+storage.StorageReadCost {
+			Fail(t, "overriding one method changed another's gas to", used)
+		}
+	}
+
+	// methods that choose how much gas they use ignore overrides
+	testABI, err := templates.ArbosTestMetaData.GetAbi()
+	Require(t, err)
+	burnInput, err := testABI.Pack("burnArbGas", big.NewInt(0))
+	Require(t, err)
+	burner := common.HexToAddress("69")
+	burnGas := func() uint64 {
+		t.Helper()
+		gas := uint64(100000)
+		_, gasLeft, err := Precompiles()[burner].Call(burnInput, burner, burner, common.Address{}, big.NewInt(0), false, gas, evm)
+		Require(t, err)
+		return gas - gasLeft
+	}
+	defaultBurn := burnGas()
+	Require(t, ArbOwner{}.SetPrecompileMethodGas(context, evm, burner, *(*[4]byte)(burnInput), defaultBurn+1000))
+	if used := burnGas(); used != defaultBurn {
+		Fail(t, "BurnArbGas used", used, "gas instead of", defaultBurn)
+	}
+
+	// a call that can't afford the override fails without running
+	Require(t, ArbOwner{}.SetPrecompileMethodGas(context, evm, sys, method, 5000))
+	if _, _, err := Precompiles()[sys].Call(input, sys, sys, common.Address{}, big.NewInt(0), false, 4999, evm); err == nil {
+		Fail(t, "call succeeded with less gas than the override")
+	}
+
+	// clearing the override restores the default
+	Require(t, ArbOwner{}.SetPrecompileMethodGas(context, evm, sys, method, 0))
+	if used := call(evm, input); used != defaultGas {
+		Fail(t, "cleared override used", used, "gas instead of the default", defaultGas)
+	}
+}
+
+func TestPendingInterfaces(t *testing.T) {
+	precompiles := make(map[string]*Precompile)
+	for _, precompile := range Precompiles() {
+		precompiles[precompile.Precompile().name] = precompile.Precompile()
+	}
+	for contract, pendingJSON := range pendingInterfaces {
+		pending, err := abi.JSON(strings.NewReader(pendingJSON))
+		Require(t, err, "bad pending interface for", contract)
+		if contract == "NodeInterface" {
+			// the node interface is only made by its own package, which checks it the same way on startup
+			continue
+		}
+		precompile, ok := precompiles[contract]
+		if !ok {
+			Fail(t, "pending interface for unknown precompile", contract)
+		}
+		for _, method := range pending.Methods {
+			id := *(*[4]byte)(method.ID)
+			if precompile.methods[id] == nil {
+				Fail(t, contract, "doesn't implement its pending method", method.Name)
+			}
+		}
+		for name := range pending.Events {
+			if _, ok := precompile.events[name]; !ok {
+				Fail(t, contract, "doesn't emit its pending event", name)
+			}
+		}
+		for name := range pending.Errors {
+			if _, ok := precompile.errors[name]; !ok {
+				Fail(t, contract, "doesn't return its pending error", name)
+			}
+		}
+	}
+}
@@ -86,10 +86,14 @@ type BlockValidatorConfig struct {
 	CurrentModuleRoot        string                        `koanf:"current-module-root"`         // TODO(magic) requires reinitialization on hot reload
 	PendingUpgradeModuleRoot string                        `koanf:"pending-upgrade-module-root"` // TODO(magic) requires StatelessBlockValidator recreation on hot reload
 	FailureIsFatal           bool                          `koanf:"failure-is-fatal" reload:"hot"`
+	EigenDAReadConcurrency   int                           `koanf:"eigenda-read-concurrency"`
 	Dangerous                BlockValidatorDangerousConfig `koanf:"dangerous"`
 }
 
 func (c *BlockValidatorConfig) Validate() error {
+	if c.EigenDAReadConcurrency < 1 {
+		return errors.New("block validator eigenda-read-concurrency must be at least 1")
+	}
 	return c.ValidationServer.Validate()
 }
 
@@ -108,6 +112,7 @@ func BlockValidatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.String(prefix+".current-module-root", DefaultBlockValidatorConfig.CurrentModuleRoot, "current wasm module root ('current' read from chain, 'latest' from machines/latest dir, or provide hash)")
 	f.String(prefix+".pending-upgrade-module-root", DefaultBlockValidatorConfig.PendingUpgradeModuleRoot, "pending upgrade wasm module root to additionally validate (hash, 'latest' or empty)")
 	f.Bool(prefix+".failure-is-fatal", DefaultBlockValidatorConfig.FailureIsFatal, "failing a validation is treated as a fatal error")
+	f.Int(prefix+".eigenda-read-concurrency", DefaultBlockValidatorConfig.EigenDAReadConcurrency, "maximum number of EigenDA blobs fetched at once while recording a validation")
 	BlockValidatorDangerousConfigAddOptions(prefix+".dangerous", f)
 }
 
@@ -124,6 +129,7 @@ var DefaultBlockValidatorConfig = BlockValidatorConfig{
 	CurrentModuleRoot:        "current",
 	PendingUpgradeModuleRoot: "latest",
 	FailureIsFatal:           true,
+	EigenDAReadConcurrency:   8,
 	Dangerous:                DefaultBlockValidatorDangerousConfig,
 }
 
@@ -136,6 +142,7 @@ var TestBlockValidatorConfig = BlockValidatorConfig{
 	CurrentModuleRoot:        "latest",
 	PendingUpgradeModuleRoot: "latest",
 	FailureIsFatal:           true,
+	EigenDAReadConcurrency:   8,
 	Dangerous:                DefaultBlockValidatorDangerousConfig,
 }
 
@@ -284,11 +284,21 @@ func (v *StatelessBlockValidator) ValidationEntryRecord(ctx context.Context, e *
 		}
 		e.DelayedMsg = delayedMsg
 	}
+	var eigenDABatches [][]byte
 	for _, batch := range e.BatchInfo {
 		if len(batch.Data) <= 40 {
 			continue
 		}
-		if !arbstate.IsDASMessageHeaderByte(batch.Data[40]) && eigenda.IsEigenDAMessageHeaderByte(batch.Data[40]) {
+		if eigenda.IsEigenDADualMessageHeaderByte(batch.Data[40]) {
+			// dual posted batches are derived from their calldata copy, so there's nothing to record
+			continue
+		}
+		if eigenda.IsEigenDAMessageHeaderByte(batch.Data[40]) || eigenda.IsEigenDACertifiedMessageHeaderByte(batch.Data[40]) {
+			if v.eigenDAService == nil {
+				log.Warn("EigenDA not configured, but sequencer message found with EigenDA header")
+			} else {
+				eigenDABatches = append(eigenDABatches, batch.Data[40:])
+			}
 			continue
 		}
 		if arbstate.IsDASMessageHeaderByte(batch.Data[40]) {
@@ -303,15 +313,11 @@ func (v *StatelessBlockValidator) ValidationEntryRecord(ctx context.Context, e *
 				}
 			}
 		}
-		if eigenda.IsEigenDAMessageHeaderByte(batch.Data[40]) {
-			if v.eigenDAService == nil {
-				log.Warn("EigenDA not configured, but sequencer message found with EigenDA header")
-			} else {
-				_, err := eigenda.RecoverPayloadFromEigenDABatch(ctx, batch.Data[41:], v.eigenDAService, e.Preimages)
-				if err != nil {
-					return err
-				}
-			}
+	}
+	if len(eigenDABatches) > 0 {
+		_, err := eigenda.RecoverPayloadsFromEigenDABatches(ctx, eigenDABatches, v.eigenDAService, e.Preimages, v.config.EigenDAReadConcurrency)
+		if err != nil {
+			return err
 		}
 	}
 
@@ -6,6 +6,7 @@ package arbtest
 import (
 	"context"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/ethereum/go-ethereum"
@@ -273,6 +274,51 @@ func TestComponentEstimate(t *testing.T) {
 	}
 }
 
+func TestComponentEstimateSimulationGasLimit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	auth := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	arbOwner, err := precompilesgen.NewArbOwner(common.HexToAddress("0x70"), builder.L2.Client)
+	Require(t, err)
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, builder.L2.Client)
+	Require(t, err)
+	opts := &bind.CallOpts{From: builder.L2Info.GetAddress("Owner")}
+	to := testhelpers.RandomAddress()
+	calldata := make([]byte, 1000)
+	for i := range calldata {
+		calldata[i] = 0xff
+	}
+
+	setLimit := func(limit uint64) {
+		t.Helper()
+		tx, err := arbOwner.SetSimulationGasLimit(&auth, limit)
+		Require(t, err)
+		_, err = builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+	}
+
+	// the calldata alone needs more gas than the limit allows
+	setLimit(params.TxGas)
+	_, err = nodeInterface.GasEstimateComponents(opts, to, false, calldata)
+	if err == nil || !strings.Contains(err.Error(), "simulation gas exceeded") {
+		Fatal(t, "expected the simulation to exceed its gas limit, got", err)
+	}
+
+	// the L1 component doesn't run the tx, so it can still be estimated within the limit
+	_, err = nodeInterface.GasEstimateL1Component(opts, to, false, calldata)
+	Require(t, err)
+
+	// 0 restores the default limit
+	setLimit(0)
+	_, err = nodeInterface.GasEstimateComponents(opts, to, false, calldata)
+	Require(t, err)
+}
+
 func TestDisableL1Charging(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
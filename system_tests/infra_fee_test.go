@@ -72,3 +72,82 @@ func TestInfraFee(t *testing.T) {
 		Fatal(t, infraFeeBalanceBefore, expectedFunds, infraFeeBalanceAfter, expectedBalanceAfter)
 	}
 }
+
+func TestInfraFeeTips(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 20
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	ownerTxOpts := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	ownerTxOpts.Context = ctx
+	ownerCallOpts := builder.L2Info.GetDefaultCallOpts("Owner", ctx)
+
+	arbowner, err := precompilesgen.NewArbOwner(common.HexToAddress("70"), builder.L2.Client)
+	Require(t, err)
+	arbownerPublic, err := precompilesgen.NewArbOwnerPublic(common.HexToAddress("6b"), builder.L2.Client)
+	Require(t, err)
+	arbGasInfo, err := precompilesgen.NewArbGasInfo(common.HexToAddress("6c"), builder.L2.Client)
+	Require(t, err)
+	networkFeeAddr, err := arbownerPublic.GetNetworkFeeAccount(ownerCallOpts)
+	Require(t, err)
+	infraFeeAddr := common.BytesToAddress(crypto.Keccak256([]byte{3, 2, 6}))
+	tx, err := arbowner.SetInfraFeeAccount(&ownerTxOpts, infraFeeAddr)
+	Require(t, err)
+	_, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+
+	_, simple := builder.L2.DeploySimple(t, ownerTxOpts)
+
+	// testTip sends a tx with a tip, checking the infra fee account receives its share of the basefee,
+	// plus the tip if tips are being collected
+	testTip := func(collect bool) {
+		t.Helper()
+		tx, err := arbowner.SetCollectTips(&ownerTxOpts, collect)
+		Require(t, err)
+		_, err = builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+		collecting, err := arbGasInfo.GetCollectTips(ownerCallOpts)
+		Require(t, err)
+		if collecting != collect {
+			Fatal(t, "tip collection should be", collect, "but is", collecting)
+		}
+
+		netFeeBalanceBefore, err := builder.L2.Client.BalanceAt(ctx, networkFeeAddr, nil)
+		Require(t, err)
+		infraFeeBalanceBefore, err := builder.L2.Client.BalanceAt(ctx, infraFeeAddr, nil)
+		Require(t, err)
+
+		tipOpts := ownerTxOpts
+		tipOpts.GasTipCap = arbmath.UintToBig(l2pricing.InitialBaseFeeWei)
+		tx, err = simple.Increment(&tipOpts)
+		Require(t, err)
+		receipt, err := builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+		l2GasUsed := receipt.GasUsed - receipt.GasUsedForL1
+		expectedFunds := arbmath.BigMulByUint(arbmath.UintToBig(l2pricing.InitialBaseFeeWei), l2GasUsed)
+		if collect {
+			expectedFunds = arbmath.BigAdd(expectedFunds, arbmath.BigMulByUint(tipOpts.GasTipCap, receipt.GasUsed))
+		}
+
+		netFeeBalanceAfter, err := builder.L2.Client.BalanceAt(ctx, networkFeeAddr, nil)
+		Require(t, err)
+		infraFeeBalanceAfter, err := builder.L2.Client.BalanceAt(ctx, infraFeeAddr, nil)
+		Require(t, err)
+		if !arbmath.BigEquals(netFeeBalanceBefore, netFeeBalanceAfter) {
+			Fatal(t, "network fee account was paid", netFeeBalanceBefore, netFeeBalanceAfter)
+		}
+		infraFunds := arbmath.BigSub(infraFeeBalanceAfter, infraFeeBalanceBefore)
+		if !arbmath.BigEquals(infraFunds, expectedFunds) {
+			Fatal(t, "collecting tips:", collect, "infra fee account received", infraFunds, "expected", expectedFunds)
+		}
+	}
+
+	testTip(false)
+	testTip(true)
+	testTip(false)
+}
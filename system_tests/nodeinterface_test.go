@@ -11,8 +11,11 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
 	"github.com/offchainlabs/nitro/arbos/util"
 	"github.com/offchainlabs/nitro/solgen/go/node_interfacegen"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/util/arbmath"
 )
 
 func TestL2BlockRangeForL1(t *testing.T) {
@@ -110,3 +113,200 @@ func TestGetL1Confirmations(t *testing.T) {
 		t.Fatalf("L1Confirmations for latest block %v is only %v (did not hit expected %v)", genesisBlock.Number(), l1Confs, numTransactions)
 	}
 }
+
+func TestGetBatchMetadata(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, builder.L2.Client)
+	Require(t, err)
+
+	// keep making blocks until a few batches have been posted
+	var count uint64
+	for i := 0; count < 3; i++ {
+		if i >= 1000 {
+			Fatal(t, "only", count, "batches were posted")
+		}
+		builder.L2.TransferBalance(t, "Owner", "Owner", common.Big0, builder.L2Info)
+		builder.L1.TransferBalance(t, "User", "User", common.Big0, builder.L1Info)
+		count, err = nodeInterface.GetBatchCount(&bind.CallOpts{})
+		Require(t, err)
+	}
+
+	// the first batch starts at genesis, and each batch's blocks follow on from the previous one's
+	genesis := builder.chainConfig.ArbitrumChainParams.GenesisBlockNum
+	nextBlock := genesis
+	var prevL1Block uint64
+	for batch := uint64(0); batch < count; batch++ {
+		meta, err := nodeInterface.GetBatchMetadata(&bind.CallOpts{}, batch)
+		Require(t, err)
+		if meta.FirstL2Block != nextBlock || meta.LastL2Block+1 < meta.FirstL2Block || meta.L1Block < prevL1Block {
+			Fatal(t, "batch", batch, "has unexpected metadata", meta, "after L1 block", prevL1Block, "and L2 block", nextBlock)
+		}
+		if meta.LastL2Block >= meta.FirstL2Block && meta.LastL2Block > genesis {
+			found, err := nodeInterface.FindBatchContainingBlock(&bind.CallOpts{}, meta.LastL2Block)
+			Require(t, err)
+			if found != batch {
+				Fatal(t, "batch", batch, "ends at block", meta.LastL2Block, "which is in batch", found)
+			}
+		}
+		nextBlock = meta.LastL2Block + 1
+		prevL1Block = meta.L1Block
+	}
+
+	if _, err := nodeInterface.GetBatchMetadata(&bind.CallOpts{}, count+100); err == nil {
+		Fatal(t, "GetBatchMetadata didn't fail for a batch that doesn't exist")
+	}
+}
+
+func TestNitroGenesisBlockAndBlockL1Num(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, builder.L2.Client)
+	Require(t, err)
+
+	genesis, err := nodeInterface.NitroGenesisBlock(&bind.CallOpts{})
+	Require(t, err)
+	if genesis.Uint64() != builder.chainConfig.ArbitrumChainParams.GenesisBlockNum {
+		Fatal(t, "wrong nitro genesis block", genesis, "expected", builder.chainConfig.ArbitrumChainParams.GenesisBlockNum)
+	}
+
+	builder.L2.TransferBalance(t, "Owner", "Owner", common.Big0, builder.L2Info)
+	latest, err := builder.L2.Client.HeaderByNumber(ctx, nil)
+	Require(t, err)
+	genesisHeader, err := builder.L2.Client.HeaderByNumber(ctx, genesis)
+	Require(t, err)
+
+	// the L1 block number should be read from the header, both at genesis and after it
+	for _, header := range []*types.Header{genesisHeader, latest} {
+		l1BlockNum, err := nodeInterface.BlockL1Num(&bind.CallOpts{}, header.Number.Uint64())
+		Require(t, err)
+		expected := types.DeserializeHeaderExtraInformation(header).L1BlockNumber
+		if l1BlockNum != expected {
+			Fatal(t, "block", header.Number, "has L1 block number", expected, "but BlockL1Num returned", l1BlockNum)
+		}
+	}
+
+	if _, err := nodeInterface.BlockL1Num(&bind.CallOpts{}, latest.Number.Uint64()+1000); err == nil {
+		Fatal(t, "BlockL1Num didn't fail for a block that doesn't exist yet")
+	}
+}
+
+func TestEstimateOutboxExecution(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	auth := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	arbSys, err := precompilesgen.NewArbSys(types.ArbSysAddress, builder.L2.Client)
+	Require(t, err)
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, builder.L2.Client)
+	Require(t, err)
+
+	// send to a simple target, once without calldata and once with
+	destination := common.HexToAddress("0x0102030405")
+	calldata := make([]byte, 100)
+	for i := range calldata {
+		calldata[i] = byte(i + 1)
+	}
+	var leaves []uint64
+	for _, data := range [][]byte{{}, calldata} {
+		auth.Value = big.NewInt(1e9)
+		tx, err := arbSys.SendTxToL1(&auth, destination, data)
+		Require(t, err)
+		receipt, err := builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+		for _, log := range receipt.Logs {
+			if parsed, err := arbSys.ParseL2ToL1Tx(*log); err == nil {
+				leaves = append(leaves, parsed.Position.Uint64())
+			}
+		}
+	}
+	if len(leaves) != 2 {
+		Fatal(t, "expected 2 sends but found", len(leaves))
+	}
+
+	plain, err := nodeInterface.EstimateOutboxExecution(&bind.CallOpts{}, leaves[0])
+	Require(t, err)
+	withData, err := nodeInterface.EstimateOutboxExecution(&bind.CallOpts{}, leaves[1])
+	Require(t, err)
+
+	// executing a value transfer through the outbox costs on the order of 100k L1 gas
+	if plain < 60000 || plain > 150000 {
+		Fatal(t, "estimate", plain, "isn't within tolerance of a plain outbox execution")
+	}
+
+	// both are proven against the same root, so only the calldata's costs should differ
+	words := arbmath.WordsForBytes(uint64(len(calldata)))
+	calldataGas := uint64(len(calldata))*params.TxDataNonZeroGasEIP2028 + (words*32-uint64(len(calldata)))*params.TxDataZeroGas
+	expected := plain + calldataGas + words*(params.Keccak256WordGas+2*params.CopyGas)
+	if withData != expected {
+		Fatal(t, "estimate with calldata", withData, "expected", expected)
+	}
+
+	if _, err := nodeInterface.EstimateOutboxExecution(&bind.CallOpts{}, leaves[1]+1); err == nil {
+		Fatal(t, "estimated a send that doesn't exist")
+	}
+}
+
+func TestGetStatsAtBlock(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 20
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, builder.L2.Client)
+	Require(t, err)
+	statsAt := func(block uint64) (uint64, uint64) {
+		t.Helper()
+		stats, err := nodeInterface.GetStatsAtBlock(&bind.CallOpts{Context: ctx}, block)
+		Require(t, err)
+		if stats.BlockNum.Uint64() != block {
+			Fatal(t, "stats for block", block, "are for block", stats.BlockNum)
+		}
+		return stats.Contracts, stats.Accounts
+	}
+	latest := func() uint64 {
+		t.Helper()
+		block, err := builder.L2.Client.BlockNumber(ctx)
+		Require(t, err)
+		return block
+	}
+
+	// between the two blocks, a contract is deployed and an empty account is funded
+	before := latest()
+	ownerTxOpts := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	builder.L2.DeploySimple(t, ownerTxOpts)
+	builder.L2Info.GenerateAccount("Fresh")
+	builder.L2.TransferBalance(t, "Owner", "Fresh", big.NewInt(1e12), builder.L2Info)
+	after := latest()
+
+	contractsBefore, accountsBefore := statsAt(before)
+	contractsAfter, accountsAfter := statsAt(after)
+	if contractsAfter-contractsBefore != 1 || accountsAfter-accountsBefore != 2 {
+		Fatal(t, "stats changed by", contractsAfter-contractsBefore, "contracts and", accountsAfter-accountsBefore, "accounts")
+	}
+
+	if _, err := nodeInterface.GetStatsAtBlock(&bind.CallOpts{Context: ctx}, after+1000); err == nil {
+		Fatal(t, "got stats for a future block")
+	}
+}
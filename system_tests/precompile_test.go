@@ -10,6 +10,8 @@ import (
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/solgen/go/mocksgen"
@@ -120,3 +122,137 @@ func TestPrecompileErrorGasLeft(t *testing.T) {
 	Require(t, err)
 	assertNotAllGasConsumed(common.HexToAddress("0xff"), arbDebug.Methods["legacyError"].ID)
 }
+
+func TestArbStatisticsCounts(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 20
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	arbStatistics, err := precompilesgen.NewArbStatistics(common.HexToAddress("0x6f"), builder.L2.Client)
+	Require(t, err)
+	callOpts := &bind.CallOpts{Context: ctx}
+	counts := func() (uint64, uint64) {
+		t.Helper()
+		contracts, err := arbStatistics.GetContractCount(callOpts)
+		Require(t, err)
+		accounts, err := arbStatistics.GetAccountCount(callOpts)
+		Require(t, err)
+		return contracts, accounts
+	}
+	contracts, accounts := counts()
+
+	// deploying a contract creates both a contract and an account
+	builder.L2.DeploySimple(t, builder.L2Info.GetDefaultTransactOpts("Owner", ctx))
+	newContracts, newAccounts := counts()
+	if newContracts != contracts+1 || newAccounts != accounts+1 {
+		Fatal(t, "deployment changed the counts from", contracts, accounts, "to", newContracts, newAccounts)
+	}
+	contracts, accounts = newContracts, newAccounts
+
+	// funding a fresh account creates an account, but funding it again doesn't
+	builder.L2Info.GenerateAccount("Fresh")
+	for i := 0; i < 2; i++ {
+		builder.L2.TransferBalance(t, "Owner", "Fresh", big.NewInt(1e12), builder.L2Info)
+		newContracts, newAccounts = counts()
+		if newContracts != contracts || newAccounts != accounts+1 {
+			Fatal(t, "funding an account", i+1, "times changed the counts from", contracts, accounts, "to", newContracts, newAccounts)
+		}
+	}
+
+	// deploying to an address that was already funded creates a contract, but not an account
+	owner := builder.L2Info.GetAddress("Owner")
+	nonce, err := builder.L2.Client.PendingNonceAt(ctx, owner)
+	Require(t, err)
+	builder.L2.TransferBalanceTo(t, "Faucet", crypto.CreateAddress(owner, nonce), big.NewInt(1e12), builder.L2Info)
+	contracts, accounts = counts()
+	builder.L2.DeploySimple(t, builder.L2Info.GetDefaultTransactOpts("Owner", ctx))
+	newContracts, newAccounts = counts()
+	if newContracts != contracts+1 || newAccounts != accounts {
+		Fatal(t, "deploying to a funded address changed the counts from", contracts, accounts, "to", newContracts, newAccounts)
+	}
+}
+
+func TestGetCurrentL1Context(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 20
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	arbSys, err := precompilesgen.NewArbSys(common.HexToAddress("0x64"), builder.L2.Client)
+	Require(t, err)
+
+	// each block reports the L1 context of the message it was made from
+	for i := 0; i < 3; i++ {
+		builder.L2.TransferBalance(t, "Owner", "Owner", common.Big0, builder.L2Info)
+		// move L1 along so that the next block is made in a different L1 context
+		for j := 0; j < 5; j++ {
+			builder.L1.SendWaitTestTransactions(t, []*types.Transaction{
+				builder.L1Info.PrepareTx("Faucet", "Faucet", 30000, common.Big0, nil),
+			})
+		}
+		header, err := builder.L2.Client.HeaderByNumber(ctx, nil)
+		Require(t, err)
+		l1Context, err := arbSys.GetCurrentL1Context(&bind.CallOpts{Context: ctx, BlockNumber: header.Number})
+		Require(t, err)
+		expected := types.DeserializeHeaderExtraInformation(header).L1BlockNumber
+		if l1Context.L1BlockNumber != expected || l1Context.L1Timestamp != header.Time {
+			Fatal(t, "block", header.Number, "reported L1 context", l1Context, "instead of", expected, header.Time)
+		}
+	}
+}
+
+func TestArbOSUpgradedEvent(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	builder := NewNodeBuilder(ctx).DefaultConfig(t, false)
+	builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 19
+	cleanup := builder.Build(t)
+	defer cleanup()
+
+	auth := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	arbOwner, err := precompilesgen.NewArbOwner(common.HexToAddress("0x70"), builder.L2.Client)
+	Require(t, err)
+	arbSys, err := precompilesgen.NewArbSys(common.HexToAddress("0x64"), builder.L2.Client)
+	Require(t, err)
+
+	tx, err := arbOwner.ScheduleArbOSUpgrade(&auth, 20, 0)
+	Require(t, err)
+	_, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+
+	// the upgrade happens at the start of the next block, and the ones after it don't upgrade again
+	for i := 0; i < 3; i++ {
+		builder.L2.TransferBalance(t, "Owner", "Owner", common.Big0, builder.L2Info)
+	}
+	version, err := arbSys.ArbOSVersion(&bind.CallOpts{Context: ctx})
+	Require(t, err)
+	if version.Uint64() != 55+20 {
+		Fatal(t, "didn't upgrade to ArbOS 20, at version", version)
+	}
+
+	iter, err := arbSys.FilterArbOSUpgraded(&bind.FilterOpts{Context: ctx, Start: 0})
+	Require(t, err)
+	defer iter.Close()
+	events := 0
+	for iter.Next() {
+		events++
+		if iter.Event.PreviousVersion != 19 || iter.Event.NewVersion != 20 {
+			Fatal(t, "upgrade reported versions", iter.Event.PreviousVersion, iter.Event.NewVersion)
+		}
+	}
+	Require(t, iter.Error())
+	if events != 1 {
+		Fatal(t, "upgrade emitted", events, "events instead of one")
+	}
+}
@@ -29,9 +29,10 @@ import (
 	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/util/colors"
+	"github.com/offchainlabs/nitro/util/testhelpers"
 )
 
-func retryableSetup(t *testing.T) (
+func retryableSetup(t *testing.T, modifyNodeConfig ...func(*NodeBuilder)) (
 	*NodeBuilder,
 	*bridgegen.Inbox,
 	func(*types.Receipt) *types.Transaction,
@@ -40,6 +41,9 @@ func retryableSetup(t *testing.T) (
 ) {
 	ctx, cancel := context.WithCancel(context.Background())
 	builder := NewNodeBuilder(ctx).DefaultConfig(t, true)
+	for _, f := range modifyNodeConfig {
+		f(builder)
+	}
 	builder.Build(t)
 
 	builder.L2Info.GenerateAccount("User2")
@@ -366,6 +370,781 @@ func TestSubmitRetryableFailThenRetry(t *testing.T) {
 	}
 }
 
+func TestRetryableEscrowedCallValue(t *testing.T) {
+	t.Parallel()
+	builder, delayedInbox, lookupL2Tx, ctx, teardown := retryableSetup(t, func(builder *NodeBuilder) {
+		builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 20
+	})
+	defer teardown()
+
+	ownerTxOpts := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	usertxopts := builder.L1Info.GetDefaultTransactOpts("Faucet", ctx)
+	usertxopts.Value = arbmath.BigMul(big.NewInt(1e12), big.NewInt(1e12))
+	target := testhelpers.RandomAddress()
+	beneficiaryAddress := builder.L2Info.GetAddress("Beneficiary")
+	callValue := big.NewInt(1e6)
+
+	// without any gas the ticket isn't auto-redeemed, so its callvalue stays escrowed
+	l1tx, err := delayedInbox.CreateRetryableTicket(
+		&usertxopts,
+		target,
+		callValue,
+		big.NewInt(1e16),
+		beneficiaryAddress,
+		beneficiaryAddress,
+		common.Big0,
+		big.NewInt(l2pricing.InitialBaseFeeWei*2),
+		[]byte{},
+	)
+	Require(t, err)
+	l1Receipt, err := builder.L1.EnsureTxSucceeded(l1tx)
+	Require(t, err)
+	if l1Receipt.Status != types.ReceiptStatusSuccessful {
+		Fatal(t, "l1Receipt indicated failure")
+	}
+	waitForL1DelayBlocks(t, ctx, builder)
+	receipt, err := builder.L2.EnsureTxSucceeded(lookupL2Tx(l1Receipt))
+	Require(t, err)
+	ticketId := receipt.Logs[0].Topics[1]
+
+	arbRetryableTx, err := precompilesgen.NewArbRetryableTx(common.HexToAddress("6e"), builder.L2.Client)
+	Require(t, err)
+	escrowed, err := arbRetryableTx.GetEscrowedCallValue(&bind.CallOpts{}, ticketId)
+	Require(t, err)
+	if !arbmath.BigEquals(escrowed, callValue) {
+		Fatal(t, "escrowed", escrowed, "instead of", callValue)
+	}
+
+	// redeeming delivers the escrow to the target
+	tx, err := arbRetryableTx.Redeem(&ownerTxOpts, ticketId)
+	Require(t, err)
+	receipt, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+	receipt, err = WaitForTx(ctx, builder.L2.Client, receipt.Logs[0].Topics[2], time.Second*1)
+	Require(t, err)
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		Fatal(t, "redeem failed")
+	}
+	if _, err := arbRetryableTx.GetEscrowedCallValue(&bind.CallOpts{}, ticketId); err == nil {
+		Fatal(t, "got the escrow of a redeemed ticket")
+	}
+	balance, err := builder.L2.Client.BalanceAt(ctx, target, nil)
+	Require(t, err)
+	if !arbmath.BigEquals(balance, callValue) {
+		Fatal(t, "target received", balance, "instead of", callValue)
+	}
+}
+
+func TestCalculateInboxTicketId(t *testing.T) {
+	t.Parallel()
+	builder, delayedInbox, lookupL2Tx, ctx, teardown := retryableSetup(t, func(builder *NodeBuilder) {
+		builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 20
+	})
+	defer teardown()
+
+	usertxopts := builder.L1Info.GetDefaultTransactOpts("Faucet", ctx)
+	usertxopts.Value = arbmath.BigMul(big.NewInt(1e12), big.NewInt(1e12))
+	beneficiaryAddress := builder.L2Info.GetAddress("Beneficiary")
+	to := testhelpers.RandomAddress()
+	maxSubmissionFee := big.NewInt(1e16)
+	maxFeePerGas := big.NewInt(l2pricing.InitialBaseFeeWei * 2)
+	data := testhelpers.RandomizeSlice(make([]byte, 100))
+
+	l1tx, err := delayedInbox.CreateRetryableTicket(
+		&usertxopts,
+		to,
+		common.Big0,
+		maxSubmissionFee,
+		beneficiaryAddress,
+		beneficiaryAddress,
+		common.Big0,
+		maxFeePerGas,
+		data,
+	)
+	Require(t, err)
+	l1Receipt, err := builder.L1.EnsureTxSucceeded(l1tx)
+	Require(t, err)
+	if l1Receipt.Status != types.ReceiptStatusSuccessful {
+		Fatal(t, "l1Receipt indicated failure")
+	}
+	waitForL1DelayBlocks(t, ctx, builder)
+
+	l2Tx := lookupL2Tx(l1Receipt)
+	submission, ok := l2Tx.GetInner().(*types.ArbitrumSubmitRetryableTx)
+	if !ok {
+		Fatal(t, "unexpected submission type", l2Tx.Type())
+	}
+	receipt, err := builder.L2.EnsureTxSucceeded(l2Tx)
+	Require(t, err)
+
+	// the id is computed from what was sent to the inbox, along with where the message landed and its L1 basefee
+	arbRetryableTx, err := precompilesgen.NewArbRetryableTx(common.HexToAddress("6e"), builder.L2.Client)
+	Require(t, err)
+	expected, err := arbRetryableTx.CalculateTicketId(
+		&bind.CallOpts{},
+		builder.L1Info.GetAddress("Faucet"),
+		submission.RequestId.Big().Uint64(),
+		submission.L1BaseFee,
+		usertxopts.Value,
+		to,
+		common.Big0,
+		maxSubmissionFee,
+		beneficiaryAddress,
+		beneficiaryAddress,
+		0,
+		maxFeePerGas,
+		data,
+	)
+	Require(t, err)
+	if ticketId := receipt.Logs[0].Topics[1]; ticketId != expected {
+		Fatal(t, "TicketCreated had id", ticketId, "but CalculateTicketId gave", common.Hash(expected))
+	}
+}
+
+func TestSimulateSubmitRetryable(t *testing.T) {
+	t.Parallel()
+	builder, delayedInbox, lookupL2Tx, ctx, teardown := retryableSetup(t, func(builder *NodeBuilder) {
+		builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 20
+	})
+	defer teardown()
+
+	ownerTxOpts := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	simpleAddr, _ := builder.L2.DeploySimple(t, ownerTxOpts)
+	simpleABI, err := mocksgen.SimpleMetaData.GetAbi()
+	Require(t, err)
+	increment := simpleABI.Methods["increment"].ID
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, builder.L2.Client)
+	Require(t, err)
+	arbRetryableTx, err := precompilesgen.NewArbRetryableTx(common.HexToAddress("6e"), builder.L2.Client)
+	Require(t, err)
+	beneficiaryAddress := builder.L2Info.GetAddress("Beneficiary")
+	sender := builder.L1Info.GetAddress("Faucet")
+	deposit := arbmath.BigMul(big.NewInt(1e12), big.NewInt(1e12))
+	callValue := big.NewInt(1e6)
+	maxSubmissionFee := big.NewInt(1e16)
+
+	// submit creates a retryable from L1, then simulates it against the state it was submitted on top of
+	submit := func(gasLimit uint64, data []byte) {
+		t.Helper()
+		maxFeePerGas := big.NewInt(l2pricing.InitialBaseFeeWei * 2)
+		if gasLimit == 0 {
+			maxFeePerGas = common.Big0
+		}
+		usertxopts := builder.L1Info.GetDefaultTransactOpts("Faucet", ctx)
+		usertxopts.Value = deposit
+		l1tx, err := delayedInbox.CreateRetryableTicket(
+			&usertxopts,
+			simpleAddr,
+			callValue,
+			maxSubmissionFee,
+			beneficiaryAddress,
+			beneficiaryAddress,
+			arbmath.UintToBig(gasLimit),
+			maxFeePerGas,
+			data,
+		)
+		Require(t, err)
+		l1Receipt, err := builder.L1.EnsureTxSucceeded(l1tx)
+		Require(t, err)
+		waitForL1DelayBlocks(t, ctx, builder)
+
+		l2Tx := lookupL2Tx(l1Receipt)
+		submission, ok := l2Tx.GetInner().(*types.ArbitrumSubmitRetryableTx)
+		if !ok {
+			Fatal(t, "unexpected submission type", l2Tx.Type())
+		}
+		receipt, err := builder.L2.EnsureTxSucceeded(l2Tx)
+		Require(t, err)
+		ticketId := receipt.Logs[0].Topics[1]
+		autoRedeemSucceeded := false
+		if len(receipt.Logs) > 1 {
+			retryReceipt, err := WaitForTx(ctx, builder.L2.Client, receipt.Logs[1].Topics[2], time.Second*5)
+			Require(t, err)
+			autoRedeemSucceeded = retryReceipt.Status == types.ReceiptStatusSuccessful
+		}
+
+		simulated, err := nodeInterface.SimulateSubmitRetryable(
+			&bind.CallOpts{Context: ctx, BlockNumber: arbmath.BigSub(receipt.BlockNumber, common.Big1)},
+			sender,
+			deposit,
+			simpleAddr,
+			callValue,
+			maxSubmissionFee,
+			beneficiaryAddress,
+			beneficiaryAddress,
+			gasLimit,
+			maxFeePerGas,
+			data,
+			submission.L1BaseFee,
+			submission.RequestId.Big().Uint64(),
+		)
+		Require(t, err)
+		if common.Hash(simulated.TicketId) != ticketId {
+			Fatal(t, "simulated ticket id", common.Hash(simulated.TicketId), "but the submission created", ticketId)
+		}
+		fee := retryables.RetryableSubmissionFee(len(data), submission.L1BaseFee)
+		if !arbmath.BigEquals(simulated.SubmissionFee, fee) {
+			Fatal(t, "simulated submission fee", simulated.SubmissionFee, "but the submission was charged", fee)
+		}
+		if simulated.AutoRedeemSucceeds != autoRedeemSucceeded {
+			Fatal(t, "simulated auto-redeem success", simulated.AutoRedeemSucceeds, "but the auto-redeem's result was", autoRedeemSucceeded)
+		}
+	}
+
+	submit(1000000, increment)
+	submit(params.TxGas+params.TxDataNonZeroGasEIP2028*4, increment) // enough for intrinsic but not compute
+	submit(0, increment)
+
+	// simulating never submits anything
+	simulated, err := nodeInterface.SimulateSubmitRetryable(
+		&bind.CallOpts{Context: ctx}, sender, deposit, simpleAddr, callValue, maxSubmissionFee, beneficiaryAddress,
+		beneficiaryAddress, 1000000, big.NewInt(l2pricing.InitialBaseFeeWei*2), increment, common.Big0, 1<<40,
+	)
+	Require(t, err)
+	if _, err := arbRetryableTx.GetTimeout(&bind.CallOpts{}, simulated.TicketId); err == nil {
+		Fatal(t, "simulation created a retryable")
+	}
+}
+
+func TestAutoRedeemResult(t *testing.T) {
+	t.Parallel()
+	builder, delayedInbox, lookupL2Tx, ctx, teardown := retryableSetup(t, func(builder *NodeBuilder) {
+		builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 20
+	})
+	defer teardown()
+
+	ownerTxOpts := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	simpleAddr, _ := builder.L2.DeploySimple(t, ownerTxOpts)
+	simpleABI, err := mocksgen.SimpleMetaData.GetAbi()
+	Require(t, err)
+	arbRetryableTx, err := precompilesgen.NewArbRetryableTx(common.HexToAddress("6e"), builder.L2.Client)
+	Require(t, err)
+	beneficiaryAddress := builder.L2Info.GetAddress("Beneficiary")
+
+	// submit creates a retryable from L1, returning its ticket id & the id of its auto-redeem, if one was scheduled
+	submit := func(to common.Address, gasLimit uint64, data []byte) (common.Hash, common.Hash) {
+		t.Helper()
+		usertxopts := builder.L1Info.GetDefaultTransactOpts("Faucet", ctx)
+		usertxopts.Value = arbmath.BigMul(big.NewInt(1e12), big.NewInt(1e12))
+		maxFeePerGas := big.NewInt(l2pricing.InitialBaseFeeWei * 2)
+		if gasLimit == 0 {
+			maxFeePerGas = common.Big0
+		}
+		l1tx, err := delayedInbox.CreateRetryableTicket(
+			&usertxopts,
+			to,
+			common.Big0,
+			big.NewInt(1e16),
+			beneficiaryAddress,
+			beneficiaryAddress,
+			arbmath.UintToBig(gasLimit),
+			maxFeePerGas,
+			data,
+		)
+		Require(t, err)
+		l1Receipt, err := builder.L1.EnsureTxSucceeded(l1tx)
+		Require(t, err)
+
+		waitForL1DelayBlocks(t, ctx, builder)
+
+		receipt, err := builder.L2.EnsureTxSucceeded(lookupL2Tx(l1Receipt))
+		Require(t, err)
+		ticketId := receipt.Logs[0].Topics[1]
+		if len(receipt.Logs) < 2 {
+			return ticketId, common.Hash{}
+		}
+		retryTxId := receipt.Logs[1].Topics[2]
+		_, err = WaitForTx(ctx, builder.L2.Client, retryTxId, time.Second*5)
+		Require(t, err)
+		return ticketId, retryTxId
+	}
+	expect := func(ticketId common.Hash, attempted, succeeded bool, retryTxId common.Hash) {
+		t.Helper()
+		result, err := arbRetryableTx.GetAutoRedeemResult(&bind.CallOpts{}, ticketId)
+		Require(t, err)
+		if result.Attempted != attempted || result.Succeeded != succeeded || result.RedeemTxId != retryTxId {
+			Fatal(t, "unexpected auto-redeem result", result.Attempted, result.Succeeded, result.RedeemTxId)
+		}
+	}
+
+	incrementRedeem := simpleABI.Methods["incrementRedeem"].ID
+
+	// a successful auto-redeem deletes the ticket, and its outcome along with it
+	ticketId, _ := submit(simpleAddr, 1000000, incrementRedeem)
+	expect(ticketId, false, false, common.Hash{})
+
+	// send enough L2 gas for intrinsic but not compute
+	ticketId, retryTxId := submit(simpleAddr, params.TxGas+params.TxDataNonZeroGasEIP2028*4, incrementRedeem)
+	expect(ticketId, true, false, retryTxId)
+
+	// a manual redeem succeeding deletes the ticket, which clears the failed auto-redeem's outcome
+	tx, err := arbRetryableTx.Redeem(&ownerTxOpts, ticketId)
+	Require(t, err)
+	receipt, err := builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+	receipt, err = WaitForTx(ctx, builder.L2.Client, receipt.Logs[0].Topics[2], time.Second*5)
+	Require(t, err)
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		Fatal(t, "manual redeem failed")
+	}
+	expect(ticketId, false, false, common.Hash{})
+
+	ticketId, _ = submit(simpleAddr, 0, incrementRedeem)
+	expect(ticketId, false, false, common.Hash{})
+}
+
+func TestLookupRedeemTx(t *testing.T) {
+	t.Parallel()
+	builder, delayedInbox, lookupL2Tx, ctx, teardown := retryableSetup(t)
+	defer teardown()
+
+	ownerTxOpts := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	usertxopts := builder.L1Info.GetDefaultTransactOpts("Faucet", ctx)
+	usertxopts.Value = arbmath.BigMul(big.NewInt(1e12), big.NewInt(1e12))
+
+	simpleAddr, _ := builder.L2.DeploySimple(t, ownerTxOpts)
+	simpleABI, err := mocksgen.SimpleMetaData.GetAbi()
+	Require(t, err)
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, builder.L2.Client)
+	Require(t, err)
+
+	beneficiaryAddress := builder.L2Info.GetAddress("Beneficiary")
+	l1tx, err := delayedInbox.CreateRetryableTicket(
+		&usertxopts,
+		simpleAddr,
+		common.Big0,
+		big.NewInt(1e16),
+		beneficiaryAddress,
+		beneficiaryAddress,
+		// send enough L2 gas for intrinsic but not compute
+		big.NewInt(int64(params.TxGas+params.TxDataNonZeroGasEIP2028*4)),
+		big.NewInt(l2pricing.InitialBaseFeeWei*2),
+		simpleABI.Methods["incrementRedeem"].ID,
+	)
+	Require(t, err)
+	l1Receipt, err := builder.L1.EnsureTxSucceeded(l1tx)
+	Require(t, err)
+
+	waitForL1DelayBlocks(t, ctx, builder)
+
+	submissionTx := lookupL2Tx(l1Receipt)
+	receipt, err := builder.L2.EnsureTxSucceeded(submissionTx)
+	Require(t, err)
+	ticketId := receipt.Logs[0].Topics[1]
+	autoRedeemTxId := receipt.Logs[1].Topics[2]
+	_, err = WaitForTx(ctx, builder.L2.Client, autoRedeemTxId, time.Second*5)
+	Require(t, err)
+
+	arbRetryableTx, err := precompilesgen.NewArbRetryableTx(common.HexToAddress("6e"), builder.L2.Client)
+	Require(t, err)
+	tx, err := arbRetryableTx.Redeem(&ownerTxOpts, ticketId)
+	Require(t, err)
+	receipt, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+	redeemTxId := receipt.Logs[0].Topics[2]
+	_, err = WaitForTx(ctx, builder.L2.Client, redeemTxId, time.Second*5)
+	Require(t, err)
+
+	// the auto-redeem is the ticket's first attempt, and the manual redeem its second
+	for sequenceNum, retryTxId := range []common.Hash{autoRedeemTxId, redeemTxId} {
+		result, err := nodeInterface.LookupRedeemTx(&bind.CallOpts{}, retryTxId)
+		Require(t, err)
+		if result.TicketId != ticketId || result.SequenceNum != uint64(sequenceNum) {
+			Fatal(t, "redeem", retryTxId, "reversed to ticket", result.TicketId, "attempt", result.SequenceNum)
+		}
+	}
+
+	for _, notRedeem := range []common.Hash{submissionTx.Hash(), tx.Hash(), {}} {
+		if _, err := nodeInterface.LookupRedeemTx(&bind.CallOpts{}, notRedeem); err == nil {
+			Fatal(t, "looked up a redeem for", notRedeem)
+		}
+	}
+}
+
+func TestGetRetryableInfoAtBlock(t *testing.T) {
+	t.Parallel()
+	builder, delayedInbox, lookupL2Tx, ctx, teardown := retryableSetup(t)
+	defer teardown()
+
+	ownerTxOpts := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	usertxopts := builder.L1Info.GetDefaultTransactOpts("Faucet", ctx)
+	usertxopts.Value = arbmath.BigMul(big.NewInt(1e12), big.NewInt(1e12))
+
+	simpleAddr, _ := builder.L2.DeploySimple(t, ownerTxOpts)
+	simpleABI, err := mocksgen.SimpleMetaData.GetAbi()
+	Require(t, err)
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, builder.L2.Client)
+	Require(t, err)
+	arbRetryableTx, err := precompilesgen.NewArbRetryableTx(common.HexToAddress("6e"), builder.L2.Client)
+	Require(t, err)
+
+	beneficiaryAddress := builder.L2Info.GetAddress("Beneficiary")
+	l1tx, err := delayedInbox.CreateRetryableTicket(
+		&usertxopts,
+		simpleAddr,
+		common.Big0,
+		big.NewInt(1e16),
+		beneficiaryAddress,
+		beneficiaryAddress,
+		// send enough L2 gas for intrinsic but not compute, so the auto-redeem fails and the ticket stays
+		big.NewInt(int64(params.TxGas+params.TxDataNonZeroGasEIP2028*4)),
+		big.NewInt(l2pricing.InitialBaseFeeWei*2),
+		simpleABI.Methods["incrementRedeem"].ID,
+	)
+	Require(t, err)
+	l1Receipt, err := builder.L1.EnsureTxSucceeded(l1tx)
+	Require(t, err)
+
+	waitForL1DelayBlocks(t, ctx, builder)
+
+	receipt, err := builder.L2.EnsureTxSucceeded(lookupL2Tx(l1Receipt))
+	Require(t, err)
+	ticketId := receipt.Logs[0].Topics[1]
+	autoRedeemTxId := receipt.Logs[1].Topics[2]
+	_, err = WaitForTx(ctx, builder.L2.Client, autoRedeemTxId, time.Second*5)
+	Require(t, err)
+	creationBlock := receipt.BlockNumber.Uint64()
+
+	infoAt := func(block uint64) (common.Address, uint64, uint64, error) {
+		t.Helper()
+		info, err := nodeInterface.GetRetryableInfoAtBlock(&bind.CallOpts{}, ticketId, block)
+		return info.Beneficiary, info.Timeout, info.NumTries, err
+	}
+
+	// the ticket didn't exist before it was submitted
+	if _, _, _, err := infoAt(creationBlock - 1); err == nil {
+		Fatal(t, "found the ticket before the block it was created in")
+	}
+
+	// it was created with the failed auto-redeem as its first attempt
+	beneficiary, timeout, numTries, err := infoAt(creationBlock)
+	Require(t, err)
+	if beneficiary != beneficiaryAddress || numTries != 1 {
+		Fatal(t, "ticket was created with beneficiary", beneficiary, "and", numTries, "tries")
+	}
+
+	// extending its lifetime is only seen from the block that did it on
+	tx, err := arbRetryableTx.Keepalive(&ownerTxOpts, ticketId)
+	Require(t, err)
+	receipt, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+	keepaliveBlock := receipt.BlockNumber.Uint64()
+	_, before, _, err := infoAt(keepaliveBlock - 1)
+	Require(t, err)
+	_, after, _, err := infoAt(keepaliveBlock)
+	Require(t, err)
+	if before != timeout || after != timeout+retryables.RetryableLifetimeSeconds {
+		Fatal(t, "keepalive changed the timeout from", before, "to", after, "instead of from", timeout)
+	}
+
+	// and redeeming it deletes it, though the earlier state remains readable
+	tx, err = arbRetryableTx.Redeem(&ownerTxOpts, ticketId)
+	Require(t, err)
+	receipt, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+	_, err = WaitForTx(ctx, builder.L2.Client, receipt.Logs[0].Topics[2], time.Second*5)
+	Require(t, err)
+	redeemBlock := receipt.BlockNumber.Uint64()
+	if _, _, _, err := infoAt(redeemBlock); err == nil {
+		Fatal(t, "found the ticket after it was redeemed")
+	}
+	_, _, numTries, err = infoAt(redeemBlock - 1)
+	Require(t, err)
+	if numTries != 1 {
+		Fatal(t, "ticket had", numTries, "tries before it was redeemed")
+	}
+}
+
+func TestRetryableCreatorAllowlist(t *testing.T) {
+	t.Parallel()
+	builder, delayedInbox, lookupL2Tx, ctx, teardown := retryableSetup(t, func(builder *NodeBuilder) {
+		builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 20
+	})
+	defer teardown()
+
+	ownerTxOpts := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	arbOwner, err := precompilesgen.NewArbOwner(common.HexToAddress("0x70"), builder.L2.Client)
+	Require(t, err)
+	beneficiaryAddress := builder.L2Info.GetAddress("Beneficiary")
+	creator := builder.L1Info.GetAddress("Faucet")
+
+	// submit creates a retryable from L1 and returns whether the submission succeeded on L2
+	submit := func() bool {
+		t.Helper()
+		usertxopts := builder.L1Info.GetDefaultTransactOpts("Faucet", ctx)
+		usertxopts.Value = arbmath.BigMul(big.NewInt(1e12), big.NewInt(1e12))
+		l1tx, err := delayedInbox.CreateRetryableTicket(
+			&usertxopts,
+			beneficiaryAddress,
+			common.Big0,
+			big.NewInt(1e16),
+			beneficiaryAddress,
+			beneficiaryAddress,
+			common.Big0,
+			common.Big0,
+			[]byte{},
+		)
+		Require(t, err)
+		l1Receipt, err := builder.L1.EnsureTxSucceeded(l1tx)
+		Require(t, err)
+
+		waitForL1DelayBlocks(t, ctx, builder)
+
+		receipt, err := WaitForTx(ctx, builder.L2.Client, lookupL2Tx(l1Receipt).Hash(), time.Second*5)
+		Require(t, err)
+		return receipt.Status == types.ReceiptStatusSuccessful
+	}
+	ownerDo := func(tx *types.Transaction, err error) {
+		t.Helper()
+		Require(t, err)
+		_, err = builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+	}
+
+	if !submit() {
+		Fatal(t, "submission failed with the allowlist disabled")
+	}
+
+	ownerDo(arbOwner.SetRetryableCreationAllowlist(&ownerTxOpts, true))
+	if submit() {
+		Fatal(t, "submission from a sender that isn't allowed succeeded")
+	}
+
+	ownerDo(arbOwner.AddRetryableCreator(&ownerTxOpts, creator))
+	if !submit() {
+		Fatal(t, "submission from an allowed sender failed")
+	}
+
+	ownerDo(arbOwner.RemoveRetryableCreator(&ownerTxOpts, creator))
+	if submit() {
+		Fatal(t, "submission from a removed sender succeeded")
+	}
+
+	ownerDo(arbOwner.SetRetryableCreationAllowlist(&ownerTxOpts, false))
+	if !submit() {
+		Fatal(t, "submission failed after disabling the allowlist")
+	}
+}
+
+func TestRetryableMinDeposit(t *testing.T) {
+	t.Parallel()
+	builder, delayedInbox, lookupL2Tx, ctx, teardown := retryableSetup(t, func(builder *NodeBuilder) {
+		builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 20
+	})
+	defer teardown()
+
+	ownerTxOpts := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	arbOwner, err := precompilesgen.NewArbOwner(common.HexToAddress("0x70"), builder.L2.Client)
+	Require(t, err)
+	beneficiaryAddress := builder.L2Info.GetAddress("Beneficiary")
+	deposit := arbmath.BigMul(big.NewInt(1e12), big.NewInt(1e12))
+
+	// submit creates a retryable from L1 and returns whether the submission succeeded on L2
+	submit := func() bool {
+		t.Helper()
+		usertxopts := builder.L1Info.GetDefaultTransactOpts("Faucet", ctx)
+		usertxopts.Value = deposit
+		l1tx, err := delayedInbox.CreateRetryableTicket(
+			&usertxopts,
+			beneficiaryAddress,
+			common.Big0,
+			big.NewInt(1e16),
+			beneficiaryAddress,
+			beneficiaryAddress,
+			common.Big0,
+			common.Big0,
+			[]byte{},
+		)
+		Require(t, err)
+		l1Receipt, err := builder.L1.EnsureTxSucceeded(l1tx)
+		Require(t, err)
+
+		waitForL1DelayBlocks(t, ctx, builder)
+
+		receipt, err := WaitForTx(ctx, builder.L2.Client, lookupL2Tx(l1Receipt).Hash(), time.Second*5)
+		Require(t, err)
+		return receipt.Status == types.ReceiptStatusSuccessful
+	}
+	setMinimum := func(minimum *big.Int) {
+		t.Helper()
+		tx, err := arbOwner.SetMinRetryableDeposit(&ownerTxOpts, minimum)
+		Require(t, err)
+		_, err = builder.L2.EnsureTxSucceeded(tx)
+		Require(t, err)
+	}
+
+	setMinimum(arbmath.BigSubByUint(deposit, 1))
+	if !submit() {
+		Fatal(t, "submission above the minimum deposit failed")
+	}
+	setMinimum(deposit)
+	if !submit() {
+		Fatal(t, "submission at the minimum deposit failed")
+	}
+	setMinimum(arbmath.BigAddByUint(deposit, 1))
+	if submit() {
+		Fatal(t, "submission below the minimum deposit succeeded")
+	}
+}
+
+func TestRetryableMaxTries(t *testing.T) {
+	t.Parallel()
+	builder, delayedInbox, lookupL2Tx, ctx, teardown := retryableSetup(t, func(builder *NodeBuilder) {
+		builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 20
+	})
+	defer teardown()
+
+	ownerTxOpts := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	arbOwner, err := precompilesgen.NewArbOwner(common.HexToAddress("0x70"), builder.L2.Client)
+	Require(t, err)
+	arbRetryableTx, err := precompilesgen.NewArbRetryableTx(common.HexToAddress("6e"), builder.L2.Client)
+	Require(t, err)
+	tx, err := arbOwner.SetRetryableMaxTries(&ownerTxOpts, 2)
+	Require(t, err)
+	_, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+
+	// ArbSys reverts on an unknown selector, so every attempt fails however much gas it has
+	usertxopts := builder.L1Info.GetDefaultTransactOpts("Faucet", ctx)
+	usertxopts.Value = arbmath.BigMul(big.NewInt(1e12), big.NewInt(1e12))
+	beneficiaryAddress := builder.L2Info.GetAddress("Beneficiary")
+	l1tx, err := delayedInbox.CreateRetryableTicket(
+		&usertxopts,
+		types.ArbSysAddress,
+		common.Big0,
+		big.NewInt(1e16),
+		beneficiaryAddress,
+		beneficiaryAddress,
+		big.NewInt(100000),
+		big.NewInt(l2pricing.InitialBaseFeeWei*2),
+		[]byte{0xde, 0xad, 0xbe, 0xef},
+	)
+	Require(t, err)
+	l1Receipt, err := builder.L1.EnsureTxSucceeded(l1tx)
+	Require(t, err)
+	waitForL1DelayBlocks(t, ctx, builder)
+
+	receipt, err := builder.L2.EnsureTxSucceeded(lookupL2Tx(l1Receipt))
+	Require(t, err)
+	ticketId := receipt.Logs[0].Topics[1]
+	receipt, err = WaitForTx(ctx, builder.L2.Client, receipt.Logs[1].Topics[2], time.Second*5)
+	Require(t, err)
+	if receipt.Status != types.ReceiptStatusFailed {
+		Fatal(t, "auto-redeem of a call ArbSys rejects succeeded")
+	}
+
+	// one failed attempt leaves the ticket with one more
+	_, err = arbRetryableTx.GetTimeout(&bind.CallOpts{}, ticketId)
+	Require(t, err)
+	tx, err = arbRetryableTx.Redeem(&ownerTxOpts, ticketId)
+	Require(t, err)
+	receipt, err = builder.L2.EnsureTxSucceeded(tx)
+	Require(t, err)
+	receipt, err = WaitForTx(ctx, builder.L2.Client, receipt.Logs[0].Topics[2], time.Second*5)
+	Require(t, err)
+	if receipt.Status != types.ReceiptStatusFailed {
+		Fatal(t, "redeem of a call ArbSys rejects succeeded")
+	}
+
+	// and failing that one deletes it
+	retryABI, err := precompilesgen.ArbRetryableTxMetaData.GetAbi()
+	Require(t, err)
+	expired := false
+	for _, log := range receipt.Logs {
+		if log.Topics[0] == retryABI.Events["Expired"].ID && log.Topics[1] == ticketId {
+			expired = true
+		}
+	}
+	if !expired {
+		Fatal(t, "exhausting a retryable's tries didn't emit Expired")
+	}
+	_, err = arbRetryableTx.GetTimeout(&bind.CallOpts{}, ticketId)
+	if err == nil || err.Error() != "execution reverted: error NoTicketWithID()" {
+		Fatal(t, "retryable with no tries left wasn't deleted", err)
+	}
+}
+
+func TestRetryableTargets(t *testing.T) {
+	t.Parallel()
+	builder, delayedInbox, lookupL2Tx, ctx, teardown := retryableSetup(t, func(builder *NodeBuilder) {
+		builder.chainConfig.ArbitrumChainParams.InitialArbOSVersion = 20
+	})
+	defer teardown()
+
+	ownerTxOpts := builder.L2Info.GetDefaultTransactOpts("Owner", ctx)
+	simpleAddr, simple := builder.L2.DeploySimple(t, ownerTxOpts)
+	simpleABI, err := mocksgen.SimpleMetaData.GetAbi()
+	Require(t, err)
+	tableABI, err := precompilesgen.ArbAddressTableMetaData.GetAbi()
+	Require(t, err)
+	addressTable, err := precompilesgen.NewArbAddressTable(common.HexToAddress("0x66"), builder.L2.Client)
+	Require(t, err)
+	beneficiaryAddress := builder.L2Info.GetAddress("Beneficiary")
+
+	// submit creates a retryable from L1, returning whether it was created and whether its auto-redeem succeeded
+	submit := func(to common.Address, data []byte) (bool, bool) {
+		t.Helper()
+		usertxopts := builder.L1Info.GetDefaultTransactOpts("Faucet", ctx)
+		usertxopts.Value = arbmath.BigMul(big.NewInt(1e12), big.NewInt(1e12))
+		l1tx, err := delayedInbox.CreateRetryableTicket(
+			&usertxopts,
+			to,
+			common.Big0,
+			big.NewInt(1e16),
+			beneficiaryAddress,
+			beneficiaryAddress,
+			big.NewInt(1000000),
+			big.NewInt(l2pricing.InitialBaseFeeWei*2),
+			data,
+		)
+		Require(t, err)
+		l1Receipt, err := builder.L1.EnsureTxSucceeded(l1tx)
+		Require(t, err)
+		waitForL1DelayBlocks(t, ctx, builder)
+
+		receipt, err := WaitForTx(ctx, builder.L2.Client, lookupL2Tx(l1Receipt).Hash(), time.Second*5)
+		Require(t, err)
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			return false, false
+		}
+		receipt, err = WaitForTx(ctx, builder.L2.Client, receipt.Logs[1].Topics[2], time.Second*5)
+		Require(t, err)
+		return true, receipt.Status == types.ReceiptStatusSuccessful
+	}
+
+	if created, redeemed := submit(testhelpers.RandomAddress(), []byte{}); !created || !redeemed {
+		Fatal(t, "retryable to an EOA wasn't redeemed", created, redeemed)
+	}
+	if created, redeemed := submit(simpleAddr, simpleABI.Methods["incrementRedeem"].ID); !created || !redeemed {
+		Fatal(t, "retryable to a contract wasn't redeemed", created, redeemed)
+	}
+	counter, err := simple.Counter(&bind.CallOpts{})
+	Require(t, err)
+	if counter != 1 {
+		Fatal(t, "retryable to a contract didn't call it")
+	}
+
+	// redeems dispatch to precompiles like any other call
+	registered := testhelpers.RandomAddress()
+	register, err := tableABI.Pack("register", registered)
+	Require(t, err)
+	if created, redeemed := submit(common.HexToAddress("0x66"), register); !created || !redeemed {
+		Fatal(t, "retryable to a precompile wasn't redeemed", created, redeemed)
+	}
+	exists, err := addressTable.AddressExists(&bind.CallOpts{}, registered)
+	Require(t, err)
+	if !exists {
+		Fatal(t, "retryable to a precompile didn't call it")
+	}
+
+	// but retryables to addresses they could never call aren't created
+	for _, target := range []common.Address{types.ArbosAddress, types.NodeInterfaceAddress, types.NodeInterfaceDebugAddress} {
+		if created, _ := submit(target, []byte{}); created {
+			Fatal(t, "created a retryable to", target)
+		}
+	}
+}
+
 func TestSubmissionGasCosts(t *testing.T) {
 	t.Parallel()
 	builder, delayedInbox, lookupL2Tx, ctx, teardown := retryableSetup(t)